@@ -0,0 +1,187 @@
+package gtfs
+
+import (
+	"fmt"
+	"io"
+)
+
+// An Attribution identifies an organization involved in producing, operating,
+// or regulating the service described by a feed.
+//
+// An Attribution applies to at most one of Agency, Route, or Trip. If none of
+// them is set, it applies to the entire feed.
+//
+// Fields correspond directly to columns in attributions.txt.
+type Attribution struct {
+	ID               string
+	Agency           *Agency
+	Route            *Route
+	Trip             *Trip
+	OrganizationName string
+	IsProducer       bool
+	IsOperator       bool
+	IsAuthority      bool
+	URL              string
+	Email            string
+	Phone            string
+}
+
+var attributionFields = map[string]bool{
+	"attribution_id":    false,
+	"agency_id":         false,
+	"route_id":          false,
+	"trip_id":           false,
+	"organization_name": true,
+	"is_producer":       false,
+	"is_operator":       false,
+	"is_authority":      false,
+	"attribution_url":   false,
+	"attribution_email": false,
+	"attribution_phone": false,
+}
+
+func (g *GTFS) processAttributions(r io.Reader) error {
+	res, err := readCSVWithHeadings(r, attributionFields, g.strictMode)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range res {
+		isProducer, err := parseOptionalBool(row["is_producer"])
+		if err != nil {
+			return fmt.Errorf("invalid is_producer: %v", err)
+		}
+
+		isOperator, err := parseOptionalBool(row["is_operator"])
+		if err != nil {
+			return fmt.Errorf("invalid is_operator: %v", err)
+		}
+
+		isAuthority, err := parseOptionalBool(row["is_authority"])
+		if err != nil {
+			return fmt.Errorf("invalid is_authority: %v", err)
+		}
+
+		if g.strictMode && !isProducer && !isOperator && !isAuthority {
+			return fmt.Errorf("attribution for %s has no role", row["organization_name"])
+		}
+
+		a := &Attribution{
+			ID:               row["attribution_id"],
+			OrganizationName: row["organization_name"],
+			IsProducer:       isProducer,
+			IsOperator:       isOperator,
+			IsAuthority:      isAuthority,
+			URL:              row["attribution_url"],
+			Email:            row["attribution_email"],
+			Phone:            row["attribution_phone"],
+		}
+
+		// Outside of strict mode, attributions referencing unknown entities
+		// are skipped, rather than applying to the whole feed
+		refs := 0
+		if id := row["agency_id"]; id != "" {
+			a.Agency = g.agencyByID(id)
+			if a.Agency == nil {
+				if g.strictMode {
+					return fmt.Errorf("invalid agency ID: %s", id)
+				}
+
+				continue
+			}
+			refs++
+		}
+
+		if id := row["route_id"]; id != "" {
+			a.Route = g.routeByID(id)
+			if a.Route == nil {
+				if g.strictMode {
+					return fmt.Errorf("invalid route ID: %s", id)
+				}
+
+				continue
+			}
+			refs++
+		}
+
+		if id := row["trip_id"]; id != "" {
+			a.Trip = g.tripByID(id)
+			if a.Trip == nil {
+				if g.strictMode {
+					return fmt.Errorf("invalid trip ID: %s", id)
+				}
+
+				continue
+			}
+			refs++
+		}
+
+		if g.strictMode && refs > 1 {
+			return fmt.Errorf("attribution for %s references more than one of agency_id, route_id, and trip_id", a.OrganizationName)
+		}
+
+		g.Attributions = append(g.Attributions, a)
+	}
+
+	return nil
+}
+
+// IsFeedWide reports whether a applies to the entire feed.
+func (a *Attribution) IsFeedWide() bool {
+	return a.Agency == nil && a.Route == nil && a.Trip == nil
+}
+
+// AttributionsForAgency returns all attributions that apply to agency a,
+// including those that apply to the entire feed.
+func (g *GTFS) AttributionsForAgency(a *Agency) []*Attribution {
+	var res []*Attribution
+	for _, attr := range g.Attributions {
+		if attr.IsFeedWide() || (a != nil && attr.Agency == a) {
+			res = append(res, attr)
+		}
+	}
+
+	return res
+}
+
+// AttributionsForRoute returns all attributions that apply to route r,
+// including those that apply to its agency or to the entire feed.
+func (g *GTFS) AttributionsForRoute(r *Route) []*Attribution {
+	var res []*Attribution
+	for _, attr := range g.Attributions {
+		if attr.IsFeedWide() || (r != nil && (attr.Route == r || (attr.Agency != nil && attr.Agency == r.Agency))) {
+			res = append(res, attr)
+		}
+	}
+
+	return res
+}
+
+// AttributionsForTrip returns all attributions that apply to trip t, including
+// those that apply to its route, its route's agency, or the entire feed.
+func (g *GTFS) AttributionsForTrip(t *Trip) []*Attribution {
+	var res []*Attribution
+	for _, attr := range g.Attributions {
+		switch {
+		case attr.IsFeedWide():
+		case t == nil:
+			continue
+		case attr.Trip != nil:
+			if attr.Trip != t {
+				continue
+			}
+		case attr.Route != nil:
+			if attr.Route != t.Route {
+				continue
+			}
+		case attr.Agency != nil:
+			if t.Route == nil || attr.Agency != t.Route.Agency {
+				continue
+			}
+		}
+
+		res = append(res, attr)
+	}
+
+	return res
+}
@@ -0,0 +1,206 @@
+package gtfs
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testAttributionsCSVValid = `attribution_id,agency_id,route_id,trip_id,organization_name,is_producer,is_operator,is_authority,attribution_url,attribution_email,attribution_phone
+a1,,,,Regional Data Co,1,,,https://data.example.com,data@example.com,
+a2,agency-1,,,Metro Operations,0,1,0,,,555-0100
+a3,,route-1,,City Transit Authority,,,1,,,
+a4,,,trip-1,Contract Operator,,1,,,,`
+
+const testAttributionsCSVNoRole = `organization_name,is_producer
+Nobody,0`
+
+const testAttributionsCSVMultipleRefs = `organization_name,agency_id,route_id,is_operator
+Metro Operations,agency-1,route-1,1`
+
+const testAttributionsCSVInvalidRoute = `organization_name,route_id,is_operator
+Metro Operations,route-2,1`
+
+const testAttributionsCSVInvalidRole = `organization_name,is_operator
+Metro Operations,yes`
+
+func TestGTFS_processAttributions(t *testing.T) {
+	testAgency := &Agency{ID: "agency-1"}
+	testRoute := &Route{ID: "route-1", Agency: testAgency}
+	testTrip := &Trip{ID: "trip-1", Route: testRoute}
+
+	wantAttributions := []*Attribution{
+		{
+			ID:               "a1",
+			OrganizationName: "Regional Data Co",
+			IsProducer:       true,
+			URL:              "https://data.example.com",
+			Email:            "data@example.com",
+		},
+		{
+			ID:               "a2",
+			Agency:           testAgency,
+			OrganizationName: "Metro Operations",
+			IsOperator:       true,
+			Phone:            "555-0100",
+		},
+		{
+			ID:               "a3",
+			Route:            testRoute,
+			OrganizationName: "City Transit Authority",
+			IsAuthority:      true,
+		},
+		{
+			ID:               "a4",
+			Trip:             testTrip,
+			OrganizationName: "Contract Operator",
+			IsOperator:       true,
+		},
+	}
+
+	type fields struct {
+		strictMode bool
+	}
+	type args struct {
+		r io.Reader
+	}
+	tests := []struct {
+		name             string
+		fields           fields
+		args             args
+		wantErr          bool
+		wantAttributions []*Attribution
+	}{
+		{
+			name: "Valid",
+			args: args{
+				r: strings.NewReader(testAttributionsCSVValid),
+			},
+			wantErr:          false,
+			wantAttributions: wantAttributions,
+		},
+		{
+			name: "Empty",
+			args: args{
+				r: strings.NewReader(""),
+			},
+			wantErr:          true,
+			wantAttributions: nil,
+		},
+		{
+			name: "No Role (non-strict)",
+			args: args{
+				r: strings.NewReader(testAttributionsCSVNoRole),
+			},
+			wantErr: false,
+			wantAttributions: []*Attribution{
+				{
+					OrganizationName: "Nobody",
+				},
+			},
+		},
+		{
+			name: "No Role (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				r: strings.NewReader(testAttributionsCSVNoRole),
+			},
+			wantErr:          true,
+			wantAttributions: nil,
+		},
+		{
+			name: "Multiple References (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				r: strings.NewReader(testAttributionsCSVMultipleRefs),
+			},
+			wantErr:          true,
+			wantAttributions: nil,
+		},
+		{
+			name: "Invalid Route (non-strict)",
+			args: args{
+				r: strings.NewReader(testAttributionsCSVInvalidRoute),
+			},
+			wantErr:          false,
+			wantAttributions: nil,
+		},
+		{
+			name: "Invalid Route (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				r: strings.NewReader(testAttributionsCSVInvalidRoute),
+			},
+			wantErr:          true,
+			wantAttributions: nil,
+		},
+		{
+			name: "Invalid Role",
+			args: args{
+				r: strings.NewReader(testAttributionsCSVInvalidRole),
+			},
+			wantErr:          true,
+			wantAttributions: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{
+				agenciesByID: map[string]*Agency{"agency-1": testAgency},
+				routesByID:   map[string]*Route{"route-1": testRoute},
+				tripsByID:    map[string]*Trip{"trip-1": testTrip},
+				strictMode:   tt.fields.strictMode,
+			}
+			if err := g.processAttributions(tt.args.r); (err != nil) != tt.wantErr {
+				t.Errorf("GTFS.processAttributions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(g.Attributions, tt.wantAttributions) {
+				t.Errorf("GTFS.processAttributions() Attributions = %v, wantAttributions %v", g.Attributions, tt.wantAttributions)
+			}
+		})
+	}
+}
+
+func TestGTFS_AttributionsFor(t *testing.T) {
+	agency1 := &Agency{ID: "agency-1"}
+	agency2 := &Agency{ID: "agency-2"}
+	route1 := &Route{ID: "route-1", Agency: agency1}
+	route2 := &Route{ID: "route-2", Agency: agency2}
+	trip1 := &Trip{ID: "trip-1", Route: route1}
+	trip2 := &Trip{ID: "trip-2", Route: route1}
+
+	feedWide := &Attribution{OrganizationName: "Feed", IsProducer: true}
+	forAgency := &Attribution{OrganizationName: "Agency", Agency: agency1, IsOperator: true}
+	forRoute := &Attribution{OrganizationName: "Route", Route: route1, IsAuthority: true}
+	forTrip := &Attribution{OrganizationName: "Trip", Trip: trip1, IsOperator: true}
+
+	g := &GTFS{
+		Attributions: []*Attribution{feedWide, forAgency, forRoute, forTrip},
+	}
+
+	if got, want := g.AttributionsForAgency(agency1), []*Attribution{feedWide, forAgency}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.AttributionsForAgency(agency1) = %v, want %v", got, want)
+	}
+	if got, want := g.AttributionsForAgency(agency2), []*Attribution{feedWide}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.AttributionsForAgency(agency2) = %v, want %v", got, want)
+	}
+	if got, want := g.AttributionsForRoute(route1), []*Attribution{feedWide, forAgency, forRoute}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.AttributionsForRoute(route1) = %v, want %v", got, want)
+	}
+	if got, want := g.AttributionsForRoute(route2), []*Attribution{feedWide}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.AttributionsForRoute(route2) = %v, want %v", got, want)
+	}
+	if got, want := g.AttributionsForTrip(trip1), []*Attribution{feedWide, forAgency, forRoute, forTrip}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.AttributionsForTrip(trip1) = %v, want %v", got, want)
+	}
+	if got, want := g.AttributionsForTrip(trip2), []*Attribution{feedWide, forAgency, forRoute}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.AttributionsForTrip(trip2) = %v, want %v", got, want)
+	}
+}
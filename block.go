@@ -0,0 +1,216 @@
+package gtfs
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A Block is a sequence of trips operated by a single vehicle on a single
+// service day.
+type Block struct {
+	ID string
+
+	// Trips are the trips in the block active on the day, ordered by first
+	// departure time. Frequency-based trips appear once for each run.
+	Trips []*Trip
+
+	// Offsets are the run offsets of the trips, as returned by
+	// Trip.InstanceOffsets, so that Offsets[i] must be added to the times of
+	// Trips[i] to obtain those of its run. Offsets distinguish the runs of a
+	// frequency-based trip, and are zero for other trips.
+	Offsets []time.Duration
+
+	// Links describe the gaps between consecutive trips, so that Links[i] is
+	// the gap between Trips[i] and Trips[i+1].
+	Links []*BlockLink
+}
+
+// A BlockLink is the gap between two consecutive trips in a block.
+type BlockLink struct {
+	From *Trip
+	To   *Trip
+
+	// FromOffset and ToOffset are the run offsets of From and To, as in
+	// Block.Offsets.
+	FromOffset time.Duration
+	ToOffset   time.Duration
+
+	// FromStop is the last stop served by From, and ToStop is the first stop
+	// served by To.
+	FromStop *Stop
+	ToStop   *Stop
+
+	// Layover is the time between the last arrival of From and the first
+	// departure of To. Layover is negative if the trips overlap.
+	Layover time.Duration
+
+	// DeadheadDistance is the straight-line distance, in meters, between
+	// FromStop and ToStop.
+	DeadheadDistance float64
+}
+
+// IsDeadhead reports whether the vehicle must travel out of service between
+// the trips in l.
+func (l *BlockLink) IsDeadhead() bool {
+	return l.FromStop != l.ToStop
+}
+
+// blockTrip is a run of a trip in a block along with its first and last
+// scheduled times.
+type blockTrip struct {
+	trip        *Trip
+	offset      time.Duration
+	first, last *StopTime
+	start, end  time.Duration
+}
+
+// Blocks groups the trips active on date by block ID, ordering the trips in
+// each block by first departure time. Trips without a block ID are omitted, and
+// blocks are ordered by ID.
+//
+// Blocks are returned even if some of them contain overlapping trips, in which
+// case a non-nil error describing each overlap is also returned. A trip
+// overlaps if it departs before any earlier trip in the block has arrived, not
+// just the one immediately before it. Each run of a frequency-based trip is
+// placed in the block separately.
+func (g *GTFS) Blocks(date time.Time) ([]*Block, error) {
+	tripsByBlock := map[string][]*blockTrip{}
+	var ids []string
+
+	for _, t := range g.Trips {
+		if t.BlockID == "" || t.Service == nil || !t.Service.IsActiveOn(date) {
+			continue
+		}
+
+		bt, err := newBlockTrip(t)
+		if err != nil {
+			return nil, err
+		}
+
+		if bt == nil {
+			continue
+		}
+
+		offsets, err := t.InstanceOffsets()
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := tripsByBlock[t.BlockID]; !ok {
+			ids = append(ids, t.BlockID)
+		}
+
+		// bt is placed at the first run of frequency-based trips
+		for _, offset := range offsets {
+			run := *bt
+			run.offset = offset
+			run.start += offset - offsets[0]
+			run.end += offset - offsets[0]
+			tripsByBlock[t.BlockID] = append(tripsByBlock[t.BlockID], &run)
+		}
+	}
+
+	sort.Strings(ids)
+
+	var blocks []*Block
+	var errs []error
+	for _, id := range ids {
+		trips := tripsByBlock[id]
+		sort.SliceStable(trips, func(i, j int) bool {
+			return trips[i].start < trips[j].start
+		})
+
+		b := &Block{
+			ID: id,
+		}
+
+		// latest is the trip with the latest arrival so far
+		var latest *blockTrip
+		for i, bt := range trips {
+			b.Trips = append(b.Trips, bt.trip)
+			b.Offsets = append(b.Offsets, bt.offset)
+			if i == 0 {
+				latest = bt
+				continue
+			}
+
+			prev := trips[i-1]
+			l := &BlockLink{
+				From:       prev.trip,
+				To:         bt.trip,
+				FromOffset: prev.offset,
+				ToOffset:   bt.offset,
+				FromStop:   prev.last.Stop,
+				ToStop:     bt.first.Stop,
+				Layover:    bt.start - prev.end,
+			}
+
+			if l.FromStop != nil && l.ToStop != nil {
+				l.DeadheadDistance = l.FromStop.DistanceTo(l.ToStop)
+			}
+
+			if bt.start < latest.end {
+				errs = append(errs, fmt.Errorf("trips %s and %s in block %s overlap", latest.trip.ID, bt.trip.ID, id))
+			}
+
+			if bt.end > latest.end {
+				latest = bt
+			}
+
+			b.Links = append(b.Links, l)
+		}
+
+		blocks = append(blocks, b)
+	}
+
+	return blocks, errors.Join(errs...)
+}
+
+// newBlockTrip finds the first and last scheduled stops of t, returning nil if
+// t has no scheduled times.
+func newBlockTrip(t *Trip) (*blockTrip, error) {
+	bt := &blockTrip{
+		trip: t,
+	}
+
+	for _, st := range t.Stops {
+		if st.DepartureTime != "" && bt.first == nil {
+			bt.first = st
+		}
+
+		if st.ArrivalTime != "" {
+			bt.last = st
+		}
+	}
+
+	if bt.first == nil || bt.last == nil {
+		return nil, nil
+	}
+
+	var err error
+	bt.start, err = bt.first.DepartureOffset()
+	if err != nil {
+		return nil, fmt.Errorf("invalid departure time for trip %s: %v", t.ID, err)
+	}
+
+	bt.end, err = bt.last.ArrivalOffset()
+	if err != nil {
+		return nil, fmt.Errorf("invalid arrival time for trip %s: %v", t.ID, err)
+	}
+
+	offsets, err := t.InstanceOffsets()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+
+	bt.start += offsets[0]
+	bt.end += offsets[0]
+
+	return bt, nil
+}
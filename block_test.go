@@ -0,0 +1,169 @@
+package gtfs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newBlockTestTrip(id, blockID string, s *Service, stops []*Stop, times []string) *Trip {
+	t := &Trip{
+		ID:            id,
+		BlockID:       blockID,
+		Service:       s,
+		AbsoluteTimes: true,
+	}
+
+	for i, stop := range stops {
+		t.Stops = append(t.Stops, &StopTime{
+			Stop:          stop,
+			ArrivalTime:   times[i],
+			DepartureTime: times[i],
+			Sequence:      uint64(i + 1),
+		})
+	}
+
+	return t
+}
+
+func TestGTFS_Blocks(t *testing.T) {
+	weekday := &Service{
+		ID:        "weekday",
+		Monday:    true,
+		Tuesday:   true,
+		Wednesday: true,
+		Thursday:  true,
+		Friday:    true,
+		StartDate: "20190101",
+		EndDate:   "20191231",
+	}
+	weekend := &Service{
+		ID:        "weekend",
+		Saturday:  true,
+		Sunday:    true,
+		StartDate: "20190101",
+		EndDate:   "20191231",
+	}
+
+	a := &Stop{ID: "a", Latitude: 40.0, Longitude: -75.0}
+	b := &Stop{ID: "b", Latitude: 40.01, Longitude: -75.0}
+	c := &Stop{ID: "c", Latitude: 40.02, Longitude: -75.0}
+
+	outbound := newBlockTestTrip("outbound", "block-1", weekday, []*Stop{a, b}, []string{"08:00:00", "08:20:00"})
+	inbound := newBlockTestTrip("inbound", "block-1", weekday, []*Stop{b, a}, []string{"08:30:00", "08:50:00"})
+	deadhead := newBlockTestTrip("deadhead", "block-1", weekday, []*Stop{c, b}, []string{"09:10:00", "09:20:00"})
+	overlap1 := newBlockTestTrip("overlap-1", "block-2", weekday, []*Stop{a, b}, []string{"10:00:00", "10:30:00"})
+	overlap2 := newBlockTestTrip("overlap-2", "block-2", weekday, []*Stop{b, c}, []string{"10:15:00", "10:45:00"})
+	saturday := newBlockTestTrip("saturday", "block-3", weekend, []*Stop{a, b}, []string{"08:00:00", "08:20:00"})
+	unblocked := newBlockTestTrip("unblocked", "", weekday, []*Stop{a, b}, []string{"08:00:00", "08:20:00"})
+
+	g := &GTFS{
+		Trips: []*Trip{deadhead, inbound, overlap2, outbound, overlap1, saturday, unblocked},
+	}
+
+	blocks, err := g.Blocks(time.Date(2019, time.July, 3, 0, 0, 0, 0, time.UTC))
+	if err == nil {
+		t.Errorf("GTFS.Blocks() error = nil, want overlap error")
+	}
+
+	if len(blocks) != 2 {
+		t.Fatalf("GTFS.Blocks() returned %d blocks, want 2", len(blocks))
+	}
+
+	b1, b2 := blocks[0], blocks[1]
+	if b1.ID != "block-1" || len(b1.Trips) != 3 || b1.Trips[0] != outbound || b1.Trips[1] != inbound || b1.Trips[2] != deadhead {
+		t.Fatalf("GTFS.Blocks()[0] = %+v, want block-1 with outbound, inbound, and deadhead trips", b1)
+	}
+
+	if len(b1.Links) != 2 {
+		t.Fatalf("GTFS.Blocks()[0] has %d links, want 2", len(b1.Links))
+	}
+
+	layover := b1.Links[0]
+	if layover.From != outbound || layover.To != inbound || layover.Layover != 10*time.Minute || layover.IsDeadhead() || layover.DeadheadDistance != 0 {
+		t.Errorf("GTFS.Blocks()[0].Links[0] = %+v, want 10 minute layover at b", layover)
+	}
+
+	dh := b1.Links[1]
+	if dh.FromStop != a || dh.ToStop != c || dh.Layover != 20*time.Minute || !dh.IsDeadhead() || dh.DeadheadDistance < 2000 || dh.DeadheadDistance > 2300 {
+		t.Errorf("GTFS.Blocks()[0].Links[1] = %+v, want 20 minute deadhead of about 2.2km from a to c", dh)
+	}
+
+	if b2.ID != "block-2" || len(b2.Links) != 1 || b2.Links[0].Layover != -15*time.Minute {
+		t.Errorf("GTFS.Blocks()[1] = %+v, want block-2 with overlapping trips", b2)
+	}
+
+	blocks, err = g.Blocks(time.Date(2019, time.July, 6, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Errorf("GTFS.Blocks() error = %v, want nil", err)
+	}
+
+	if len(blocks) != 1 || blocks[0].ID != "block-3" || len(blocks[0].Links) != 0 {
+		t.Errorf("GTFS.Blocks() = %+v, want only block-3", blocks)
+	}
+}
+
+func TestGTFS_Blocks_overlaps(t *testing.T) {
+	s := &Service{
+		ID:        "daily",
+		Monday:    true,
+		Tuesday:   true,
+		Wednesday: true,
+		Thursday:  true,
+		Friday:    true,
+		Saturday:  true,
+		Sunday:    true,
+		StartDate: "20190101",
+		EndDate:   "20191231",
+	}
+
+	a := &Stop{ID: "a"}
+	b := &Stop{ID: "b"}
+
+	shuttle := newBlockTestTrip("shuttle", "freq", s, []*Stop{a, b}, []string{"00:00:00", "00:20:00"})
+	shuttle.AbsoluteTimes = false
+	shuttle.Frequencies = []Frequency{{StartTime: "08:00:00", EndTime: "09:00:00", HeadwaySeconds: 1800}}
+
+	g := &GTFS{
+		Trips: []*Trip{
+			newBlockTestTrip("long", "nested", s, []*Stop{a, b}, []string{"08:00:00", "10:00:00"}),
+			newBlockTestTrip("short", "nested", s, []*Stop{b, a}, []string{"08:10:00", "08:20:00"}),
+			newBlockTestTrip("later", "nested", s, []*Stop{a, b}, []string{"09:00:00", "09:30:00"}),
+			shuttle,
+			newBlockTestTrip("after", "freq", s, []*Stop{b, a}, []string{"08:45:00", "09:00:00"}),
+		},
+	}
+
+	blocks, err := g.Blocks(time.Date(2019, time.July, 3, 0, 0, 0, 0, time.UTC))
+	if err == nil {
+		t.Fatalf("GTFS.Blocks() error = nil, want overlap errors")
+	}
+
+	want := "trips shuttle and after in block freq overlap\n" +
+		"trips long and short in block nested overlap\n" +
+		"trips long and later in block nested overlap"
+	if err.Error() != want {
+		t.Errorf("GTFS.Blocks() error = %q, want %q", err, want)
+	}
+
+	if len(blocks) != 2 {
+		t.Fatalf("GTFS.Blocks() returned %d blocks, want 2", len(blocks))
+	}
+
+	freq := blocks[0]
+	if freq.ID != "freq" || len(freq.Trips) != 3 || freq.Trips[0] != shuttle || freq.Trips[1] != shuttle || freq.Trips[2].ID != "after" {
+		t.Fatalf("GTFS.Blocks()[0] = %+v, want both shuttle runs followed by after", freq)
+	}
+
+	if !reflect.DeepEqual(freq.Offsets, []time.Duration{8 * time.Hour, 8*time.Hour + 30*time.Minute, 0}) {
+		t.Errorf("GTFS.Blocks()[0].Offsets = %v, want runs at 08:00 and 08:30 followed by after", freq.Offsets)
+	}
+
+	if freq.Links[0].FromOffset != 8*time.Hour || freq.Links[0].ToOffset != 8*time.Hour+30*time.Minute {
+		t.Errorf("GTFS.Blocks()[0].Links[0] = %+v, want link from the 08:00 run to the 08:30 run", freq.Links[0])
+	}
+
+	if freq.Links[0].Layover != 10*time.Minute || freq.Links[1].Layover != -5*time.Minute {
+		t.Errorf("GTFS.Blocks()[0].Links = %+v, want layovers of 10 and -5 minutes", freq.Links)
+	}
+}
@@ -0,0 +1,147 @@
+package gtfs
+
+import (
+	"fmt"
+	"io"
+)
+
+// A BookingRule describes how riders must book demand-responsive (GTFS-Flex)
+// service.
+//
+// Fields correspond directly to columns in booking_rules.txt. Durations are in
+// minutes and days are counted back from the day of travel.
+type BookingRule struct {
+	ID                     string
+	Type                   BookingType
+	PriorNoticeDurationMin uint64
+	PriorNoticeDurationMax uint64
+	PriorNoticeLastDay     uint64
+	PriorNoticeLastTime    string
+	PriorNoticeStartDay    uint64
+	PriorNoticeStartTime   string
+	PriorNoticeService     *Service
+	Message                string
+	PickupMessage          string
+	DropOffMessage         string
+	PhoneNumber            string
+	InfoURL                string
+	BookingURL             string
+}
+
+// BookingType indicates how far in advance a booking must be made.
+type BookingType int
+
+const (
+	// BookingTypeRealTime indicates that service may be booked in real time.
+	BookingTypeRealTime BookingType = iota
+
+	// BookingTypeSameDay indicates that service must be booked on the day of
+	// travel, with advance notice.
+	BookingTypeSameDay
+
+	// BookingTypePriorDays indicates that service must be booked one or more
+	// days before the day of travel.
+	BookingTypePriorDays
+)
+
+var bookingRuleFields = map[string]bool{
+	"booking_rule_id":           true,
+	"booking_type":              true,
+	"prior_notice_duration_min": false,
+	"prior_notice_duration_max": false,
+	"prior_notice_last_day":     false,
+	"prior_notice_last_time":    false,
+	"prior_notice_start_day":    false,
+	"prior_notice_start_time":   false,
+	"prior_notice_service_id":   false,
+	"message":                   false,
+	"pickup_message":            false,
+	"drop_off_message":          false,
+	"phone_number":              false,
+	"info_url":                  false,
+	"booking_url":               false,
+}
+
+func (g *GTFS) processBookingRules(r io.Reader) error {
+	res, err := readCSVWithHeadings(r, bookingRuleFields, g.strictMode)
+	if err != nil {
+		return err
+	}
+
+	g.bookingRulesByID = map[string]*BookingRule{}
+
+	for _, row := range res {
+		bookingType, err := parseBookingType(row["booking_type"])
+		if err != nil {
+			return err
+		}
+
+		durationMin, err := parseOptionalUint(row["prior_notice_duration_min"])
+		if err != nil {
+			return fmt.Errorf("invalid prior_notice_duration_min: %v", err)
+		}
+
+		durationMax, err := parseOptionalUint(row["prior_notice_duration_max"])
+		if err != nil {
+			return fmt.Errorf("invalid prior_notice_duration_max: %v", err)
+		}
+
+		lastDay, err := parseOptionalUint(row["prior_notice_last_day"])
+		if err != nil {
+			return fmt.Errorf("invalid prior_notice_last_day: %v", err)
+		}
+
+		startDay, err := parseOptionalUint(row["prior_notice_start_day"])
+		if err != nil {
+			return fmt.Errorf("invalid prior_notice_start_day: %v", err)
+		}
+
+		var service *Service
+		if serviceID := row["prior_notice_service_id"]; serviceID != "" {
+			service = g.serviceByID(serviceID)
+			if service == nil && g.strictMode {
+				return fmt.Errorf("invalid prior_notice_service_id: %s", serviceID)
+			}
+		}
+
+		br := &BookingRule{
+			ID:                     row["booking_rule_id"],
+			Type:                   bookingType,
+			PriorNoticeDurationMin: durationMin,
+			PriorNoticeDurationMax: durationMax,
+			PriorNoticeLastDay:     lastDay,
+			PriorNoticeLastTime:    row["prior_notice_last_time"],
+			PriorNoticeStartDay:    startDay,
+			PriorNoticeStartTime:   row["prior_notice_start_time"],
+			PriorNoticeService:     service,
+			Message:                row["message"],
+			PickupMessage:          row["pickup_message"],
+			DropOffMessage:         row["drop_off_message"],
+			PhoneNumber:            row["phone_number"],
+			InfoURL:                row["info_url"],
+			BookingURL:             row["booking_url"],
+		}
+
+		g.BookingRules = append(g.BookingRules, br)
+		g.bookingRulesByID[br.ID] = br
+	}
+
+	return nil
+}
+
+func (g *GTFS) bookingRuleByID(id string) *BookingRule {
+	return g.bookingRulesByID[id]
+}
+
+func parseBookingType(val string) (BookingType, error) {
+	switch val {
+	case "0":
+		return BookingTypeRealTime, nil
+	case "1":
+		return BookingTypeSameDay, nil
+	case "2":
+		return BookingTypePriorDays, nil
+	default:
+		return BookingTypeRealTime, fmt.Errorf("invalid booking type: %s", val)
+	}
+}
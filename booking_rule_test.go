@@ -0,0 +1,167 @@
+package gtfs
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testBookingRulesCSVValid = `booking_rule_id,booking_type,prior_notice_duration_min,prior_notice_duration_max,prior_notice_last_day,prior_notice_last_time,prior_notice_start_day,prior_notice_start_time,prior_notice_service_id,message,pickup_message,drop_off_message,phone_number,info_url,booking_url
+br-1,1,30,1440,,,,,,Book at least 30 minutes ahead,,,555-0100,https://example.com/info,https://example.com/book
+br-2,2,,,1,17:00:00,7,08:00:00,1,,Meet at the curb,,,,`
+
+const testBookingRulesCSVInvalidType = `booking_rule_id,booking_type
+br-1,3`
+
+const testBookingRulesCSVInvalidDuration = `booking_rule_id,booking_type,prior_notice_duration_min
+br-1,1,foo`
+
+func TestGTFS_processBookingRules(t *testing.T) {
+	testService := &Service{
+		ID: "1",
+	}
+	testBookingRule1 := &BookingRule{
+		ID:                     "br-1",
+		Type:                   BookingTypeSameDay,
+		PriorNoticeDurationMin: 30,
+		PriorNoticeDurationMax: 1440,
+		Message:                "Book at least 30 minutes ahead",
+		PhoneNumber:            "555-0100",
+		InfoURL:                "https://example.com/info",
+		BookingURL:             "https://example.com/book",
+	}
+	testBookingRule2 := &BookingRule{
+		ID:                   "br-2",
+		Type:                 BookingTypePriorDays,
+		PriorNoticeLastDay:   1,
+		PriorNoticeLastTime:  "17:00:00",
+		PriorNoticeStartDay:  7,
+		PriorNoticeStartTime: "08:00:00",
+		PriorNoticeService:   testService,
+		PickupMessage:        "Meet at the curb",
+	}
+	type args struct {
+		r io.Reader
+	}
+	tests := []struct {
+		name                 string
+		args                 args
+		wantErr              bool
+		wantBookingRules     []*BookingRule
+		wantBookingRulesByID map[string]*BookingRule
+	}{
+		{
+			name: "Valid",
+			args: args{
+				r: strings.NewReader(testBookingRulesCSVValid),
+			},
+			wantErr: false,
+			wantBookingRules: []*BookingRule{
+				testBookingRule1,
+				testBookingRule2,
+			},
+			wantBookingRulesByID: map[string]*BookingRule{
+				"br-1": testBookingRule1,
+				"br-2": testBookingRule2,
+			},
+		},
+		{
+			name: "Empty",
+			args: args{
+				r: strings.NewReader(""),
+			},
+			wantErr:              true,
+			wantBookingRules:     nil,
+			wantBookingRulesByID: nil,
+		},
+		{
+			name: "Invalid Booking Type",
+			args: args{
+				r: strings.NewReader(testBookingRulesCSVInvalidType),
+			},
+			wantErr:              true,
+			wantBookingRules:     nil,
+			wantBookingRulesByID: map[string]*BookingRule{},
+		},
+		{
+			name: "Invalid Duration",
+			args: args{
+				r: strings.NewReader(testBookingRulesCSVInvalidDuration),
+			},
+			wantErr:              true,
+			wantBookingRules:     nil,
+			wantBookingRulesByID: map[string]*BookingRule{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{
+				servicesByID: map[string]*Service{
+					"1": testService,
+				},
+			}
+			if err := g.processBookingRules(tt.args.r); (err != nil) != tt.wantErr {
+				t.Errorf("GTFS.processBookingRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(g.BookingRules, tt.wantBookingRules) {
+				t.Errorf("GTFS.processBookingRules() BookingRules = %v, wantBookingRules %v", g.BookingRules, tt.wantBookingRules)
+			}
+			if !reflect.DeepEqual(g.bookingRulesByID, tt.wantBookingRulesByID) {
+				t.Errorf("GTFS.processBookingRules() bookingRulesByID = %v, wantBookingRulesByID %v", g.bookingRulesByID, tt.wantBookingRulesByID)
+			}
+		})
+	}
+}
+
+func Test_parseBookingType(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    BookingType
+		wantErr bool
+	}{
+		{
+			name:    "Real-Time",
+			val:     "0",
+			want:    BookingTypeRealTime,
+			wantErr: false,
+		},
+		{
+			name:    "Same Day",
+			val:     "1",
+			want:    BookingTypeSameDay,
+			wantErr: false,
+		},
+		{
+			name:    "Prior Days",
+			val:     "2",
+			want:    BookingTypePriorDays,
+			wantErr: false,
+		},
+		{
+			name:    "Empty",
+			val:     "",
+			want:    BookingTypeRealTime,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid",
+			val:     "3",
+			want:    BookingTypeRealTime,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBookingType(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseBookingType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseBookingType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
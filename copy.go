@@ -0,0 +1,282 @@
+package gtfs
+
+// A copier makes deep copies of entities in a feed, copying each entity at most
+// once so that references between copied entities are preserved.
+//
+// Copying an entity also copies every entity it references.
+type copier struct {
+	agencies       map[*Agency]*Agency
+	stops          map[*Stop]*Stop
+	routes         map[*Route]*Route
+	services       map[*Service]*Service
+	shapes         map[*Shape]*Shape
+	trips          map[*Trip]*Trip
+	locations      map[*Location]*Location
+	locationGroups map[*LocationGroup]*LocationGroup
+	bookingRules   map[*BookingRule]*BookingRule
+	attributions   map[*Attribution]*Attribution
+}
+
+func newCopier() *copier {
+	return &copier{
+		agencies:       map[*Agency]*Agency{},
+		stops:          map[*Stop]*Stop{},
+		routes:         map[*Route]*Route{},
+		services:       map[*Service]*Service{},
+		shapes:         map[*Shape]*Shape{},
+		trips:          map[*Trip]*Trip{},
+		locations:      map[*Location]*Location{},
+		locationGroups: map[*LocationGroup]*LocationGroup{},
+		bookingRules:   map[*BookingRule]*BookingRule{},
+		attributions:   map[*Attribution]*Attribution{},
+	}
+}
+
+func (c *copier) agency(a *Agency) *Agency {
+	if a == nil {
+		return nil
+	}
+
+	if res, ok := c.agencies[a]; ok {
+		return res
+	}
+
+	res := *a
+	c.agencies[a] = &res
+
+	return &res
+}
+
+func (c *copier) stop(s *Stop) *Stop {
+	if s == nil {
+		return nil
+	}
+
+	if res, ok := c.stops[s]; ok {
+		return res
+	}
+
+	res := *s
+	c.stops[s] = &res
+	res.ParentStation = c.stop(s.ParentStation)
+
+	return &res
+}
+
+func (c *copier) route(r *Route) *Route {
+	if r == nil {
+		return nil
+	}
+
+	if res, ok := c.routes[r]; ok {
+		return res
+	}
+
+	res := *r
+	c.routes[r] = &res
+	res.Agency = c.agency(r.Agency)
+
+	return &res
+}
+
+func (c *copier) service(s *Service) *Service {
+	if s == nil {
+		return nil
+	}
+
+	if res, ok := c.services[s]; ok {
+		return res
+	}
+
+	res := *s
+	c.services[s] = &res
+	res.AdditionalDates = append([]string(nil), s.AdditionalDates...)
+	res.ExceptDates = append([]string(nil), s.ExceptDates...)
+
+	return &res
+}
+
+func (c *copier) shape(s *Shape) *Shape {
+	if s == nil {
+		return nil
+	}
+
+	if res, ok := c.shapes[s]; ok {
+		return res
+	}
+
+	res := *s
+	c.shapes[s] = &res
+	res.Points = make([]*ShapePoint, 0, len(s.Points))
+	for _, pt := range s.Points {
+		cp := *pt
+		res.Points = append(res.Points, &cp)
+	}
+
+	return &res
+}
+
+func (c *copier) trip(t *Trip) *Trip {
+	return c.tripWithStops(t, t.Stops)
+}
+
+// tripWithStops copies t, replacing its stop times with copies of stops.
+func (c *copier) tripWithStops(t *Trip, stops []*StopTime) *Trip {
+	if t == nil {
+		return nil
+	}
+
+	if res, ok := c.trips[t]; ok {
+		return res
+	}
+
+	res := *t
+	c.trips[t] = &res
+	res.Route = c.route(t.Route)
+	res.Service = c.service(t.Service)
+	res.Shape = c.shape(t.Shape)
+	res.Frequencies = append([]Frequency(nil), t.Frequencies...)
+
+	res.Stops = make([]*StopTime, 0, len(stops))
+	for _, st := range stops {
+		res.Stops = append(res.Stops, c.stopTime(st))
+	}
+
+	return &res
+}
+
+func (c *copier) stopTime(st *StopTime) *StopTime {
+	res := *st
+	res.Stop = c.stop(st.Stop)
+	res.Location = c.location(st.Location)
+	res.LocationGroup = c.locationGroup(st.LocationGroup)
+	res.PickupBookingRule = c.bookingRule(st.PickupBookingRule)
+	res.DropOffBookingRule = c.bookingRule(st.DropOffBookingRule)
+
+	return &res
+}
+
+func (c *copier) location(l *Location) *Location {
+	if l == nil {
+		return nil
+	}
+
+	if res, ok := c.locations[l]; ok {
+		return res
+	}
+
+	res := *l
+	c.locations[l] = &res
+	res.Polygons = make([]Polygon, 0, len(l.Polygons))
+	for _, p := range l.Polygons {
+		cp := make(Polygon, 0, len(p))
+		for _, ring := range p {
+			cp = append(cp, append([][2]float64(nil), ring...))
+		}
+
+		res.Polygons = append(res.Polygons, cp)
+	}
+
+	return &res
+}
+
+func (c *copier) locationGroup(lg *LocationGroup) *LocationGroup {
+	if lg == nil {
+		return nil
+	}
+
+	return c.locationGroupWithStops(lg, lg.Stops)
+}
+
+// locationGroupWithStops copies lg, replacing its stops with copies of stops.
+func (c *copier) locationGroupWithStops(lg *LocationGroup, stops []*Stop) *LocationGroup {
+	if res, ok := c.locationGroups[lg]; ok {
+		return res
+	}
+
+	res := *lg
+	c.locationGroups[lg] = &res
+	res.Stops = make([]*Stop, 0, len(stops))
+	for _, s := range stops {
+		res.Stops = append(res.Stops, c.stop(s))
+	}
+
+	return &res
+}
+
+func (c *copier) bookingRule(br *BookingRule) *BookingRule {
+	if br == nil {
+		return nil
+	}
+
+	if res, ok := c.bookingRules[br]; ok {
+		return res
+	}
+
+	res := *br
+	c.bookingRules[br] = &res
+	res.PriorNoticeService = c.service(br.PriorNoticeService)
+
+	return &res
+}
+
+// index rebuilds the lookup maps of g from its entities.
+func (g *GTFS) index() {
+	g.agenciesByID = map[string]*Agency{}
+	for _, a := range g.Agencies {
+		g.agenciesByID[a.ID] = a
+	}
+
+	g.stopsByID = map[string]*Stop{}
+	for _, s := range g.Stops {
+		g.stopsByID[s.ID] = s
+	}
+
+	g.routesByID = map[string]*Route{}
+	for _, r := range g.Routes {
+		g.routesByID[r.ID] = r
+	}
+
+	g.servicesByID = map[string]*Service{}
+	for _, s := range g.Services {
+		g.servicesByID[s.ID] = s
+	}
+
+	g.shapesByID = map[string]*Shape{}
+	for _, s := range g.Shapes {
+		g.shapesByID[s.ID] = s
+	}
+
+	g.tripsByID = map[string]*Trip{}
+	for _, t := range g.Trips {
+		g.tripsByID[t.ID] = t
+	}
+
+	g.faresByID = map[string]*Fare{}
+	for _, f := range g.Fares {
+		g.faresByID[f.ID] = f
+	}
+
+	g.locationsByID = map[string]*Location{}
+	for _, l := range g.Locations {
+		g.locationsByID[l.ID] = l
+	}
+
+	g.locationGroupsByID = map[string]*LocationGroup{}
+	for _, lg := range g.LocationGroups {
+		g.locationGroupsByID[lg.ID] = lg
+	}
+
+	g.bookingRulesByID = map[string]*BookingRule{}
+	for _, br := range g.BookingRules {
+		g.bookingRulesByID[br.ID] = br
+	}
+
+	g.translationsByID = map[string]map[string]*Translation{}
+	g.translationsByRecord = map[translationRecordKey]*Translation{}
+	g.translationsByValue = map[translationValueKey]*Translation{}
+	for _, t := range g.Translations {
+		// Translations were validated when they were loaded
+		_ = g.indexTranslation(t)
+	}
+}
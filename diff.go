@@ -0,0 +1,558 @@
+package gtfs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultStopMoveDistance is the default distance, in meters, that a stop must
+// move before it is considered to have changed.
+const DefaultStopMoveDistance = 10
+
+// DiffOptions specifies options used when comparing feeds.
+type DiffOptions struct {
+	// StopMoveDistance is the distance, in meters, that a stop must move
+	// before it is considered to have changed. If zero,
+	// DefaultStopMoveDistance is used.
+	StopMoveDistance float64
+}
+
+// ChangeType specifies whether an entity was added, removed or modified.
+type ChangeType int
+
+const (
+	// ChangeTypeAdded indicates that an entity only exists in the new feed.
+	ChangeTypeAdded ChangeType = iota
+
+	// ChangeTypeRemoved indicates that an entity only exists in the old feed.
+	ChangeTypeRemoved
+
+	// ChangeTypeModified indicates that an entity exists in both feeds, but
+	// differs between them.
+	ChangeTypeModified
+)
+
+// A Change describes how a single entity differs between two feeds.
+type Change struct {
+	ID   string
+	Type ChangeType
+
+	// Fields contains the names of the columns whose values changed, for
+	// modified entities.
+	Fields []string
+
+	// Distance is the distance, in meters, that a modified stop moved.
+	Distance float64
+
+	// TimeShift is the change in the first departure time of a modified
+	// trip.
+	TimeShift time.Duration
+
+	// AddedDates and RemovedDates contain the dates, in YYYYMMDD format, on
+	// which a modified service became active or inactive.
+	AddedDates   []string
+	RemovedDates []string
+}
+
+// A ChangeSet contains all changes between two feeds, keyed by entity ID.
+type ChangeSet struct {
+	Agencies map[string]*Change
+	Stops    map[string]*Change
+	Routes   map[string]*Change
+	Services map[string]*Change
+	Trips    map[string]*Change
+}
+
+// ChangeCounts contains the number of entities of a single type that were
+// added, removed and modified.
+type ChangeCounts struct {
+	Added    int
+	Removed  int
+	Modified int
+}
+
+// Diff compares two versions of a feed using the default options.
+func Diff(oldFeed, newFeed *GTFS) *ChangeSet {
+	return DiffWithOptions(oldFeed, newFeed, DiffOptions{})
+}
+
+// DiffWithOptions compares two versions of a feed.
+//
+// Entities are matched by ID. Stops are only considered to have moved if they
+// moved at least opts.StopMoveDistance, services are compared by the dates on
+// which they are active, and trips are compared by their stops and times as
+// well as their other fields.
+func DiffWithOptions(oldFeed, newFeed *GTFS, opts DiffOptions) *ChangeSet {
+	if opts.StopMoveDistance == 0 {
+		opts.StopMoveDistance = DefaultStopMoveDistance
+	}
+
+	res := &ChangeSet{
+		Agencies: map[string]*Change{},
+		Stops:    map[string]*Change{},
+		Routes:   map[string]*Change{},
+		Services: map[string]*Change{},
+		Trips:    map[string]*Change{},
+	}
+
+	for _, a := range oldFeed.Agencies {
+		if newFeed.agencyByID(a.ID) == nil {
+			res.Agencies[a.ID] = &Change{ID: a.ID, Type: ChangeTypeRemoved}
+		}
+	}
+
+	for _, a := range newFeed.Agencies {
+		if o := oldFeed.agencyByID(a.ID); o == nil {
+			res.Agencies[a.ID] = &Change{ID: a.ID, Type: ChangeTypeAdded}
+		} else if c := diffAgency(o, a); c != nil {
+			res.Agencies[a.ID] = c
+		}
+	}
+
+	for _, s := range oldFeed.Stops {
+		if newFeed.stopByID(s.ID) == nil {
+			res.Stops[s.ID] = &Change{ID: s.ID, Type: ChangeTypeRemoved}
+		}
+	}
+
+	for _, s := range newFeed.Stops {
+		if o := oldFeed.stopByID(s.ID); o == nil {
+			res.Stops[s.ID] = &Change{ID: s.ID, Type: ChangeTypeAdded}
+		} else if c := diffStop(o, s, opts.StopMoveDistance); c != nil {
+			res.Stops[s.ID] = c
+		}
+	}
+
+	for _, r := range oldFeed.Routes {
+		if newFeed.routeByID(r.ID) == nil {
+			res.Routes[r.ID] = &Change{ID: r.ID, Type: ChangeTypeRemoved}
+		}
+	}
+
+	for _, r := range newFeed.Routes {
+		if o := oldFeed.routeByID(r.ID); o == nil {
+			res.Routes[r.ID] = &Change{ID: r.ID, Type: ChangeTypeAdded}
+		} else if c := diffRoute(o, r); c != nil {
+			res.Routes[r.ID] = c
+		}
+	}
+
+	for _, s := range oldFeed.Services {
+		if newFeed.serviceByID(s.ID) == nil {
+			res.Services[s.ID] = &Change{ID: s.ID, Type: ChangeTypeRemoved}
+		}
+	}
+
+	for _, s := range newFeed.Services {
+		if o := oldFeed.serviceByID(s.ID); o == nil {
+			res.Services[s.ID] = &Change{ID: s.ID, Type: ChangeTypeAdded}
+		} else if c := diffService(o, s); c != nil {
+			res.Services[s.ID] = c
+		}
+	}
+
+	for _, t := range oldFeed.Trips {
+		if newFeed.tripByID(t.ID) == nil {
+			res.Trips[t.ID] = &Change{ID: t.ID, Type: ChangeTypeRemoved}
+		}
+	}
+
+	for _, t := range newFeed.Trips {
+		if o := oldFeed.tripByID(t.ID); o == nil {
+			res.Trips[t.ID] = &Change{ID: t.ID, Type: ChangeTypeAdded}
+		} else if c := diffTrip(o, t); c != nil {
+			res.Trips[t.ID] = c
+		}
+	}
+
+	return res
+}
+
+// Empty reports whether cs contains no changes.
+func (cs *ChangeSet) Empty() bool {
+	return len(cs.Agencies) == 0 && len(cs.Stops) == 0 && len(cs.Routes) == 0 && len(cs.Services) == 0 && len(cs.Trips) == 0
+}
+
+// Summary returns the number of changes of each type, keyed by the name of the
+// file containing the changed entities (e.g. "stops").
+func (cs *ChangeSet) Summary() map[string]ChangeCounts {
+	res := map[string]ChangeCounts{}
+	for _, t := range cs.tables() {
+		var counts ChangeCounts
+		for _, c := range t.changes {
+			switch c.Type {
+			case ChangeTypeAdded:
+				counts.Added++
+			case ChangeTypeRemoved:
+				counts.Removed++
+			case ChangeTypeModified:
+				counts.Modified++
+			}
+		}
+
+		res[t.name] = counts
+	}
+
+	return res
+}
+
+// Report returns a human-readable description of all changes in cs, with one
+// line per changed entity.
+func (cs *ChangeSet) Report() string {
+	if cs.Empty() {
+		return "No changes\n"
+	}
+
+	summary := cs.Summary()
+
+	var b strings.Builder
+	for _, t := range cs.tables() {
+		if len(t.changes) == 0 {
+			continue
+		}
+
+		counts := summary[t.name]
+		fmt.Fprintf(&b, "%s: %d added, %d removed, %d modified\n", t.name, counts.Added, counts.Removed, counts.Modified)
+
+		ids := make([]string, 0, len(t.changes))
+		for id := range t.changes {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			c := t.changes[id]
+			switch c.Type {
+			case ChangeTypeAdded:
+				fmt.Fprintf(&b, "  + %s\n", id)
+			case ChangeTypeRemoved:
+				fmt.Fprintf(&b, "  - %s\n", id)
+			case ChangeTypeModified:
+				fmt.Fprintf(&b, "  ~ %s: %s\n", id, strings.Join(c.details(), "; "))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+type changeTable struct {
+	name    string
+	changes map[string]*Change
+}
+
+func (cs *ChangeSet) tables() []changeTable {
+	return []changeTable{
+		{"agency", cs.Agencies},
+		{"stops", cs.Stops},
+		{"routes", cs.Routes},
+		{"calendar", cs.Services},
+		{"trips", cs.Trips},
+	}
+}
+
+// details returns human-readable descriptions of the modifications in c.
+func (c *Change) details() []string {
+	var res []string
+	if c.Distance != 0 {
+		res = append(res, fmt.Sprintf("moved %.0f m", c.Distance))
+	}
+
+	if c.TimeShift > 0 {
+		res = append(res, fmt.Sprintf("shifted +%v", c.TimeShift))
+	} else if c.TimeShift < 0 {
+		res = append(res, fmt.Sprintf("shifted %v", c.TimeShift))
+	}
+
+	if len(c.AddedDates) > 0 {
+		res = append(res, "added "+strings.Join(c.AddedDates, ", "))
+	}
+
+	if len(c.RemovedDates) > 0 {
+		res = append(res, "removed "+strings.Join(c.RemovedDates, ", "))
+	}
+
+	if len(c.Fields) > 0 {
+		res = append(res, "changed "+strings.Join(c.Fields, ", "))
+	}
+
+	return res
+}
+
+// A diffField is a column whose before and after values are compared.
+type diffField struct {
+	name          string
+	before, after interface{}
+}
+
+// changedFields returns the names of fields whose values differ.
+func changedFields(fields ...diffField) []string {
+	var res []string
+	for _, f := range fields {
+		if f.before != f.after {
+			res = append(res, f.name)
+		}
+	}
+
+	return res
+}
+
+// modified returns a Change for an entity with the specified ID if any of
+// fields changed, and otherwise returns nil.
+func modified(id string, fields []string) *Change {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &Change{
+		ID:     id,
+		Type:   ChangeTypeModified,
+		Fields: fields,
+	}
+}
+
+func diffAgency(before, after *Agency) *Change {
+	return modified(after.ID, changedFields(
+		diffField{"agency_name", before.Name, after.Name},
+		diffField{"agency_url", before.URL, after.URL},
+		diffField{"agency_timezone", before.Timezone, after.Timezone},
+		diffField{"agency_lang", before.Lang, after.Lang},
+		diffField{"agency_phone", before.Phone, after.Phone},
+		diffField{"agency_fare_url", before.FareURL, after.FareURL},
+		diffField{"agency_email", before.Email, after.Email},
+	))
+}
+
+func diffStop(before, after *Stop, minDistance float64) *Change {
+	fields := changedFields(
+		diffField{"stop_code", before.Code, after.Code},
+		diffField{"stop_name", before.Name, after.Name},
+		diffField{"stop_desc", before.Description, after.Description},
+		diffField{"zone_id", before.ZoneID, after.ZoneID},
+		diffField{"stop_url", before.URL, after.URL},
+		diffField{"location_type", before.LocationType, after.LocationType},
+		diffField{"parent_station", stopID(before.ParentStation), stopID(after.ParentStation)},
+		diffField{"stop_timezone", before.Timezone, after.Timezone},
+		diffField{"wheelchair_boarding", before.WheelchairBoarding, after.WheelchairBoarding},
+		diffField{"platform_code", before.PlatformCode, after.PlatformCode},
+		diffField{"vehicle_type", before.VehicleType, after.VehicleType},
+	)
+
+	var dist float64
+	if d := haversine(before.Latitude, before.Longitude, after.Latitude, after.Longitude); d >= minDistance {
+		dist = d
+	}
+
+	if dist == 0 && len(fields) == 0 {
+		return nil
+	}
+
+	return &Change{
+		ID:       after.ID,
+		Type:     ChangeTypeModified,
+		Fields:   fields,
+		Distance: dist,
+	}
+}
+
+func diffRoute(before, after *Route) *Change {
+	return modified(after.ID, changedFields(
+		diffField{"agency_id", agencyID(before.Agency), agencyID(after.Agency)},
+		diffField{"route_short_name", before.ShortName, after.ShortName},
+		diffField{"route_long_name", before.LongName, after.LongName},
+		diffField{"route_desc", before.Description, after.Description},
+		diffField{"route_type", before.Type, after.Type},
+		diffField{"route_url", before.URL, after.URL},
+		diffField{"route_color", before.Color, after.Color},
+		diffField{"route_text_color", before.TextColor, after.TextColor},
+		diffField{"route_sort_order", before.SortOrder, after.SortOrder},
+		diffField{"continuous_pickup", before.ContinuousPickup, after.ContinuousPickup},
+		diffField{"continuous_drop_off", before.ContinuousDropOff, after.ContinuousDropOff},
+	))
+}
+
+// diffService compares the dates on which before and after are active.
+func diffService(before, after *Service) *Change {
+	beforeDates := before.activeDates()
+	afterDates := after.activeDates()
+
+	c := &Change{
+		ID:   after.ID,
+		Type: ChangeTypeModified,
+	}
+
+	for _, d := range afterDates {
+		if !containsString(beforeDates, d) {
+			c.AddedDates = append(c.AddedDates, d)
+		}
+	}
+
+	for _, d := range beforeDates {
+		if !containsString(afterDates, d) {
+			c.RemovedDates = append(c.RemovedDates, d)
+		}
+	}
+
+	if len(c.AddedDates) == 0 && len(c.RemovedDates) == 0 {
+		return nil
+	}
+
+	return c
+}
+
+// activeDates returns every date, in YYYYMMDD format, on which s is active, in
+// increasing order.
+func (s *Service) activeDates() []string {
+	first, last, ok := s.dateRange()
+	if !ok {
+		return nil
+	}
+
+	var res []string
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		if s.IsActiveOn(d) {
+			res = append(res, d.Format(dateFormat))
+		}
+	}
+
+	return res
+}
+
+func diffTrip(before, after *Trip) *Change {
+	fields := changedFields(
+		diffField{"route_id", routeID(before.Route), routeID(after.Route)},
+		diffField{"service_id", serviceID(before.Service), serviceID(after.Service)},
+		diffField{"shape_id", shapeID(before.Shape), shapeID(after.Shape)},
+		diffField{"trip_headsign", before.Headsign, after.Headsign},
+		diffField{"trip_short_name", before.ShortName, after.ShortName},
+		diffField{"direction_id", before.DirectionID, after.DirectionID},
+		diffField{"block_id", before.BlockID, after.BlockID},
+		diffField{"wheelchair_accessible", before.WheelchairAccessible, after.WheelchairAccessible},
+		diffField{"bikes_allowed", before.BikesAllowed, after.BikesAllowed},
+		diffField{"frequencies", fmt.Sprint(before.Frequencies), fmt.Sprint(after.Frequencies)},
+	)
+
+	shift, uniform := tripTimeShift(before, after)
+	if !uniform || !sameStops(before, after) {
+		fields = append(fields, "stop_times")
+	}
+
+	if shift == 0 && len(fields) == 0 {
+		return nil
+	}
+
+	return &Change{
+		ID:        after.ID,
+		Type:      ChangeTypeModified,
+		Fields:    fields,
+		TimeShift: shift,
+	}
+}
+
+// tripTimeShift returns the change in the first departure time between before and
+// after, along with whether all arrival and departure times shifted by the same
+// amount.
+func tripTimeShift(before, after *Trip) (time.Duration, bool) {
+	if len(before.Stops) == 0 || len(after.Stops) == 0 {
+		return 0, len(before.Stops) == len(after.Stops)
+	}
+
+	beforeDep, beforeErr := before.Stops[0].DepartureOffset()
+	afterDep, afterErr := after.Stops[0].DepartureOffset()
+	if beforeErr != nil || afterErr != nil {
+		return 0, false
+	}
+
+	shift := afterDep - beforeDep
+	if len(before.Stops) != len(after.Stops) {
+		return shift, false
+	}
+
+	for i, st := range before.Stops {
+		other := after.Stops[i]
+		if !shiftedBy(st.ArrivalTime, other.ArrivalTime, shift) || !shiftedBy(st.DepartureTime, other.DepartureTime, shift) {
+			return shift, false
+		}
+	}
+
+	return shift, true
+}
+
+// shiftedBy reports whether the time after is the time before shifted by shift.
+// Times that are empty on both sides are considered shifted, while times that
+// are only set on one side aren't.
+func shiftedBy(before, after string, shift time.Duration) bool {
+	if before == "" || after == "" {
+		return before == after
+	}
+
+	beforeTime, beforeErr := ParseTime(before)
+	afterTime, afterErr := ParseTime(after)
+
+	return beforeErr == nil && afterErr == nil && afterTime-beforeTime == shift
+}
+
+// sameStops reports whether before and after serve the same stops in the same
+// order, with the same stop time fields apart from arrival and departure
+// times.
+func sameStops(before, after *Trip) bool {
+	if len(before.Stops) != len(after.Stops) {
+		return false
+	}
+
+	for i, st := range before.Stops {
+		other := after.Stops[i]
+		if stopID(st.Stop) != stopID(other.Stop) || st.PickupType != other.PickupType || st.DropoffType != other.DropoffType || st.Headsign != other.Headsign || st.Timepoint != other.Timepoint {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsString(vals []string, s string) bool {
+	i := sort.SearchStrings(vals, s)
+	return i < len(vals) && vals[i] == s
+}
+
+func agencyID(a *Agency) string {
+	if a == nil {
+		return ""
+	}
+
+	return a.ID
+}
+
+func stopID(s *Stop) string {
+	if s == nil {
+		return ""
+	}
+
+	return s.ID
+}
+
+func routeID(r *Route) string {
+	if r == nil {
+		return ""
+	}
+
+	return r.ID
+}
+
+func serviceID(s *Service) string {
+	if s == nil {
+		return ""
+	}
+
+	return s.ID
+}
+
+func shapeID(s *Shape) string {
+	if s == nil {
+		return ""
+	}
+
+	return s.ID
+}
@@ -0,0 +1,197 @@
+package gtfs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newDiffTestFeeds() (*GTFS, *GTFS) {
+	agency := &Agency{ID: "agency", Name: "Transit"}
+
+	a := &Stop{ID: "a", Name: "A", Latitude: 40.0, Longitude: -75.0}
+	b := &Stop{ID: "b", Name: "B", Latitude: 40.1, Longitude: -75.0}
+	c := &Stop{ID: "c", Name: "C", Latitude: 40.2, Longitude: -75.0}
+	d := &Stop{ID: "d", Name: "D", Latitude: 40.3, Longitude: -75.0}
+	r1 := &Route{ID: "r1", Agency: agency, ShortName: "1"}
+	r2 := &Route{ID: "r2", Agency: agency, ShortName: "2"}
+	service := &Service{ID: "service", Monday: true, StartDate: "20190701", EndDate: "20190731"}
+
+	t1 := newBlockTestTrip("t1", "", service, []*Stop{a, b}, []string{"08:00:00", "08:10:00"})
+	t1.Route = r1
+	t2 := newBlockTestTrip("t2", "", service, []*Stop{a, b}, []string{"09:00:00", "09:10:00"})
+	t2.Route = r1
+	t3 := newBlockTestTrip("t3", "", service, []*Stop{b, c}, []string{"10:00:00", "10:10:00"})
+	t3.Route = r2
+
+	before := &GTFS{
+		Agencies: []*Agency{agency},
+		Stops:    []*Stop{a, b, c, d},
+		Routes:   []*Route{r1, r2},
+		Services: []*Service{service},
+		Trips:    []*Trip{t1, t2, t3},
+	}
+	before.index()
+
+	a2 := *a
+	a2.Latitude += 0.00001
+	b2 := *b
+	b2.Latitude += 0.01
+	c2 := *c
+	c2.Name = "See"
+	e := &Stop{ID: "e", Name: "E", Latitude: 40.4, Longitude: -75.0}
+	r12 := *r1
+	r12.Color = "FF0000"
+	r3 := &Route{ID: "r3", Agency: agency, ShortName: "3"}
+	service2 := *service
+	service2.ExceptDates = []string{"20190715"}
+	service2.AdditionalDates = []string{"20190804"}
+
+	t12 := newBlockTestTrip("t1", "", &service2, []*Stop{&a2, &b2}, []string{"08:05:00", "08:15:00"})
+	t12.Route = &r12
+	t22 := newBlockTestTrip("t2", "", &service2, []*Stop{&a2, &b2}, []string{"09:00:00", "09:20:00"})
+	t22.Route = &r12
+	t4 := newBlockTestTrip("t4", "", &service2, []*Stop{&b2, e}, []string{"11:00:00", "11:10:00"})
+	t4.Route = r3
+
+	after := &GTFS{
+		Agencies: []*Agency{agency},
+		Stops:    []*Stop{&a2, &b2, &c2, e},
+		Routes:   []*Route{&r12, r3},
+		Services: []*Service{&service2},
+		Trips:    []*Trip{t12, t22, t4},
+	}
+	after.index()
+
+	return before, after
+}
+
+func TestDiff(t *testing.T) {
+	before, after := newDiffTestFeeds()
+
+	got := Diff(before, after)
+	want := &ChangeSet{
+		Agencies: map[string]*Change{},
+		Stops: map[string]*Change{
+			"b": {ID: "b", Type: ChangeTypeModified, Distance: haversine(40.1, -75.0, 40.11, -75.0)},
+			"c": {ID: "c", Type: ChangeTypeModified, Fields: []string{"stop_name"}},
+			"d": {ID: "d", Type: ChangeTypeRemoved},
+			"e": {ID: "e", Type: ChangeTypeAdded},
+		},
+		Routes: map[string]*Change{
+			"r1": {ID: "r1", Type: ChangeTypeModified, Fields: []string{"route_color"}},
+			"r2": {ID: "r2", Type: ChangeTypeRemoved},
+			"r3": {ID: "r3", Type: ChangeTypeAdded},
+		},
+		Services: map[string]*Change{
+			"service": {ID: "service", Type: ChangeTypeModified, AddedDates: []string{"20190804"}, RemovedDates: []string{"20190715"}},
+		},
+		Trips: map[string]*Change{
+			"t1": {ID: "t1", Type: ChangeTypeModified, TimeShift: 5 * time.Minute},
+			"t2": {ID: "t2", Type: ChangeTypeModified, Fields: []string{"stop_times"}},
+			"t3": {ID: "t3", Type: ChangeTypeRemoved},
+			"t4": {ID: "t4", Type: ChangeTypeAdded},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+
+	wantSummary := map[string]ChangeCounts{
+		"agency":   {},
+		"stops":    {Added: 1, Removed: 1, Modified: 2},
+		"routes":   {Added: 1, Removed: 1, Modified: 1},
+		"calendar": {Modified: 1},
+		"trips":    {Added: 1, Removed: 1, Modified: 2},
+	}
+	if summary := got.Summary(); !reflect.DeepEqual(summary, wantSummary) {
+		t.Errorf("ChangeSet.Summary() = %v, want %v", summary, wantSummary)
+	}
+
+	wantReport := `stops: 1 added, 1 removed, 2 modified
+  ~ b: moved 1112 m
+  ~ c: changed stop_name
+  - d
+  + e
+routes: 1 added, 1 removed, 1 modified
+  ~ r1: changed route_color
+  - r2
+  + r3
+calendar: 0 added, 0 removed, 1 modified
+  ~ service: added 20190804; removed 20190715
+trips: 1 added, 1 removed, 2 modified
+  ~ t1: shifted +5m0s
+  ~ t2: changed stop_times
+  - t3
+  + t4
+`
+	if report := got.Report(); report != wantReport {
+		t.Errorf("ChangeSet.Report() = %v, want %v", report, wantReport)
+	}
+
+	if !Diff(before, before).Empty() {
+		t.Errorf("Diff() of identical feeds is not empty")
+	}
+}
+
+func TestDiffWithOptions(t *testing.T) {
+	before, after := newDiffTestFeeds()
+
+	got := DiffWithOptions(before, after, DiffOptions{StopMoveDistance: 0.5})
+	if c := got.Stops["a"]; c == nil || c.Distance == 0 {
+		t.Errorf("DiffWithOptions() stop a = %+v, want moved stop", c)
+	}
+
+	got = DiffWithOptions(before, after, DiffOptions{StopMoveDistance: 2000})
+	if c := got.Stops["b"]; c != nil {
+		t.Errorf("DiffWithOptions() stop b = %+v, want no change", c)
+	}
+}
+
+func Test_tripTimeShift(t *testing.T) {
+	a := &Stop{ID: "a"}
+	b := &Stop{ID: "b"}
+	c := &Stop{ID: "c"}
+
+	before := newBlockTestTrip("t", "", nil, []*Stop{a, b, c}, []string{"08:00:00", "08:10:00", "08:20:00"})
+	before.Stops[1].DepartureTime = ""
+
+	tests := []struct {
+		name        string
+		times       []string
+		departure   string
+		wantShift   time.Duration
+		wantUniform bool
+	}{
+		{
+			name:        "Uniform",
+			times:       []string{"08:05:00", "08:15:00", "08:25:00"},
+			wantShift:   5 * time.Minute,
+			wantUniform: true,
+		},
+		{
+			name:        "Non-uniform",
+			times:       []string{"08:05:00", "08:20:00", "08:25:00"},
+			wantShift:   5 * time.Minute,
+			wantUniform: false,
+		},
+		{
+			name:        "Departure Added",
+			times:       []string{"08:05:00", "08:15:00", "08:25:00"},
+			departure:   "08:15:00",
+			wantShift:   5 * time.Minute,
+			wantUniform: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := newBlockTestTrip("t", "", nil, []*Stop{a, b, c}, tt.times)
+			after.Stops[1].DepartureTime = tt.departure
+
+			shift, uniform := tripTimeShift(before, after)
+			if shift != tt.wantShift || uniform != tt.wantUniform {
+				t.Errorf("tripTimeShift() = %v, %v, want %v, %v", shift, uniform, tt.wantShift, tt.wantUniform)
+			}
+		})
+	}
+}
@@ -0,0 +1,611 @@
+package gtfs
+
+import "time"
+
+// FilterOptions specifies which parts of a feed are kept by Filter.
+//
+// Empty fields don't restrict the feed. When multiple fields are set, only
+// parts of the feed matching all of them are kept.
+type FilterOptions struct {
+	// AgencyIDs, RouteIDs and TripIDs restrict the feed to trips operated by
+	// the specified agencies, on the specified routes, or with the specified
+	// IDs.
+	AgencyIDs []string
+	RouteIDs  []string
+	TripIDs   []string
+
+	// StopIDs and BoundingBox restrict the feed to the specified stops or to
+	// stops within a bounding box. Stops within a station are kept if the
+	// station is listed in StopIDs.
+	StopIDs     []string
+	BoundingBox *BoundingBox
+
+	// StartDate and EndDate restrict the feed to service operating on or
+	// between the specified dates.
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// A BoundingBox is an area bounded by minimum and maximum latitudes and
+// longitudes.
+type BoundingBox struct {
+	MinLatitude  float64
+	MinLongitude float64
+	MaxLatitude  float64
+	MaxLongitude float64
+}
+
+// Contains reports whether the point at lat and lon is within b.
+func (b *BoundingBox) Contains(lat, lon float64) bool {
+	return lat >= b.MinLatitude && lat <= b.MaxLatitude && lon >= b.MinLongitude && lon <= b.MaxLongitude
+}
+
+// Filter returns a copy of g containing only the parts of the feed selected by
+// opts. g itself is not modified.
+//
+// Stop times at stops that aren't selected are removed, and trips are kept
+// only if they match opts, operate within the selected dates and, when stops
+// are restricted, still serve at least two stops. All other entities are then
+// kept only if they are referenced by a kept trip or by another kept entity,
+// so the result is self-consistent. Parent stations of kept stops are kept, as
+// are entrances and other locations within kept stations. Services are
+// trimmed to the selected dates.
+//
+// When stops are restricted, location groups keep only their selected stops,
+// and the frequencies of frequency-based trips whose first stop is removed are
+// shifted so that their runs keep their times. Fares restricted to zones are
+// kept only if a kept stop is in one of their zones.
+func (g *GTFS) Filter(opts FilterOptions) *GTFS {
+	agencyIDs := stringSet(opts.AgencyIDs)
+	routeIDs := stringSet(opts.RouteIDs)
+	tripIDs := stringSet(opts.TripIDs)
+	stopIDs := stringSet(opts.StopIDs)
+	restrictStops := stopIDs != nil || opts.BoundingBox != nil
+
+	stopSelected := func(s *Stop) bool {
+		if s == nil {
+			return !restrictStops
+		}
+
+		if stopIDs != nil && !stopIDs[s.ID] && (s.ParentStation == nil || !stopIDs[s.ParentStation.ID]) {
+			return false
+		}
+
+		return opts.BoundingBox == nil || opts.BoundingBox.Contains(s.Latitude, s.Longitude)
+	}
+
+	// memberStops holds the selected stops of each location group
+	memberStops := map[*LocationGroup][]*Stop{}
+	selectedMembers := func(lg *LocationGroup) []*Stop {
+		members, ok := memberStops[lg]
+		if !ok {
+			for _, s := range lg.Stops {
+				if stopSelected(s) {
+					members = append(members, s)
+				}
+			}
+
+			memberStops[lg] = members
+		}
+
+		return members
+	}
+
+	activeServices := map[*Service]bool{}
+	serviceActive := func(s *Service) bool {
+		if s == nil {
+			return false
+		}
+
+		active, ok := activeServices[s]
+		if !ok {
+			active = s.isActiveBetween(opts.StartDate, opts.EndDate)
+			activeServices[s] = active
+		}
+
+		return active
+	}
+
+	c := newCopier()
+	for _, t := range g.Trips {
+		if tripIDs != nil && !tripIDs[t.ID] {
+			continue
+		}
+
+		if routeIDs != nil && (t.Route == nil || !routeIDs[t.Route.ID]) {
+			continue
+		}
+
+		if agencyIDs != nil && (t.Route == nil || t.Route.Agency == nil || !agencyIDs[t.Route.Agency.ID]) {
+			continue
+		}
+
+		if !serviceActive(t.Service) {
+			continue
+		}
+
+		stops := t.Stops
+		var frequencies []Frequency
+		if restrictStops {
+			stops = nil
+			for _, st := range t.Stops {
+				if st.LocationGroup != nil {
+					if len(selectedMembers(st.LocationGroup)) > 0 {
+						stops = append(stops, st)
+					}
+				} else if stopSelected(st.Stop) {
+					stops = append(stops, st)
+				}
+			}
+
+			if len(stops) < 2 {
+				continue
+			}
+
+			// Runs of frequency-based trips are timed from their first stop,
+			// so their frequencies must move with it
+			if stops[0] != t.Stops[0] && !t.AbsoluteTimes {
+				var err error
+				frequencies, err = t.frequenciesFrom(stops[0])
+				if err != nil {
+					continue
+				}
+			}
+
+			for _, st := range stops {
+				if st.LocationGroup != nil {
+					c.locationGroupWithStops(st.LocationGroup, selectedMembers(st.LocationGroup))
+				}
+			}
+		}
+
+		cp := c.tripWithStops(t, stops)
+		if frequencies != nil {
+			cp.Frequencies = frequencies
+		}
+	}
+
+	// Keep entrances, generic nodes and boarding areas within kept stations
+	for _, s := range g.Stops {
+		if s.LocationType != LocationTypeStop && s.LocationType != LocationTypeStation && s.ParentStation != nil {
+			if _, ok := c.stops[s.ParentStation]; ok {
+				c.stop(s)
+			}
+		}
+	}
+
+	res := &GTFS{
+		FeedInfo:   g.FeedInfo,
+		strictMode: g.strictMode,
+	}
+
+	for _, a := range g.Agencies {
+		if cp, ok := c.agencies[a]; ok {
+			res.Agencies = append(res.Agencies, cp)
+		}
+	}
+
+	for _, s := range g.Stops {
+		if cp, ok := c.stops[s]; ok {
+			res.Stops = append(res.Stops, cp)
+		}
+	}
+
+	for _, r := range g.Routes {
+		if cp, ok := c.routes[r]; ok {
+			res.Routes = append(res.Routes, cp)
+		}
+	}
+
+	for _, s := range g.Services {
+		if cp, ok := c.services[s]; ok {
+			cp.trim(opts.StartDate, opts.EndDate)
+			res.Services = append(res.Services, cp)
+		}
+	}
+
+	for _, s := range g.Shapes {
+		if cp, ok := c.shapes[s]; ok {
+			res.Shapes = append(res.Shapes, cp)
+		}
+	}
+
+	for _, t := range g.Trips {
+		if cp, ok := c.trips[t]; ok {
+			res.Trips = append(res.Trips, cp)
+		}
+	}
+
+	for _, l := range g.Locations {
+		if cp, ok := c.locations[l]; ok {
+			res.Locations = append(res.Locations, cp)
+		}
+	}
+
+	for _, lg := range g.LocationGroups {
+		if cp, ok := c.locationGroups[lg]; ok {
+			res.LocationGroups = append(res.LocationGroups, cp)
+		}
+	}
+
+	for _, br := range g.BookingRules {
+		if cp, ok := c.bookingRules[br]; ok {
+			res.BookingRules = append(res.BookingRules, cp)
+		}
+	}
+
+	zones := map[string]bool{}
+	for _, s := range res.Stops {
+		if s.ZoneID != "" {
+			zones[s.ZoneID] = true
+		}
+	}
+
+	for _, f := range g.Fares {
+		if cp := c.filteredFare(f, zones); cp != nil {
+			res.Fares = append(res.Fares, cp)
+		}
+	}
+
+	for _, t := range g.Transfers {
+		if cp := c.filteredTransfer(t); cp != nil {
+			res.Transfers = append(res.Transfers, cp)
+		}
+	}
+
+	for _, a := range g.Attributions {
+		if cp := c.filteredAttribution(a); cp != nil {
+			res.Attributions = append(res.Attributions, cp)
+		}
+	}
+
+	records := res.translatableRecords()
+	for _, t := range g.Translations {
+		if hasTranslatedRecord(records, t) {
+			cp := *t
+			res.Translations = append(res.Translations, &cp)
+		}
+	}
+
+	res.index()
+
+	if !opts.StartDate.IsZero() && res.FeedInfo.StartDate != "" && res.FeedInfo.StartDate < opts.StartDate.Format(dateFormat) {
+		res.FeedInfo.StartDate = opts.StartDate.Format(dateFormat)
+	}
+
+	if !opts.EndDate.IsZero() && res.FeedInfo.EndDate != "" && res.FeedInfo.EndDate > opts.EndDate.Format(dateFormat) {
+		res.FeedInfo.EndDate = opts.EndDate.Format(dateFormat)
+	}
+
+	return res
+}
+
+// filteredFare copies f, keeping only routes that have been copied and, unless
+// zones is nil, zones that are in zones. Fares that only applied to routes that
+// haven't been copied, or whose origin, destination or contained zones are all
+// missing, are omitted.
+func (c *copier) filteredFare(f *Fare, zones map[string]bool) *Fare {
+	res := *f
+	res.Routes = nil
+	for _, r := range f.Routes {
+		if cp, ok := c.routes[r]; ok {
+			res.Routes = append(res.Routes, cp)
+		}
+	}
+
+	if len(f.Routes) > 0 && len(res.Routes) == 0 {
+		return nil
+	}
+
+	for _, list := range []*[]string{&res.OriginZones, &res.DestinationZones, &res.ContainsZones} {
+		var kept []string
+		for _, z := range *list {
+			if zones == nil || zones[z] {
+				kept = append(kept, z)
+			}
+		}
+
+		if len(*list) > 0 && len(kept) == 0 {
+			return nil
+		}
+
+		*list = kept
+	}
+
+	return &res
+}
+
+// frequenciesFrom returns the frequencies of t shifted so that runs of t
+// starting at first, which must be one of its stop times, depart from first at
+// the same times as they did before.
+func (t *Trip) frequenciesFrom(first *StopTime) ([]Frequency, error) {
+	if len(t.Stops) == 0 {
+		return nil, nil
+	}
+
+	start, err := t.Stops[0].DepartureOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	departure, err := first.DepartureOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	shift := departure - start
+
+	res := make([]Frequency, 0, len(t.Frequencies))
+	for _, f := range t.Frequencies {
+		startTime, err := ParseTime(f.StartTime)
+		if err != nil {
+			return nil, err
+		}
+
+		endTime, err := ParseTime(f.EndTime)
+		if err != nil {
+			return nil, err
+		}
+
+		f.StartTime = FormatTime(startTime + shift)
+		f.EndTime = FormatTime(endTime + shift)
+		res = append(res, f)
+	}
+
+	return res, nil
+}
+
+// filteredTransfer copies t if all of the entities it references have been
+// copied, and otherwise returns nil.
+func (c *copier) filteredTransfer(t *Transfer) *Transfer {
+	res := *t
+	var ok bool
+
+	if t.From != nil {
+		if res.From, ok = c.stops[t.From]; !ok {
+			return nil
+		}
+	}
+
+	if t.To != nil {
+		if res.To, ok = c.stops[t.To]; !ok {
+			return nil
+		}
+	}
+
+	if t.FromRoute != nil {
+		if res.FromRoute, ok = c.routes[t.FromRoute]; !ok {
+			return nil
+		}
+	}
+
+	if t.ToRoute != nil {
+		if res.ToRoute, ok = c.routes[t.ToRoute]; !ok {
+			return nil
+		}
+	}
+
+	if t.FromTrip != nil {
+		if res.FromTrip, ok = c.trips[t.FromTrip]; !ok {
+			return nil
+		}
+	}
+
+	if t.ToTrip != nil {
+		if res.ToTrip, ok = c.trips[t.ToTrip]; !ok {
+			return nil
+		}
+	}
+
+	return &res
+}
+
+// filteredAttribution copies a if it is feed-wide or the entity it references
+// has been copied, and otherwise returns nil.
+func (c *copier) filteredAttribution(a *Attribution) *Attribution {
+	res := *a
+
+	var ok bool
+	switch {
+	case a.Agency != nil:
+		res.Agency, ok = c.agencies[a.Agency]
+	case a.Route != nil:
+		res.Route, ok = c.routes[a.Route]
+	case a.Trip != nil:
+		res.Trip, ok = c.trips[a.Trip]
+	default:
+		ok = true
+	}
+
+	if !ok {
+		return nil
+	}
+
+	c.attributions[a] = &res
+
+	return &res
+}
+
+// translatableRecords returns the IDs of the records in g that translations
+// may reference by record_id, keyed by table name.
+func (g *GTFS) translatableRecords() map[string]map[string]bool {
+	res := map[string]map[string]bool{
+		"agency":       {},
+		"stops":        {},
+		"routes":       {},
+		"trips":        {},
+		"attributions": {},
+	}
+
+	for _, a := range g.Agencies {
+		res["agency"][a.ID] = true
+	}
+
+	for _, s := range g.Stops {
+		res["stops"][s.ID] = true
+	}
+
+	for _, r := range g.Routes {
+		res["routes"][r.ID] = true
+	}
+
+	for _, t := range g.Trips {
+		res["trips"][t.ID] = true
+	}
+
+	for _, a := range g.Attributions {
+		res["attributions"][a.ID] = true
+	}
+
+	res["stop_times"] = res["trips"]
+
+	return res
+}
+
+// hasTranslatedRecord reports whether the record translated by t is among
+// records, as returned by translatableRecords. Translations in the original
+// Google extension format, translations matching field values and translations
+// of other tables are assumed to be needed.
+func hasTranslatedRecord(records map[string]map[string]bool, t *Translation) bool {
+	if t.TableName == "" || t.FieldValue != "" {
+		return true
+	}
+
+	ids, ok := records[t.TableName]
+	if !ok {
+		return true
+	}
+
+	return ids[t.RecordID]
+}
+
+// isActiveBetween reports whether s is active on any date from start to end,
+// inclusive. A zero start or end leaves the range unbounded.
+func (s *Service) isActiveBetween(start, end time.Time) bool {
+	first, last, ok := s.dateRange()
+	if !ok {
+		return false
+	}
+
+	if !start.IsZero() && start.After(first) {
+		first = start
+	}
+
+	if !end.IsZero() && end.Before(last) {
+		last = end
+	}
+
+	for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+		if s.IsActiveOn(d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dateRange returns the first and last dates on which s could be active, along
+// with whether s has any dates at all.
+func (s *Service) dateRange() (time.Time, time.Time, bool) {
+	var first, last string
+	extend := func(d string) {
+		if d == "" {
+			return
+		}
+
+		if first == "" || d < first {
+			first = d
+		}
+
+		if last == "" || d > last {
+			last = d
+		}
+	}
+
+	extend(s.StartDate)
+	extend(s.EndDate)
+	for _, d := range s.AdditionalDates {
+		extend(d)
+	}
+
+	if first == "" {
+		return time.Time{}, time.Time{}, false
+	}
+
+	firstDate, err := time.Parse(dateFormat, first)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	lastDate, err := time.Parse(dateFormat, last)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return firstDate, lastDate, true
+}
+
+// trim restricts s to dates from start to end, inclusive. A zero start or end
+// leaves the range unbounded.
+func (s *Service) trim(start, end time.Time) {
+	var startStr, endStr string
+	if !start.IsZero() {
+		startStr = start.Format(dateFormat)
+	}
+
+	if !end.IsZero() {
+		endStr = end.Format(dateFormat)
+	}
+
+	inRange := func(d string) bool {
+		return (startStr == "" || d >= startStr) && (endStr == "" || d <= endStr)
+	}
+
+	if s.StartDate != "" {
+		if startStr != "" && s.StartDate < startStr {
+			s.StartDate = startStr
+		}
+
+		if endStr != "" && s.EndDate > endStr {
+			s.EndDate = endStr
+		}
+
+		// Services kept only for their additional dates no longer have a
+		// weekly schedule, as with services defined only in
+		// calendar_dates.txt
+		if s.StartDate > s.EndDate {
+			s.StartDate, s.EndDate = "", ""
+			s.Monday, s.Tuesday, s.Wednesday, s.Thursday, s.Friday, s.Saturday, s.Sunday = false, false, false, false, false, false, false
+		}
+	}
+
+	var additional, except []string
+	for _, d := range s.AdditionalDates {
+		if inRange(d) {
+			additional = append(additional, d)
+		}
+	}
+
+	for _, d := range s.ExceptDates {
+		if inRange(d) {
+			except = append(except, d)
+		}
+	}
+
+	s.AdditionalDates = additional
+	s.ExceptDates = except
+}
+
+// stringSet returns a set containing vals, or nil if vals is empty.
+func stringSet(vals []string) map[string]bool {
+	if len(vals) == 0 {
+		return nil
+	}
+
+	res := make(map[string]bool, len(vals))
+	for _, v := range vals {
+		res[v] = true
+	}
+
+	return res
+}
@@ -0,0 +1,344 @@
+package gtfs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newFilterTestFeed creates a small feed with two agencies, each operating a
+// single route with a single trip.
+func newFilterTestFeed() *GTFS {
+	a1 := &Agency{ID: "a1"}
+	a2 := &Agency{ID: "a2"}
+	r1 := &Route{ID: "r1", Agency: a1}
+	r2 := &Route{ID: "r2", Agency: a2}
+
+	station := &Stop{ID: "station", Latitude: 40.0, Longitude: -75.0, LocationType: LocationTypeStation}
+	p1 := &Stop{ID: "p1", Latitude: 40.0, Longitude: -75.0, ParentStation: station}
+	e1 := &Stop{ID: "e1", Latitude: 40.0, Longitude: -75.0, ParentStation: station, LocationType: LocationTypeStationEntrance}
+	s2 := &Stop{ID: "s2", Latitude: 40.1, Longitude: -75.0}
+	s3 := &Stop{ID: "s3", Latitude: 41.0, Longitude: -75.0, ZoneID: "z1"}
+
+	weekday := &Service{
+		ID:          "weekday",
+		Monday:      true,
+		Tuesday:     true,
+		Wednesday:   true,
+		Thursday:    true,
+		Friday:      true,
+		StartDate:   "20190101",
+		EndDate:     "20191231",
+		ExceptDates: []string{"20190101", "20190704"},
+	}
+	special := &Service{
+		ID:              "special",
+		AdditionalDates: []string{"20190704"},
+	}
+
+	shape := &Shape{ID: "sh1", Points: []*ShapePoint{{Latitude: 40.0, Longitude: -75.0}, {Latitude: 41.0, Longitude: -75.0}}}
+
+	t1 := newBlockTestTrip("t1", "", weekday, []*Stop{p1, s2, s3}, []string{"08:00:00", "08:10:00", "08:20:00"})
+	t1.Route = r1
+	t1.Shape = shape
+	t2 := newBlockTestTrip("t2", "", special, []*Stop{s2, s3}, []string{"09:00:00", "09:10:00"})
+	t2.Route = r2
+
+	g := &GTFS{
+		Agencies: []*Agency{a1, a2},
+		Stops:    []*Stop{station, p1, e1, s2, s3},
+		Routes:   []*Route{r1, r2},
+		Services: []*Service{weekday, special},
+		Shapes:   []*Shape{shape},
+		Trips:    []*Trip{t1, t2},
+		Fares: []*Fare{
+			{ID: "f1", Routes: []*Route{r1}},
+			{ID: "f2", Routes: []*Route{r2}},
+			{ID: "f3", OriginZones: []string{"z1"}},
+		},
+		Transfers: []*Transfer{
+			{From: p1, To: s2, Type: TransferTypeMinimumTime, MinimumTransferTime: 60},
+			{From: s2, To: s3, FromRoute: r2, Type: TransferTypeNone},
+		},
+		FeedInfo: FeedInfo{
+			PublisherName: "Publisher",
+			StartDate:     "20190101",
+			EndDate:       "20191231",
+		},
+		Translations: []*Translation{
+			{TableName: "stops", FieldName: "stop_name", RecordID: "p1", Language: "fr", Translation: "Quai 1"},
+			{TableName: "stops", FieldName: "stop_name", RecordID: "s3", Language: "fr", Translation: "Arrêt 3"},
+			{TableName: "routes", FieldName: "route_long_name", RecordID: "r2", Language: "fr", Translation: "Ligne 2"},
+			{ID: "Main Street", Language: "fr", Translation: "Rue Principale"},
+		},
+		Attributions: []*Attribution{
+			{ID: "feed", OrganizationName: "Feed", IsProducer: true},
+			{ID: "route", Route: r2, OrganizationName: "Route", IsOperator: true},
+		},
+	}
+	g.index()
+
+	return g
+}
+
+func filterTestIDs(g *GTFS) map[string][]string {
+	res := map[string][]string{}
+	for _, a := range g.Agencies {
+		res["agencies"] = append(res["agencies"], a.ID)
+	}
+	for _, s := range g.Stops {
+		res["stops"] = append(res["stops"], s.ID)
+	}
+	for _, r := range g.Routes {
+		res["routes"] = append(res["routes"], r.ID)
+	}
+	for _, s := range g.Services {
+		res["services"] = append(res["services"], s.ID)
+	}
+	for _, s := range g.Shapes {
+		res["shapes"] = append(res["shapes"], s.ID)
+	}
+	for _, t := range g.Trips {
+		res["trips"] = append(res["trips"], t.ID)
+		for _, st := range t.Stops {
+			res["stop_times"] = append(res["stop_times"], t.ID+":"+st.Stop.ID)
+		}
+	}
+	for _, f := range g.Fares {
+		res["fares"] = append(res["fares"], f.ID)
+	}
+	for _, t := range g.Transfers {
+		res["transfers"] = append(res["transfers"], t.From.ID+":"+t.To.ID)
+	}
+	for _, t := range g.Translations {
+		res["translations"] = append(res["translations"], t.Translation)
+	}
+	for _, a := range g.Attributions {
+		res["attributions"] = append(res["attributions"], a.ID)
+	}
+
+	return res
+}
+
+func TestGTFS_Filter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts FilterOptions
+		want map[string][]string
+	}{
+		{
+			name: "Everything",
+			opts: FilterOptions{},
+			want: filterTestIDs(newFilterTestFeed()),
+		},
+		{
+			name: "Agency",
+			opts: FilterOptions{AgencyIDs: []string{"a1"}},
+			want: map[string][]string{
+				"agencies":     {"a1"},
+				"stops":        {"station", "p1", "e1", "s2", "s3"},
+				"routes":       {"r1"},
+				"services":     {"weekday"},
+				"shapes":       {"sh1"},
+				"trips":        {"t1"},
+				"stop_times":   {"t1:p1", "t1:s2", "t1:s3"},
+				"fares":        {"f1", "f3"},
+				"transfers":    {"p1:s2"},
+				"translations": {"Quai 1", "Arrêt 3", "Rue Principale"},
+				"attributions": {"feed"},
+			},
+		},
+		{
+			name: "Route",
+			opts: FilterOptions{RouteIDs: []string{"r2"}},
+			want: map[string][]string{
+				"agencies":     {"a2"},
+				"stops":        {"s2", "s3"},
+				"routes":       {"r2"},
+				"services":     {"special"},
+				"trips":        {"t2"},
+				"stop_times":   {"t2:s2", "t2:s3"},
+				"fares":        {"f2", "f3"},
+				"transfers":    {"s2:s3"},
+				"translations": {"Arrêt 3", "Ligne 2", "Rue Principale"},
+				"attributions": {"feed", "route"},
+			},
+		},
+		{
+			name: "Bounding Box",
+			opts: FilterOptions{BoundingBox: &BoundingBox{MinLatitude: 39.5, MinLongitude: -75.5, MaxLatitude: 40.5, MaxLongitude: -74.5}},
+			want: map[string][]string{
+				"agencies":     {"a1"},
+				"stops":        {"station", "p1", "e1", "s2"},
+				"routes":       {"r1"},
+				"services":     {"weekday"},
+				"shapes":       {"sh1"},
+				"trips":        {"t1"},
+				"stop_times":   {"t1:p1", "t1:s2"},
+				"fares":        {"f1"},
+				"transfers":    {"p1:s2"},
+				"translations": {"Quai 1", "Rue Principale"},
+				"attributions": {"feed"},
+			},
+		},
+		{
+			name: "Station",
+			opts: FilterOptions{StopIDs: []string{"station", "s3"}},
+			want: map[string][]string{
+				"agencies":     {"a1"},
+				"stops":        {"station", "p1", "e1", "s3"},
+				"routes":       {"r1"},
+				"services":     {"weekday"},
+				"shapes":       {"sh1"},
+				"trips":        {"t1"},
+				"stop_times":   {"t1:p1", "t1:s3"},
+				"fares":        {"f1", "f3"},
+				"translations": {"Quai 1", "Arrêt 3", "Rue Principale"},
+				"attributions": {"feed"},
+			},
+		},
+		{
+			name: "Dates",
+			opts: FilterOptions{
+				StartDate: time.Date(2019, time.July, 6, 0, 0, 0, 0, time.UTC),
+				EndDate:   time.Date(2019, time.July, 19, 0, 0, 0, 0, time.UTC),
+			},
+			want: map[string][]string{
+				"agencies":     {"a1"},
+				"stops":        {"station", "p1", "e1", "s2", "s3"},
+				"routes":       {"r1"},
+				"services":     {"weekday"},
+				"shapes":       {"sh1"},
+				"trips":        {"t1"},
+				"stop_times":   {"t1:p1", "t1:s2", "t1:s3"},
+				"fares":        {"f1", "f3"},
+				"transfers":    {"p1:s2"},
+				"translations": {"Quai 1", "Arrêt 3", "Rue Principale"},
+				"attributions": {"feed"},
+			},
+		},
+		{
+			name: "Nothing",
+			opts: FilterOptions{TripIDs: []string{"t3"}},
+			want: map[string][]string{
+				"translations": {"Rue Principale"},
+				"attributions": {"feed"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newFilterTestFeed()
+			before := filterTestIDs(g)
+
+			res := g.Filter(tt.opts)
+			if got := filterTestIDs(res); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GTFS.Filter() = %v, want %v", got, tt.want)
+			}
+
+			if after := filterTestIDs(g); !reflect.DeepEqual(after, before) {
+				t.Errorf("GTFS.Filter() modified original feed: %v, want %v", after, before)
+			}
+
+			// References must point to entities in the filtered feed
+			for _, trip := range res.Trips {
+				if trip.Route != res.routeByID(trip.Route.ID) || trip.Service != res.serviceByID(trip.Service.ID) {
+					t.Errorf("GTFS.Filter() trip %s references entities outside of the filtered feed", trip.ID)
+				}
+
+				for _, st := range trip.Stops {
+					if st.Stop != res.stopByID(st.Stop.ID) {
+						t.Errorf("GTFS.Filter() trip %s references stop %s outside of the filtered feed", trip.ID, st.Stop.ID)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGTFS_Filter_dates(t *testing.T) {
+	g := newFilterTestFeed()
+
+	// The weekly schedule of the special service falls outside the window
+	special := g.serviceByID("special")
+	special.Saturday = true
+	special.StartDate = "20190801"
+	special.EndDate = "20191231"
+
+	res := g.Filter(FilterOptions{
+		StartDate: time.Date(2019, time.July, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2019, time.July, 14, 0, 0, 0, 0, time.UTC),
+	})
+
+	weekday := res.serviceByID("weekday")
+	if weekday == nil || weekday.StartDate != "20190701" || weekday.EndDate != "20190714" || !reflect.DeepEqual(weekday.ExceptDates, []string{"20190704"}) {
+		t.Errorf("GTFS.Filter() weekday service = %+v, want service trimmed to July 1-14", weekday)
+	}
+
+	if special := res.serviceByID("special"); special == nil || special.StartDate != "" || special.EndDate != "" || special.Saturday || !reflect.DeepEqual(special.AdditionalDates, []string{"20190704"}) {
+		t.Errorf("GTFS.Filter() special service = %+v, want July 4 only", special)
+	}
+
+	if res.FeedInfo.StartDate != "20190701" || res.FeedInfo.EndDate != "20190714" || res.FeedInfo.PublisherName != "Publisher" {
+		t.Errorf("GTFS.Filter() FeedInfo = %+v, want dates trimmed to July 1-14", res.FeedInfo)
+	}
+
+	if got := res.TranslateStopName(res.stopByID("p1"), "fr"); got != "Quai 1" {
+		t.Errorf("GTFS.TranslateStopName() on filtered feed = %v, want %v", got, "Quai 1")
+	}
+
+	if g.serviceByID("weekday").StartDate != "20190101" {
+		t.Errorf("GTFS.Filter() modified original service")
+	}
+}
+
+func TestGTFS_Filter_frequencies(t *testing.T) {
+	g := newFilterTestFeed()
+
+	t1 := g.tripByID("t1")
+	t1.AbsoluteTimes = false
+	t1.Frequencies = []Frequency{{StartTime: "06:00:00", EndTime: "07:00:00", HeadwaySeconds: 600}}
+
+	res := g.Filter(FilterOptions{StopIDs: []string{"s2", "s3"}})
+
+	trip := res.tripByID("t1")
+	if trip == nil {
+		t.Fatalf("GTFS.Filter() removed frequency-based trip")
+	}
+
+	want := []Frequency{{StartTime: "06:10:00", EndTime: "07:10:00", HeadwaySeconds: 600}}
+	if !reflect.DeepEqual(trip.Frequencies, want) {
+		t.Errorf("GTFS.Filter() Frequencies = %+v, want %+v", trip.Frequencies, want)
+	}
+
+	if !reflect.DeepEqual(t1.Frequencies[0], Frequency{StartTime: "06:00:00", EndTime: "07:00:00", HeadwaySeconds: 600}) {
+		t.Errorf("GTFS.Filter() modified original frequencies: %+v", t1.Frequencies)
+	}
+}
+
+func TestGTFS_Filter_locationGroups(t *testing.T) {
+	g := newFilterTestFeed()
+
+	s2 := g.stopByID("s2")
+	s3 := g.stopByID("s3")
+	lg := &LocationGroup{ID: "lg", Stops: []*Stop{s2, s3}}
+	g.LocationGroups = []*LocationGroup{lg}
+
+	t1 := g.tripByID("t1")
+	t1.Stops[2].Stop = nil
+	t1.Stops[2].LocationGroup = lg
+
+	res := g.Filter(FilterOptions{StopIDs: []string{"station", "s2"}})
+
+	if len(res.LocationGroups) != 1 || len(res.LocationGroups[0].Stops) != 1 || res.LocationGroups[0].Stops[0] != res.stopByID("s2") {
+		t.Fatalf("GTFS.Filter() LocationGroups = %+v, want group with s2 only", res.LocationGroups)
+	}
+
+	if res.stopByID("s3") != nil {
+		t.Errorf("GTFS.Filter() kept stop s3 outside of the selection")
+	}
+
+	if trip := res.tripByID("t1"); trip == nil || len(trip.Stops) != 3 || trip.Stops[2].LocationGroup != res.LocationGroups[0] {
+		t.Errorf("GTFS.Filter() trip t1 = %+v, want stop time at filtered location group", trip)
+	}
+}
@@ -0,0 +1,123 @@
+package gtfs
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultFootpathDistance is the maximum straight-line distance, in
+	// meters, between stops connected by generated footpaths when none is
+	// specified.
+	DefaultFootpathDistance = 400.0
+
+	// DefaultDetourFactor is the ratio between walking distance and
+	// straight-line distance used for generated footpaths when none is
+	// specified.
+	DefaultDetourFactor = 1.25
+
+	// DefaultWalkingSpeed is the walking speed, in meters per second, used for
+	// generated footpaths when none is specified.
+	DefaultWalkingSpeed = 1.3
+)
+
+// FootpathOptions specifies options used when generating footpaths.
+type FootpathOptions struct {
+	// MaxDistance is the maximum straight-line distance, in meters, between
+	// stops connected by a footpath. If zero, DefaultFootpathDistance is used.
+	MaxDistance float64
+
+	// DetourFactor is the ratio between the distance actually walked and the
+	// straight-line distance between stops. If zero, DefaultDetourFactor is
+	// used.
+	DetourFactor float64
+
+	// WalkingSpeed is the walking speed, in meters per second. If zero,
+	// DefaultWalkingSpeed is used.
+	WalkingSpeed float64
+}
+
+// GenerateFootpaths computes walking transfers between all pairs of stops
+// within opts.MaxDistance of each other.
+//
+// Footpaths are returned as transfers of type TransferTypeMinimumTime, with
+// MinimumTransferTime set to the time needed to walk between the stops. Only
+// stops with location type LocationTypeStop are considered, and no footpath is
+// generated for a pair of stops with an explicit transfer in g.Transfers that
+// applies to all trips, so the result may be appended to g.Transfers without
+// overriding them.
+func (g *GTFS) GenerateFootpaths(opts FootpathOptions) []*Transfer {
+	if opts.MaxDistance == 0 {
+		opts.MaxDistance = DefaultFootpathDistance
+	}
+
+	if opts.DetourFactor == 0 {
+		opts.DetourFactor = DefaultDetourFactor
+	}
+
+	if opts.WalkingSpeed == 0 {
+		opts.WalkingSpeed = DefaultWalkingSpeed
+	}
+
+	explicit := map[[2]*Stop]bool{}
+	for _, t := range g.Transfers {
+		if t.AppliesToAllTrips() {
+			explicit[[2]*Stop{t.From, t.To}] = true
+		}
+	}
+
+	var stops []*Stop
+	for _, s := range g.Stops {
+		if s.LocationType == LocationTypeStop {
+			stops = append(stops, s)
+		}
+	}
+
+	// Sweep over stops ordered by latitude, so that only stops within the
+	// maximum distance north-south of each other are compared
+	sort.SliceStable(stops, func(i, j int) bool {
+		return stops[i].Latitude < stops[j].Latitude
+	})
+
+	maxLat := opts.MaxDistance / (earthRadius * math.Pi / 180)
+
+	var res []*Transfer
+	for i, from := range stops {
+		for _, to := range stops[i+1:] {
+			if to.Latitude-from.Latitude > maxLat {
+				break
+			}
+
+			distance := from.DistanceTo(to)
+			if distance > opts.MaxDistance {
+				continue
+			}
+
+			seconds := uint64(math.Ceil(distance * opts.DetourFactor / opts.WalkingSpeed))
+
+			for _, pair := range [][2]*Stop{{from, to}, {to, from}} {
+				if explicit[pair] {
+					continue
+				}
+
+				res = append(res, &Transfer{
+					From:                pair[0],
+					To:                  pair[1],
+					Type:                TransferTypeMinimumTime,
+					MinimumTransferTime: seconds,
+				})
+			}
+		}
+	}
+
+	return res
+}
+
+// AddFootpaths generates footpaths as with GenerateFootpaths and appends them to
+// g.Transfers, returning the footpaths added.
+func (g *GTFS) AddFootpaths(opts FootpathOptions) []*Transfer {
+	res := g.GenerateFootpaths(opts)
+	g.Transfers = append(g.Transfers, res...)
+
+	return res
+}
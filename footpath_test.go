@@ -0,0 +1,95 @@
+package gtfs
+
+import "testing"
+
+func TestGTFS_GenerateFootpaths(t *testing.T) {
+	a := &Stop{ID: "a", Latitude: 40.0, Longitude: -75.0}
+	b := &Stop{ID: "b", Latitude: 40.001, Longitude: -75.0}
+	c := &Stop{ID: "c", Latitude: 40.01, Longitude: -75.0}
+	station := &Stop{ID: "station", Latitude: 40.0005, Longitude: -75.0, LocationType: LocationTypeStation}
+
+	tests := []struct {
+		name      string
+		transfers []*Transfer
+		opts      FootpathOptions
+		want      map[[2]string]uint64
+	}{
+		{
+			name: "Defaults",
+			want: map[[2]string]uint64{
+				{"a", "b"}: 107,
+				{"b", "a"}: 107,
+			},
+		},
+		{
+			name: "Custom Options",
+			opts: FootpathOptions{
+				MaxDistance:  2000,
+				DetourFactor: 1,
+				WalkingSpeed: 1,
+			},
+			want: map[[2]string]uint64{
+				{"a", "b"}: 112,
+				{"b", "a"}: 112,
+				{"a", "c"}: 1112,
+				{"c", "a"}: 1112,
+				{"b", "c"}: 1001,
+				{"c", "b"}: 1001,
+			},
+		},
+		{
+			name: "Explicit Transfer",
+			transfers: []*Transfer{
+				{From: a, To: b, Type: TransferTypeNone},
+			},
+			want: map[[2]string]uint64{
+				{"b", "a"}: 107,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{
+				Stops:     []*Stop{c, station, b, a},
+				Transfers: tt.transfers,
+			}
+
+			got := g.GenerateFootpaths(tt.opts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("GTFS.GenerateFootpaths() returned %d footpaths, want %d", len(got), len(tt.want))
+			}
+
+			for _, fp := range got {
+				want, ok := tt.want[[2]string{fp.From.ID, fp.To.ID}]
+				if !ok {
+					t.Errorf("GTFS.GenerateFootpaths() returned unexpected footpath from %s to %s", fp.From.ID, fp.To.ID)
+					continue
+				}
+
+				if fp.Type != TransferTypeMinimumTime || fp.MinimumTransferTime != want {
+					t.Errorf("GTFS.GenerateFootpaths() footpath from %s to %s = %+v, want minimum time of %d", fp.From.ID, fp.To.ID, fp, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGTFS_AddFootpaths(t *testing.T) {
+	a := &Stop{ID: "a", Latitude: 40.0, Longitude: -75.0}
+	b := &Stop{ID: "b", Latitude: 40.001, Longitude: -75.0}
+	explicit := &Transfer{From: a, To: b, Type: TransferTypeNone}
+
+	g := &GTFS{
+		Stops:     []*Stop{a, b},
+		Transfers: []*Transfer{explicit},
+	}
+
+	added := g.AddFootpaths(FootpathOptions{})
+	if len(added) != 1 || len(g.Transfers) != 2 {
+		t.Fatalf("GTFS.AddFootpaths() added %d footpaths, leaving %d transfers, want 1 and 2", len(added), len(g.Transfers))
+	}
+
+	if g.Transfers[0] != explicit || g.Transfers[1] != added[0] {
+		t.Errorf("GTFS.AddFootpaths() transfers = %v, want explicit transfer followed by footpath", g.Transfers)
+	}
+}
@@ -0,0 +1,59 @@
+package gtfs
+
+import "math"
+
+// earthRadius is the mean radius of the Earth, in meters.
+const earthRadius = 6371008.8
+
+// haversine returns the great-circle distance, in meters, between two points.
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// DistanceTo returns the straight-line distance, in meters, between s and o.
+func (s *Stop) DistanceTo(o *Stop) float64 {
+	return haversine(s.Latitude, s.Longitude, o.Latitude, o.Longitude)
+}
+
+// DistanceUnit specifies the unit in which a distance is measured.
+type DistanceUnit int
+
+const (
+	// DistanceUnitMeters indicates distances measured in meters.
+	DistanceUnitMeters DistanceUnit = iota
+
+	// DistanceUnitKilometers indicates distances measured in kilometers.
+	DistanceUnitKilometers
+
+	// DistanceUnitMiles indicates distances measured in miles.
+	DistanceUnitMiles
+
+	// DistanceUnitFeet indicates distances measured in feet.
+	DistanceUnitFeet
+)
+
+// Meters converts a distance measured in u to meters.
+func (u DistanceUnit) Meters(distance float64) float64 {
+	switch u {
+	case DistanceUnitKilometers:
+		return distance * 1000
+	case DistanceUnitMiles:
+		return distance * 1609.344
+	case DistanceUnitFeet:
+		return distance * 0.3048
+	default:
+		return distance
+	}
+}
+
+// FromMeters converts a distance measured in meters to u.
+func (u DistanceUnit) FromMeters(distance float64) float64 {
+	return distance / u.Meters(1)
+}
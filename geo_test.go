@@ -0,0 +1,97 @@
+package gtfs
+
+import (
+	"math"
+	"testing"
+)
+
+func Test_haversine(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{
+			name: "Same Point",
+			lat1: 40.7128,
+			lon1: -74.006,
+			lat2: 40.7128,
+			lon2: -74.006,
+			want: 0,
+		},
+		{
+			name: "One Degree of Latitude",
+			lat1: 0,
+			lon1: 0,
+			lat2: 1,
+			lon2: 0,
+			want: 111195,
+		},
+		{
+			name: "New York to London",
+			lat1: 40.7128,
+			lon1: -74.006,
+			lat2: 51.5074,
+			lon2: -0.1278,
+			want: 5570230,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := haversine(tt.lat1, tt.lon1, tt.lat2, tt.lon2); math.Abs(got-tt.want) > tt.want*0.001+1 {
+				t.Errorf("haversine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStop_DistanceTo(t *testing.T) {
+	a := &Stop{Latitude: 0, Longitude: 0}
+	b := &Stop{Latitude: 0, Longitude: 0.01}
+	if got := a.DistanceTo(b); math.Abs(got-1112) > 1 {
+		t.Errorf("Stop.DistanceTo() = %v, want %v", got, 1112)
+	}
+	if got := b.DistanceTo(a); math.Abs(got-a.DistanceTo(b)) > 1e-9 {
+		t.Errorf("Stop.DistanceTo() is not symmetric")
+	}
+}
+
+func TestDistanceUnit_Meters(t *testing.T) {
+	tests := []struct {
+		name string
+		unit DistanceUnit
+		want float64
+	}{
+		{
+			name: "Meters",
+			unit: DistanceUnitMeters,
+			want: 2,
+		},
+		{
+			name: "Kilometers",
+			unit: DistanceUnitKilometers,
+			want: 2000,
+		},
+		{
+			name: "Miles",
+			unit: DistanceUnitMiles,
+			want: 3218.688,
+		},
+		{
+			name: "Feet",
+			unit: DistanceUnitFeet,
+			want: 0.6096,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.unit.Meters(2); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("DistanceUnit.Meters() = %v, want %v", got, tt.want)
+			}
+
+			if got := tt.unit.FromMeters(tt.want); math.Abs(got-2) > 1e-9 {
+				t.Errorf("DistanceUnit.FromMeters() = %v, want %v", got, 2)
+			}
+		})
+	}
+}
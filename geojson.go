@@ -0,0 +1,224 @@
+package gtfs
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type geoJSONOutputFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   geoJSONOutputGeometry  `json:"geometry"`
+}
+
+type geoJSONOutputGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// A featureWriter streams a GeoJSON FeatureCollection to a writer, one feature
+// at a time.
+type featureWriter struct {
+	w   io.Writer
+	n   int
+	err error
+}
+
+func newFeatureWriter(w io.Writer) *featureWriter {
+	fw := &featureWriter{w: w}
+	_, fw.err = io.WriteString(w, `{"type":"FeatureCollection","features":[`)
+
+	return fw
+}
+
+func (fw *featureWriter) write(f *geoJSONOutputFeature) {
+	if fw.err != nil {
+		return
+	}
+
+	f.Type = "Feature"
+	buf, err := json.Marshal(f)
+	if err != nil {
+		fw.err = err
+		return
+	}
+
+	if fw.n > 0 {
+		if _, fw.err = io.WriteString(fw.w, ","); fw.err != nil {
+			return
+		}
+	}
+
+	_, fw.err = fw.w.Write(buf)
+	fw.n++
+}
+
+func (fw *featureWriter) close() error {
+	if fw.err != nil {
+		return fw.err
+	}
+
+	_, err := io.WriteString(fw.w, "]}\n")
+
+	return err
+}
+
+// WriteStopsGeoJSON writes all stops in g to w as a GeoJSON FeatureCollection.
+//
+// Each stop is a Point feature with the stop's ID and properties containing its
+// name, code, location type and parent station.
+func (g *GTFS) WriteStopsGeoJSON(w io.Writer) error {
+	fw := newFeatureWriter(w)
+	for _, s := range g.Stops {
+		var parent interface{}
+		if s.ParentStation != nil {
+			parent = s.ParentStation.ID
+		}
+
+		fw.write(&geoJSONOutputFeature{
+			ID: s.ID,
+			Properties: map[string]interface{}{
+				"name":           s.Name,
+				"code":           s.Code,
+				"location_type":  s.LocationType,
+				"parent_station": parent,
+			},
+			Geometry: geoJSONOutputGeometry{
+				Type:        "Point",
+				Coordinates: [2]float64{s.Longitude, s.Latitude},
+			},
+		})
+	}
+
+	return fw.close()
+}
+
+// WriteShapesGeoJSON writes all shapes in g to w as a GeoJSON
+// FeatureCollection, with each shape as a LineString feature with the shape's
+// ID. Shapes with fewer than two points, which aren't valid LineStrings, are
+// omitted.
+func (g *GTFS) WriteShapesGeoJSON(w io.Writer) error {
+	fw := newFeatureWriter(w)
+	for _, s := range g.Shapes {
+		if len(s.Points) < 2 {
+			continue
+		}
+
+		fw.write(&geoJSONOutputFeature{
+			ID:         s.ID,
+			Properties: map[string]interface{}{},
+			Geometry: geoJSONOutputGeometry{
+				Type:        "LineString",
+				Coordinates: shapeCoordinates(s.Points),
+			},
+		})
+	}
+
+	return fw.close()
+}
+
+// WriteRoutesGeoJSON writes all routes in g to w as a GeoJSON
+// FeatureCollection.
+//
+// Each route is a MultiLineString feature with the route's ID, containing one
+// line for each distinct shape used by the route's trips. Trips without shapes
+// contribute straight lines between their stops instead. Properties contain
+// the route's short and long names, GTFS route type code, and colors as CSS
+// hex colors. Routes without any trips are omitted.
+func (g *GTFS) WriteRoutesGeoJSON(w io.Writer) error {
+	linesByRoute := g.routeLines()
+
+	fw := newFeatureWriter(w)
+	for _, r := range g.Routes {
+		lines, ok := linesByRoute[r]
+		if !ok {
+			continue
+		}
+
+		coords := make([][][2]float64, 0, len(lines))
+		for _, l := range lines {
+			coords = append(coords, shapeCoordinates(l))
+		}
+
+		fw.write(&geoJSONOutputFeature{
+			ID: r.ID,
+			Properties: map[string]interface{}{
+				"short_name": r.ShortName,
+				"long_name":  r.LongName,
+				"route_type": r.Type.Code(),
+				"color":      cssColor(r.Color),
+				"text_color": cssColor(r.TextColor),
+			},
+			Geometry: geoJSONOutputGeometry{
+				Type:        "MultiLineString",
+				Coordinates: coords,
+			},
+		})
+	}
+
+	return fw.close()
+}
+
+// routeLines returns the distinct lines followed by the trips on each route in
+// g. Trips without shapes follow straight lines between their stops.
+func (g *GTFS) routeLines() map[*Route][][]*ShapePoint {
+	res := map[*Route][][]*ShapePoint{}
+	seen := map[*Route]map[string]bool{}
+
+	for _, t := range g.Trips {
+		if t.Route == nil {
+			continue
+		}
+
+		var points []*ShapePoint
+		if t.Shape != nil {
+			points = t.Shape.Points
+		} else {
+			for _, st := range t.Stops {
+				if st.Stop != nil {
+					points = append(points, &ShapePoint{Latitude: st.Stop.Latitude, Longitude: st.Stop.Longitude})
+				}
+			}
+		}
+
+		if len(points) < 2 {
+			continue
+		}
+
+		if seen[t.Route] == nil {
+			seen[t.Route] = map[string]bool{}
+		}
+
+		key := shapePointsKey(points)
+		if seen[t.Route][key] {
+			continue
+		}
+		seen[t.Route][key] = true
+
+		res[t.Route] = append(res[t.Route], points)
+	}
+
+	return res
+}
+
+// shapeCoordinates returns the GeoJSON coordinates of points.
+func shapeCoordinates(points []*ShapePoint) [][2]float64 {
+	res := make([][2]float64, 0, len(points))
+	for _, pt := range points {
+		res = append(res, [2]float64{pt.Longitude, pt.Latitude})
+	}
+
+	return res
+}
+
+// cssColor converts a color in the format used by GTFS files (e.g. "FFFFFF")
+// to a CSS hex color (e.g. "#FFFFFF"). Empty colors are left empty.
+func cssColor(color string) string {
+	if color == "" || strings.HasPrefix(color, "#") {
+		return color
+	}
+
+	return "#" + color
+}
@@ -0,0 +1,100 @@
+package gtfs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func newGeoJSONTestFeed() *GTFS {
+	station := &Stop{ID: "station", Name: "Station", Latitude: 40.0, Longitude: -75.0, LocationType: LocationTypeStation}
+	platform := &Stop{ID: "platform", Name: "Platform 1", Code: "P1", Latitude: 40.0, Longitude: -75.0, ParentStation: station}
+	other := &Stop{ID: "other", Name: "Other", Latitude: 40.5, Longitude: -75.5}
+
+	shape := &Shape{ID: "shape", Points: []*ShapePoint{{Latitude: 40.0, Longitude: -75.0}, {Latitude: 40.5, Longitude: -75.5}}}
+
+	r1 := &Route{ID: "r1", ShortName: "1", LongName: "One", Type: RouteTypeBus, Color: "FF0000", TextColor: "FFFFFF"}
+	r2 := &Route{ID: "r2", ShortName: "2", Type: RouteTypeRail}
+	r3 := &Route{ID: "r3", ShortName: "3", Type: RouteTypeBus}
+
+	t1 := newBlockTestTrip("t1", "", nil, []*Stop{platform, other}, []string{"08:00:00", "08:10:00"})
+	t1.Route = r1
+	t1.Shape = shape
+	t2 := newBlockTestTrip("t2", "", nil, []*Stop{platform, other}, []string{"09:00:00", "09:10:00"})
+	t2.Route = r1
+	t2.Shape = shape
+	t3 := newBlockTestTrip("t3", "", nil, []*Stop{other, platform}, []string{"10:00:00", "10:10:00"})
+	t3.Route = r1
+	t4 := newBlockTestTrip("t4", "", nil, []*Stop{platform, other}, []string{"11:00:00", "11:10:00"})
+	t4.Route = r2
+
+	return &GTFS{
+		Stops:  []*Stop{station, platform, other},
+		Shapes: []*Shape{shape},
+		Routes: []*Route{r1, r2, r3},
+		Trips:  []*Trip{t1, t2, t3, t4},
+	}
+}
+
+func TestGTFS_WriteStopsGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newGeoJSONTestFeed().WriteStopsGeoJSON(&buf); err != nil {
+		t.Fatalf("GTFS.WriteStopsGeoJSON() error = %v", err)
+	}
+
+	want := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","id":"station","properties":{"code":"","location_type":1,"name":"Station","parent_station":null},"geometry":{"type":"Point","coordinates":[-75,40]}},` +
+		`{"type":"Feature","id":"platform","properties":{"code":"P1","location_type":0,"name":"Platform 1","parent_station":"station"},"geometry":{"type":"Point","coordinates":[-75,40]}},` +
+		`{"type":"Feature","id":"other","properties":{"code":"","location_type":0,"name":"Other","parent_station":null},"geometry":{"type":"Point","coordinates":[-75.5,40.5]}}` +
+		"]}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("GTFS.WriteStopsGeoJSON() = %v, want %v", got, want)
+	}
+}
+
+func TestGTFS_WriteShapesGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newGeoJSONTestFeed().WriteShapesGeoJSON(&buf); err != nil {
+		t.Fatalf("GTFS.WriteShapesGeoJSON() error = %v", err)
+	}
+
+	want := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","id":"shape","properties":{},"geometry":{"type":"LineString","coordinates":[[-75,40],[-75.5,40.5]]}}` +
+		"]}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("GTFS.WriteShapesGeoJSON() = %v, want %v", got, want)
+	}
+
+	var empty bytes.Buffer
+	short := &GTFS{Shapes: []*Shape{{ID: "point", Points: []*ShapePoint{{Latitude: 40.0, Longitude: -75.0}}}, {ID: "empty"}}}
+	if err := short.WriteShapesGeoJSON(&empty); err != nil || empty.String() != "{\"type\":\"FeatureCollection\",\"features\":[]}\n" {
+		t.Errorf("GTFS.WriteShapesGeoJSON() with no valid shapes = %v, %v", empty.String(), err)
+	}
+}
+
+func TestGTFS_WriteRoutesGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := newGeoJSONTestFeed().WriteRoutesGeoJSON(&buf); err != nil {
+		t.Fatalf("GTFS.WriteRoutesGeoJSON() error = %v", err)
+	}
+
+	want := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","id":"r1","properties":{"color":"#FF0000","long_name":"One","route_type":3,"short_name":"1","text_color":"#FFFFFF"},"geometry":{"type":"MultiLineString","coordinates":[[[-75,40],[-75.5,40.5]],[[-75.5,40.5],[-75,40]]]}},` +
+		`{"type":"Feature","id":"r2","properties":{"color":"","long_name":"","route_type":2,"short_name":"2","text_color":""},"geometry":{"type":"MultiLineString","coordinates":[[[-75,40],[-75.5,40.5]]]}}` +
+		"]}\n"
+	if got := buf.String(); got != want {
+		t.Errorf("GTFS.WriteRoutesGeoJSON() = %v, want %v", got, want)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestGTFS_WriteStopsGeoJSON_error(t *testing.T) {
+	if err := newGeoJSONTestFeed().WriteStopsGeoJSON(failingWriter{}); err == nil {
+		t.Errorf("GTFS.WriteStopsGeoJSON() error = nil, want error")
+	}
+}
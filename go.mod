@@ -1 +1,3 @@
 module github.com/dpearson/gtfs
+
+go 1.20
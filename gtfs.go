@@ -20,6 +20,13 @@ var validFilenames = map[string]bool{
 	"transfers.txt":       false,
 	"feed_info.txt":       false,
 	"translations.txt":    false,
+	"attributions.txt":    false,
+
+	// GTFS-Flex:
+	"locations.geojson":        false,
+	"location_groups.txt":      false,
+	"location_group_stops.txt": false,
+	"booking_rules.txt":        false,
 }
 
 // GTFS represents a single GTFS feed.
@@ -34,6 +41,12 @@ type GTFS struct {
 	Transfers    []*Transfer
 	FeedInfo     FeedInfo
 	Translations []*Translation
+	Attributions []*Attribution
+
+	// GTFS-Flex:
+	Locations      []*Location
+	LocationGroups []*LocationGroup
+	BookingRules   []*BookingRule
 
 	agenciesByID     map[string]*Agency
 	stopsByID        map[string]*Stop
@@ -43,7 +56,15 @@ type GTFS struct {
 	tripsByID        map[string]*Trip
 	faresByID        map[string]*Fare
 	translationsByID map[string]map[string]*Translation
-	strictMode       bool
+
+	translationsByRecord map[translationRecordKey]*Translation
+	translationsByValue  map[translationValueKey]*Translation
+
+	locationsByID      map[string]*Location
+	locationGroupsByID map[string]*LocationGroup
+	bookingRulesByID   map[string]*BookingRule
+
+	strictMode bool
 }
 
 // ParsingOptions specifies options used when parsing GTFS files.
@@ -155,6 +176,38 @@ func (g *GTFS) doLoad(files map[string]rcOpener) error {
 		}
 	}
 
+	f, ok = files["locations.geojson"]
+	if ok {
+		err = callWithOpenedReader(g.processLocations, f)
+		if err != nil {
+			return fmt.Errorf("error parsing locations.geojson: %v", err)
+		}
+	}
+
+	f, ok = files["location_groups.txt"]
+	if ok {
+		err = callWithOpenedReader(g.processLocationGroups, f)
+		if err != nil {
+			return fmt.Errorf("error parsing location_groups.txt: %v", err)
+		}
+
+		f, ok = files["location_group_stops.txt"]
+		if ok {
+			err = callWithOpenedReader(g.processLocationGroupStops, f)
+			if err != nil {
+				return fmt.Errorf("error parsing location_group_stops.txt: %v", err)
+			}
+		}
+	}
+
+	f, ok = files["booking_rules.txt"]
+	if ok {
+		err = callWithOpenedReader(g.processBookingRules, f)
+		if err != nil {
+			return fmt.Errorf("error parsing booking_rules.txt: %v", err)
+		}
+	}
+
 	err = callWithOpenedReader(g.processTrips, files["trips.txt"])
 	if err != nil {
 		return fmt.Errorf("error parsing trips.txt: %v", err)
@@ -213,5 +266,13 @@ func (g *GTFS) doLoad(files map[string]rcOpener) error {
 		}
 	}
 
+	f, ok = files["attributions.txt"]
+	if ok {
+		err = callWithOpenedReader(g.processAttributions, f)
+		if err != nil {
+			return fmt.Errorf("error parsing attributions.txt: %v", err)
+		}
+	}
+
 	return nil
 }
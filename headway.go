@@ -0,0 +1,228 @@
+package gtfs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A Pattern is a set of trips along a single route that serve exactly the same
+// sequence of stops.
+type Pattern struct {
+	Route *Route
+	Stops []*Stop
+	Trips []*Trip
+}
+
+// Patterns groups all trips in g into patterns. Patterns are ordered by the
+// first appearance of any of their trips in g.Trips.
+func (g *GTFS) Patterns() []*Pattern {
+	return tripPatterns(g.Trips)
+}
+
+// tripPatterns groups trips into patterns, ordered by the first appearance of any
+// of their trips in trips.
+func tripPatterns(trips []*Trip) []*Pattern {
+	var res []*Pattern
+	patternsByKey := map[string]*Pattern{}
+
+	for _, t := range trips {
+		var b strings.Builder
+		if t.Route != nil {
+			b.WriteString(t.Route.ID)
+		}
+
+		stops := make([]*Stop, 0, len(t.Stops))
+		for _, st := range t.Stops {
+			stops = append(stops, st.Stop)
+
+			b.WriteByte('|')
+			if st.Stop != nil {
+				b.WriteString(st.Stop.ID)
+			}
+		}
+
+		key := b.String()
+		p, ok := patternsByKey[key]
+		if !ok {
+			p = &Pattern{
+				Route: t.Route,
+				Stops: stops,
+			}
+			patternsByKey[key] = p
+			res = append(res, p)
+		}
+
+		p.Trips = append(p.Trips, t)
+	}
+
+	return res
+}
+
+// Departures returns the times of all departures from the first stop of p by
+// trips active on date, in increasing order. Each run of a frequency-based trip
+// is a separate departure.
+func (p *Pattern) Departures(date time.Time) ([]time.Duration, error) {
+	var res []time.Duration
+	for _, t := range p.Trips {
+		if t.Service == nil || !t.Service.IsActiveOn(date) || len(t.Stops) == 0 {
+			continue
+		}
+
+		deps, err := tripDepartures(t, t.Stops[0])
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, deps...)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+
+	return res, nil
+}
+
+// StopDepartures returns the times of all departures from s by trips active on
+// date, in increasing order.
+//
+// Only stop times at which passengers may board are included, and the final
+// stop of each trip is excluded. If s is a station, departures from all stops
+// within it are included. Each run of a frequency-based trip is a separate
+// departure.
+func (g *GTFS) StopDepartures(s *Stop, date time.Time) ([]time.Duration, error) {
+	var res []time.Duration
+	for _, t := range g.Trips {
+		if t.Service == nil || !t.Service.IsActiveOn(date) {
+			continue
+		}
+
+		for i, st := range t.Stops {
+			if i == len(t.Stops)-1 || st.PickupType == PickupTypeNone || st.DepartureTime == "" {
+				continue
+			}
+
+			if st.Stop != s && (st.Stop == nil || st.Stop.ParentStation != s || s.LocationType != LocationTypeStation) {
+				continue
+			}
+
+			deps, err := tripDepartures(t, st)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, deps...)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+
+	return res, nil
+}
+
+// tripDepartures returns the departure times from st, which is one of t's
+// stops, for every run of t.
+func tripDepartures(t *Trip, st *StopTime) ([]time.Duration, error) {
+	dep, err := st.DepartureOffset()
+	if err != nil {
+		return nil, fmt.Errorf("invalid departure time for trip %s: %v", t.ID, err)
+	}
+
+	offsets, err := t.InstanceOffsets()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]time.Duration, 0, len(offsets))
+	for _, offset := range offsets {
+		res = append(res, dep+offset)
+	}
+
+	return res, nil
+}
+
+// A TimeWindow is a period of a service day, from Start (inclusive) to End
+// (exclusive), with both times expressed as the amount of time elapsed since
+// the start of the service day.
+type TimeWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Duration returns the length of w.
+func (w TimeWindow) Duration() time.Duration {
+	return w.End - w.Start
+}
+
+// HeadwayStatistics summarizes the headways between departures within a time
+// window.
+type HeadwayStatistics struct {
+	Window     TimeWindow
+	Departures int
+
+	// AverageHeadway is the length of the window divided by the number of
+	// departures within it.
+	AverageHeadway time.Duration
+
+	// MaxHeadway is the longest wait for a departure at any time within the
+	// window, including the waits from the start of the window until the
+	// first departure and from the last departure until the end of the
+	// window.
+	MaxHeadway time.Duration
+}
+
+// Headways computes headway statistics for departures, which must be in
+// increasing order, within each of windows.
+//
+// If there are no departures within a window, both the average and maximum
+// headways are equal to the length of the window.
+func Headways(departures []time.Duration, windows ...TimeWindow) []*HeadwayStatistics {
+	res := make([]*HeadwayStatistics, 0, len(windows))
+	for _, w := range windows {
+		h := &HeadwayStatistics{
+			Window:         w,
+			AverageHeadway: w.Duration(),
+		}
+
+		prev := w.Start
+		for _, d := range departures {
+			if d < w.Start || d >= w.End {
+				continue
+			}
+
+			h.Departures++
+			if d-prev > h.MaxHeadway {
+				h.MaxHeadway = d - prev
+			}
+			prev = d
+		}
+
+		if w.End-prev > h.MaxHeadway {
+			h.MaxHeadway = w.End - prev
+		}
+
+		if h.Departures > 0 {
+			h.AverageHeadway = w.Duration() / time.Duration(h.Departures)
+		}
+
+		res = append(res, h)
+	}
+
+	return res
+}
+
+// IsFrequent reports whether departures, which must be in increasing order,
+// provide frequent service throughout window, such that nobody arriving at any
+// time within window waits longer than maxHeadway for a departure.
+//
+// For example, service every 10 minutes or better from 7am to 7pm can be
+// checked with:
+//
+//	IsFrequent(departures, TimeWindow{7 * time.Hour, 19 * time.Hour}, 10*time.Minute)
+func IsFrequent(departures []time.Duration, window TimeWindow, maxHeadway time.Duration) bool {
+	return Headways(departures, window)[0].MaxHeadway <= maxHeadway
+}
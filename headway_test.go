@@ -0,0 +1,189 @@
+package gtfs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGTFS_Patterns(t *testing.T) {
+	r1 := &Route{ID: "r1"}
+	r2 := &Route{ID: "r2"}
+	a := &Stop{ID: "a"}
+	b := &Stop{ID: "b"}
+
+	t1 := newBlockTestTrip("t1", "", nil, []*Stop{a, b}, []string{"08:00:00", "08:10:00"})
+	t1.Route = r1
+	t2 := newBlockTestTrip("t2", "", nil, []*Stop{b, a}, []string{"08:00:00", "08:10:00"})
+	t2.Route = r1
+	t3 := newBlockTestTrip("t3", "", nil, []*Stop{a, b}, []string{"09:00:00", "09:10:00"})
+	t3.Route = r1
+	t4 := newBlockTestTrip("t4", "", nil, []*Stop{a, b}, []string{"08:00:00", "08:10:00"})
+	t4.Route = r2
+
+	g := &GTFS{
+		Trips: []*Trip{t1, t2, t3, t4},
+	}
+
+	want := []*Pattern{
+		{Route: r1, Stops: []*Stop{a, b}, Trips: []*Trip{t1, t3}},
+		{Route: r1, Stops: []*Stop{b, a}, Trips: []*Trip{t2}},
+		{Route: r2, Stops: []*Stop{a, b}, Trips: []*Trip{t4}},
+	}
+	if got := g.Patterns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.Patterns() = %v, want %v", got, want)
+	}
+}
+
+func TestDepartures(t *testing.T) {
+	service := &Service{
+		ID:        "daily",
+		Monday:    true,
+		Tuesday:   true,
+		Wednesday: true,
+		Thursday:  true,
+		Friday:    true,
+		Saturday:  true,
+		Sunday:    true,
+		StartDate: "20190101",
+		EndDate:   "20191231",
+	}
+	other := &Service{ID: "none"}
+
+	station := &Stop{ID: "station", LocationType: LocationTypeStation}
+	platform := &Stop{ID: "platform", ParentStation: station}
+	a := &Stop{ID: "a"}
+	route := &Route{ID: "route"}
+
+	t1 := newBlockTestTrip("t1", "", service, []*Stop{a, platform}, []string{"07:00:00", "07:10:00"})
+	t1.Route = route
+	t2 := newBlockTestTrip("t2", "", service, []*Stop{platform, a}, []string{"07:30:00", "07:40:00"})
+	t2.Route = route
+	t3 := newBlockTestTrip("t3", "", other, []*Stop{platform, a}, []string{"07:45:00", "07:55:00"})
+	t3.Route = route
+	freq := newBlockTestTrip("freq", "", service, []*Stop{platform, a}, []string{"00:00:00", "00:10:00"})
+	freq.Route = route
+	freq.AbsoluteTimes = false
+	freq.Frequencies = []Frequency{{StartTime: "08:00:00", EndTime: "09:00:00", HeadwaySeconds: 1200}}
+
+	g := &GTFS{
+		Trips: []*Trip{t1, t2, t3, freq},
+	}
+	date := time.Date(2019, time.July, 3, 0, 0, 0, 0, time.UTC)
+
+	got, err := g.StopDepartures(station, date)
+	if err != nil {
+		t.Fatalf("GTFS.StopDepartures() error = %v", err)
+	}
+
+	want := []time.Duration{
+		7*time.Hour + 30*time.Minute,
+		8 * time.Hour,
+		8*time.Hour + 20*time.Minute,
+		8*time.Hour + 40*time.Minute,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.StopDepartures() = %v, want %v", got, want)
+	}
+
+	patterns := g.Patterns()
+	if len(patterns) != 2 {
+		t.Fatalf("GTFS.Patterns() returned %d patterns, want 2", len(patterns))
+	}
+
+	got, err = patterns[1].Departures(date)
+	if err != nil {
+		t.Fatalf("Pattern.Departures() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Pattern.Departures() = %v, want %v", got, want)
+	}
+}
+
+func TestHeadways(t *testing.T) {
+	departures := []time.Duration{
+		7 * time.Hour,
+		7*time.Hour + 10*time.Minute,
+		7*time.Hour + 20*time.Minute,
+		7*time.Hour + 45*time.Minute,
+		8*time.Hour + 50*time.Minute,
+	}
+
+	tests := []struct {
+		name   string
+		window TimeWindow
+		want   *HeadwayStatistics
+	}{
+		{
+			name:   "Regular",
+			window: TimeWindow{7 * time.Hour, 7*time.Hour + 30*time.Minute},
+			want: &HeadwayStatistics{
+				Window:         TimeWindow{7 * time.Hour, 7*time.Hour + 30*time.Minute},
+				Departures:     3,
+				AverageHeadway: 10 * time.Minute,
+				MaxHeadway:     10 * time.Minute,
+			},
+		},
+		{
+			name:   "Irregular",
+			window: TimeWindow{7 * time.Hour, 8 * time.Hour},
+			want: &HeadwayStatistics{
+				Window:         TimeWindow{7 * time.Hour, 8 * time.Hour},
+				Departures:     4,
+				AverageHeadway: 15 * time.Minute,
+				MaxHeadway:     25 * time.Minute,
+			},
+		},
+		{
+			name:   "Late Start",
+			window: TimeWindow{8 * time.Hour, 9 * time.Hour},
+			want: &HeadwayStatistics{
+				Window:         TimeWindow{8 * time.Hour, 9 * time.Hour},
+				Departures:     1,
+				AverageHeadway: time.Hour,
+				MaxHeadway:     50 * time.Minute,
+			},
+		},
+		{
+			name:   "No Service",
+			window: TimeWindow{10 * time.Hour, 11 * time.Hour},
+			want: &HeadwayStatistics{
+				Window:         TimeWindow{10 * time.Hour, 11 * time.Hour},
+				AverageHeadway: time.Hour,
+				MaxHeadway:     time.Hour,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Headways(departures, tt.window); !reflect.DeepEqual(got, []*HeadwayStatistics{tt.want}) {
+				t.Errorf("Headways() = %+v, want %+v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestIsFrequent(t *testing.T) {
+	var departures []time.Duration
+	for d := 7 * time.Hour; d < 19*time.Hour; d += 10 * time.Minute {
+		departures = append(departures, d)
+	}
+
+	window := TimeWindow{7 * time.Hour, 19 * time.Hour}
+	if !IsFrequent(departures, window, 10*time.Minute) {
+		t.Errorf("IsFrequent() = false, want true")
+	}
+
+	if IsFrequent(departures, window, 5*time.Minute) {
+		t.Errorf("IsFrequent() with shorter headway = true, want false")
+	}
+
+	if IsFrequent(departures[2:], window, 10*time.Minute) {
+		t.Errorf("IsFrequent() with late first departure = true, want false")
+	}
+
+	if IsFrequent(departures, TimeWindow{7 * time.Hour, 20 * time.Hour}, 10*time.Minute) {
+		t.Errorf("IsFrequent() with longer window = true, want false")
+	}
+}
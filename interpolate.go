@@ -0,0 +1,154 @@
+package gtfs
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// InterpolateStopTimes fills in missing arrival and departure times for every
+// trip in g. Trips that can't be interpolated are left unchanged, and an error
+// describing each of them is returned.
+//
+// See Trip.InterpolateStopTimes for details.
+func (g *GTFS) InterpolateStopTimes() error {
+	var errs []error
+	for _, t := range g.Trips {
+		if err := t.InterpolateStopTimes(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// InterpolateStopTimes fills in missing arrival and departure times for t.
+//
+// Stop times with only an arrival or a departure time have the missing time
+// set to the one that is present. Stop times with neither are given times
+// interpolated linearly between the departure from the previous stop with a
+// time and the arrival at the next. Every stop time given a time is marked as
+// approximate.
+// Interpolation uses distances along the trip's shape when they are available
+// for all of the stops involved, and straight-line distances between stops
+// otherwise.
+//
+// Trips serving GTFS-Flex locations or with pickup and drop-off windows are
+// skipped. If interpolation isn't possible (e.g. because the first or last stop
+// has no time), t is left unchanged and an error is returned.
+func (t *Trip) InterpolateStopTimes() error {
+	for _, st := range t.Stops {
+		if st.Stop == nil || st.StartPickupDropOffWindow != "" || st.EndPickupDropOffWindow != "" {
+			return nil
+		}
+	}
+
+	arrivals := make([]time.Duration, len(t.Stops))
+	departures := make([]time.Duration, len(t.Stops))
+	known := make([]bool, len(t.Stops))
+	for i, st := range t.Stops {
+		arr, dep := st.ArrivalTime, st.DepartureTime
+		if arr == "" {
+			arr = dep
+		}
+
+		if dep == "" {
+			dep = arr
+		}
+
+		if arr == "" {
+			continue
+		}
+
+		var err error
+		arrivals[i], err = ParseTime(arr)
+		if err != nil {
+			return fmt.Errorf("trip %s: invalid arrival time at stop %d: %v", t.ID, i, err)
+		}
+
+		departures[i], err = ParseTime(dep)
+		if err != nil {
+			return fmt.Errorf("trip %s: invalid departure time at stop %d: %v", t.ID, i, err)
+		}
+
+		known[i] = true
+	}
+
+	if len(t.Stops) > 0 && (!known[0] || !known[len(known)-1]) {
+		return fmt.Errorf("trip %s: first and last stops must have times", t.ID)
+	}
+
+	prev := 0
+	for i := 1; i < len(t.Stops); i++ {
+		if !known[i] {
+			continue
+		}
+
+		if i-prev > 1 {
+			if arrivals[i] < departures[prev] {
+				return fmt.Errorf("trip %s: arrival at stop %d is before departure from stop %d", t.ID, i, prev)
+			}
+
+			t.interpolateBetween(prev, i, arrivals, departures)
+		}
+
+		prev = i
+	}
+
+	for i, st := range t.Stops {
+		if st.ArrivalTime == "" || st.DepartureTime == "" {
+			st.Timepoint = TimepointTypeApproximate
+		}
+
+		if st.ArrivalTime == "" {
+			st.ArrivalTime = FormatTime(arrivals[i])
+		}
+
+		if st.DepartureTime == "" {
+			st.DepartureTime = FormatTime(departures[i])
+		}
+	}
+
+	return nil
+}
+
+// interpolateBetween sets the arrival and departure times of the stops between
+// first and last, both of which have times, in proportion to the distances
+// between them.
+func (t *Trip) interpolateBetween(first, last int, arrivals, departures []time.Duration) {
+	// Cumulative distances from the first stop
+	dists := make([]float64, last-first+1)
+
+	useShape := true
+	for i := first + 1; i <= last; i++ {
+		if t.Stops[i].ShapeDistanceTraveled <= t.Stops[i-1].ShapeDistanceTraveled {
+			useShape = false
+			break
+		}
+	}
+
+	for i := first + 1; i <= last; i++ {
+		prev, st := t.Stops[i-1], t.Stops[i]
+
+		d := st.ShapeDistanceTraveled - prev.ShapeDistanceTraveled
+		if !useShape {
+			d = prev.Stop.DistanceTo(st.Stop)
+		}
+
+		dists[i-first] = dists[i-first-1] + d
+	}
+
+	total := dists[len(dists)-1]
+	start, end := departures[first], arrivals[last]
+	for i := first + 1; i < last; i++ {
+		// Space stops evenly if they're all in the same place
+		fraction := float64(i-first) / float64(last-first)
+		if total > 0 {
+			fraction = dists[i-first] / total
+		}
+
+		d := start + time.Duration(fraction*float64(end-start)).Round(time.Second)
+		arrivals[i] = d
+		departures[i] = d
+	}
+}
@@ -0,0 +1,163 @@
+package gtfs
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newInterpolationTestTrip(id string, times []string, dists []float64) *Trip {
+	stops := []*Stop{
+		{ID: "a", Latitude: 40.0, Longitude: -75.0},
+		{ID: "b", Latitude: 40.0, Longitude: -74.99},
+		{ID: "c", Latitude: 40.0, Longitude: -74.96},
+		{ID: "d", Latitude: 40.0, Longitude: -74.95},
+	}
+
+	t := newBlockTestTrip(id, "", nil, stops[:len(times)], times)
+	for i, st := range t.Stops {
+		st.ArrivalTime = times[i]
+		st.DepartureTime = times[i]
+		if dists != nil {
+			st.ShapeDistanceTraveled = dists[i]
+		}
+	}
+
+	return t
+}
+
+func TestTrip_InterpolateStopTimes(t *testing.T) {
+	tests := []struct {
+		name       string
+		times      []string
+		dists      []float64
+		want       []string
+		wantApprox []bool
+		wantErr    bool
+	}{
+		{
+			name:       "Complete",
+			times:      []string{"08:00:00", "08:05:00", "08:10:00"},
+			want:       []string{"08:00:00", "08:05:00", "08:10:00"},
+			wantApprox: []bool{false, false, false},
+		},
+		{
+			name:       "Geometric Distance",
+			times:      []string{"08:00:00", "", "", "08:10:00"},
+			want:       []string{"08:00:00", "08:02:00", "08:08:00", "08:10:00"},
+			wantApprox: []bool{false, true, true, false},
+		},
+		{
+			name:       "Shape Distance",
+			times:      []string{"08:00:00", "", "", "08:10:00"},
+			dists:      []float64{0, 1, 2, 4},
+			want:       []string{"08:00:00", "08:02:30", "08:05:00", "08:10:00"},
+			wantApprox: []bool{false, true, true, false},
+		},
+		{
+			name:       "Incomplete Shape Distance",
+			times:      []string{"08:00:00", "", "", "08:10:00"},
+			dists:      []float64{0, 1, 1, 4},
+			want:       []string{"08:00:00", "08:02:00", "08:08:00", "08:10:00"},
+			wantApprox: []bool{false, true, true, false},
+		},
+		{
+			name:    "Missing First Time",
+			times:   []string{"", "08:05:00", "08:10:00"},
+			wantErr: true,
+		},
+		{
+			name:    "Missing Last Time",
+			times:   []string{"08:00:00", "08:05:00", ""},
+			wantErr: true,
+		},
+		{
+			name:    "Backwards",
+			times:   []string{"08:10:00", "", "08:00:00"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Time",
+			times:   []string{"08:00:00", "", "8:0"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trip := newInterpolationTestTrip("trip", tt.times, tt.dists)
+
+			err := trip.InterpolateStopTimes()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Trip.InterpolateStopTimes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			want := tt.want
+			if tt.wantErr {
+				want = tt.times
+			}
+
+			var got []string
+			var gotApprox []bool
+			for _, st := range trip.Stops {
+				if st.ArrivalTime != st.DepartureTime {
+					t.Errorf("Trip.InterpolateStopTimes() arrival %s != departure %s", st.ArrivalTime, st.DepartureTime)
+				}
+
+				got = append(got, st.DepartureTime)
+				gotApprox = append(gotApprox, st.Timepoint == TimepointTypeApproximate)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Trip.InterpolateStopTimes() = %v, want %v", got, want)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(gotApprox, tt.wantApprox) {
+				t.Errorf("Trip.InterpolateStopTimes() approximate = %v, want %v", gotApprox, tt.wantApprox)
+			}
+		})
+	}
+}
+
+func TestTrip_InterpolateStopTimes_singleTime(t *testing.T) {
+	trip := newInterpolationTestTrip("trip", []string{"08:00:00", "08:05:00", "08:10:00"}, nil)
+	trip.Stops[1].ArrivalTime = ""
+	trip.Stops[2].DepartureTime = ""
+
+	if err := trip.InterpolateStopTimes(); err != nil {
+		t.Fatalf("Trip.InterpolateStopTimes() error = %v", err)
+	}
+
+	if st := trip.Stops[0]; st.Timepoint != TimepointTypeExact {
+		t.Errorf("Trip.InterpolateStopTimes() stop 0 = %+v, want exact times", st)
+	}
+
+	if st := trip.Stops[1]; st.ArrivalTime != "08:05:00" || st.Timepoint != TimepointTypeApproximate {
+		t.Errorf("Trip.InterpolateStopTimes() stop 1 = %+v, want approximate arrival at 08:05:00", st)
+	}
+
+	if st := trip.Stops[2]; st.DepartureTime != "08:10:00" || st.Timepoint != TimepointTypeApproximate {
+		t.Errorf("Trip.InterpolateStopTimes() stop 2 = %+v, want approximate departure at 08:10:00", st)
+	}
+}
+
+func TestGTFS_InterpolateStopTimes(t *testing.T) {
+	good := newInterpolationTestTrip("good", []string{"08:00:00", "", "08:10:00"}, nil)
+	bad := newInterpolationTestTrip("bad", []string{"08:00:00", "", ""}, nil)
+
+	g := &GTFS{
+		Trips: []*Trip{good, bad},
+	}
+
+	err := g.InterpolateStopTimes()
+	if err == nil || !strings.Contains(err.Error(), "trip bad") || strings.Contains(err.Error(), "trip good") {
+		t.Errorf("GTFS.InterpolateStopTimes() error = %v, want error for trip bad", err)
+	}
+
+	if good.Stops[1].DepartureTime == "" {
+		t.Errorf("GTFS.InterpolateStopTimes() didn't interpolate trip good")
+	}
+
+	if bad.Stops[1].DepartureTime != "" {
+		t.Errorf("GTFS.InterpolateStopTimes() modified trip bad")
+	}
+}
@@ -0,0 +1,257 @@
+package gtfs
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type kmlStyle struct {
+	XMLName   xml.Name     `xml:"Style"`
+	ID        string       `xml:"id,attr"`
+	LineStyle kmlLineStyle `xml:"LineStyle"`
+}
+
+type kmlLineStyle struct {
+	Color string `xml:"color"`
+	Width int    `xml:"width"`
+}
+
+type kmlPlacemark struct {
+	XMLName       xml.Name          `xml:"Placemark"`
+	Name          string            `xml:"name"`
+	Description   string            `xml:"description,omitempty"`
+	StyleURL      string            `xml:"styleUrl,omitempty"`
+	ExtendedData  *kmlExtendedData  `xml:"ExtendedData,omitempty"`
+	Point         *kmlPoint         `xml:"Point,omitempty"`
+	MultiGeometry *kmlMultiGeometry `xml:"MultiGeometry,omitempty"`
+}
+
+type kmlExtendedData struct {
+	Data []kmlData `xml:"Data"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlMultiGeometry struct {
+	LineStrings []kmlLineString `xml:"LineString"`
+}
+
+// WriteKML writes the routes and stops in g to w as a KML document, for viewing
+// in applications such as Google Earth.
+//
+// Routes are grouped into a folder for each agency, with routes without an
+// agency in a separate folder. Each route has its own folder containing a
+// placemark with the distinct lines followed by its trips, styled with the
+// route's color, as in WriteRoutesGeoJSON. Routes without any trips are
+// omitted. All stops are placed in a separate folder, with their codes and
+// descriptions shown when they are selected.
+func (g *GTFS) WriteKML(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	name := g.FeedInfo.PublisherName
+	if name == "" {
+		name = "GTFS"
+	}
+
+	kml := xml.StartElement{Name: xml.Name{Local: "kml"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "http://www.opengis.net/kml/2.2"}}}
+	doc := xml.StartElement{Name: xml.Name{Local: "Document"}}
+	if err := encodeTokens(enc, kml, doc); err != nil {
+		return err
+	}
+
+	if err := enc.EncodeElement(name, xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+		return err
+	}
+
+	linesByRoute := g.routeLines()
+	var routes []*Route
+	for _, r := range g.Routes {
+		if _, ok := linesByRoute[r]; !ok {
+			continue
+		}
+
+		routes = append(routes, r)
+		if err := enc.Encode(kmlStyle{
+			ID: kmlRouteStyleID(r),
+			LineStyle: kmlLineStyle{
+				Color: kmlColor(r.Color),
+				Width: 3,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	agencies := map[*Agency]bool{}
+	for _, a := range g.Agencies {
+		agencies[a] = true
+		if err := writeKMLRoutes(enc, a.Name, routes, linesByRoute, func(r *Route) bool { return r.Agency == a }); err != nil {
+			return err
+		}
+	}
+
+	if err := writeKMLRoutes(enc, "Other Routes", routes, linesByRoute, func(r *Route) bool { return !agencies[r.Agency] }); err != nil {
+		return err
+	}
+
+	if len(g.Stops) > 0 {
+		if err := startKMLFolder(enc, "Stops"); err != nil {
+			return err
+		}
+
+		for _, s := range g.Stops {
+			data := []kmlData{{Name: "stop_id", Value: s.ID}}
+			if s.Code != "" {
+				data = append(data, kmlData{Name: "stop_code", Value: s.Code})
+			}
+
+			if s.ParentStation != nil {
+				data = append(data, kmlData{Name: "parent_station", Value: s.ParentStation.ID})
+			}
+
+			if err := enc.Encode(kmlPlacemark{
+				Name:         s.Name,
+				Description:  s.Description,
+				ExtendedData: &kmlExtendedData{Data: data},
+				Point: &kmlPoint{
+					Coordinates: kmlCoordinates([]*ShapePoint{{Latitude: s.Latitude, Longitude: s.Longitude}}),
+				},
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := encodeTokens(enc, xml.EndElement{Name: xml.Name{Local: "Folder"}}); err != nil {
+			return err
+		}
+	}
+
+	if err := encodeTokens(enc, doc.End(), kml.End()); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
+
+// writeKMLRoutes writes a folder with the specified name containing a folder
+// for each of routes that matches include. Nothing is written if no routes
+// match.
+func writeKMLRoutes(enc *xml.Encoder, name string, routes []*Route, linesByRoute map[*Route][][]*ShapePoint, include func(*Route) bool) error {
+	started := false
+	for _, r := range routes {
+		if !include(r) {
+			continue
+		}
+
+		if !started {
+			if err := startKMLFolder(enc, name); err != nil {
+				return err
+			}
+			started = true
+		}
+
+		if err := startKMLFolder(enc, routeName(r)); err != nil {
+			return err
+		}
+
+		var lines []kmlLineString
+		for _, l := range linesByRoute[r] {
+			lines = append(lines, kmlLineString{Tessellate: 1, Coordinates: kmlCoordinates(l)})
+		}
+
+		if err := enc.Encode(kmlPlacemark{
+			Name:          routeName(r),
+			Description:   r.Description,
+			StyleURL:      "#" + kmlRouteStyleID(r),
+			MultiGeometry: &kmlMultiGeometry{LineStrings: lines},
+		}); err != nil {
+			return err
+		}
+
+		if err := encodeTokens(enc, xml.EndElement{Name: xml.Name{Local: "Folder"}}); err != nil {
+			return err
+		}
+	}
+
+	if !started {
+		return nil
+	}
+
+	return encodeTokens(enc, xml.EndElement{Name: xml.Name{Local: "Folder"}})
+}
+
+func startKMLFolder(enc *xml.Encoder, name string) error {
+	if err := encodeTokens(enc, xml.StartElement{Name: xml.Name{Local: "Folder"}}); err != nil {
+		return err
+	}
+
+	return enc.EncodeElement(name, xml.StartElement{Name: xml.Name{Local: "name"}})
+}
+
+func encodeTokens(enc *xml.Encoder, tokens ...xml.Token) error {
+	for _, t := range tokens {
+		if err := enc.EncodeToken(t); err != nil {
+			return err
+		}
+	}
+
+	return enc.Flush()
+}
+
+// routeName returns a name for r suitable for display, made up of its short
+// and long names, or its ID if it has neither.
+func routeName(r *Route) string {
+	name := strings.TrimSpace(r.ShortName + " " + r.LongName)
+	if name == "" {
+		return r.ID
+	}
+
+	return name
+}
+
+func kmlRouteStyleID(r *Route) string {
+	return "route-" + r.ID
+}
+
+// kmlCoordinates returns the KML coordinates of points.
+func kmlCoordinates(points []*ShapePoint) string {
+	parts := make([]string, 0, len(points))
+	for _, pt := range points {
+		parts = append(parts, fmt.Sprintf("%v,%v", pt.Longitude, pt.Latitude))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// kmlColor converts a color in the format used by GTFS files (e.g. "FF8000")
+// to an opaque KML color (e.g. "ff0080ff"). Empty or invalid colors are
+// treated as white, the default route color.
+func kmlColor(color string) string {
+	color = strings.TrimPrefix(color, "#")
+	if len(color) != 6 {
+		color = "FFFFFF"
+	}
+
+	return strings.ToLower("ff" + color[4:6] + color[2:4] + color[0:2])
+}
@@ -0,0 +1,152 @@
+package gtfs
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGTFS_WriteKML(t *testing.T) {
+	g := newGeoJSONTestFeed()
+	agency := &Agency{ID: "agency", Name: "Transit & Co"}
+	g.Agencies = []*Agency{agency}
+	g.Routes[0].Agency = agency
+	g.Stops[1].Description = "Northbound"
+
+	var buf bytes.Buffer
+	if err := g.WriteKML(&buf); err != nil {
+		t.Fatalf("GTFS.WriteKML() error = %v", err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <name>GTFS</name>
+    <Style id="route-r1">
+      <LineStyle>
+        <color>ff0000ff</color>
+        <width>3</width>
+      </LineStyle>
+    </Style>
+    <Style id="route-r2">
+      <LineStyle>
+        <color>ffffffff</color>
+        <width>3</width>
+      </LineStyle>
+    </Style>
+    <Folder>
+      <name>Transit &amp; Co</name>
+      <Folder>
+        <name>1 One</name>
+        <Placemark>
+          <name>1 One</name>
+          <styleUrl>#route-r1</styleUrl>
+          <MultiGeometry>
+            <LineString>
+              <tessellate>1</tessellate>
+              <coordinates>-75,40 -75.5,40.5</coordinates>
+            </LineString>
+            <LineString>
+              <tessellate>1</tessellate>
+              <coordinates>-75.5,40.5 -75,40</coordinates>
+            </LineString>
+          </MultiGeometry>
+        </Placemark>
+      </Folder>
+    </Folder>
+    <Folder>
+      <name>Other Routes</name>
+      <Folder>
+        <name>2</name>
+        <Placemark>
+          <name>2</name>
+          <styleUrl>#route-r2</styleUrl>
+          <MultiGeometry>
+            <LineString>
+              <tessellate>1</tessellate>
+              <coordinates>-75,40 -75.5,40.5</coordinates>
+            </LineString>
+          </MultiGeometry>
+        </Placemark>
+      </Folder>
+    </Folder>
+    <Folder>
+      <name>Stops</name>
+      <Placemark>
+        <name>Station</name>
+        <ExtendedData>
+          <Data name="stop_id">
+            <value>station</value>
+          </Data>
+        </ExtendedData>
+        <Point>
+          <coordinates>-75,40</coordinates>
+        </Point>
+      </Placemark>
+      <Placemark>
+        <name>Platform 1</name>
+        <description>Northbound</description>
+        <ExtendedData>
+          <Data name="stop_id">
+            <value>platform</value>
+          </Data>
+          <Data name="stop_code">
+            <value>P1</value>
+          </Data>
+          <Data name="parent_station">
+            <value>station</value>
+          </Data>
+        </ExtendedData>
+        <Point>
+          <coordinates>-75,40</coordinates>
+        </Point>
+      </Placemark>
+      <Placemark>
+        <name>Other</name>
+        <ExtendedData>
+          <Data name="stop_id">
+            <value>other</value>
+          </Data>
+        </ExtendedData>
+        <Point>
+          <coordinates>-75.5,40.5</coordinates>
+        </Point>
+      </Placemark>
+    </Folder>
+  </Document>
+</kml>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("GTFS.WriteKML() = %v, want %v", got, want)
+	}
+}
+
+func Test_kmlColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		color string
+		want  string
+	}{
+		{
+			name:  "Color",
+			color: "FF8000",
+			want:  "ff0080ff",
+		},
+		{
+			name:  "Empty",
+			color: "",
+			want:  "ffffffff",
+		},
+		{
+			name:  "Invalid",
+			color: "F80",
+			want:  "ffffffff",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := kmlColor(tt.color); got != tt.want {
+				t.Errorf("kmlColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package gtfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// A Location is a zone in which demand-responsive (GTFS-Flex) service picks up
+// or drops off riders.
+//
+// Fields correspond to features in locations.geojson.
+type Location struct {
+	ID          string
+	Name        string
+	Description string
+	Polygons    []Polygon
+}
+
+// A Polygon is a single polygon within a Location.
+//
+// The first ring is the exterior of the polygon, and any remaining rings are
+// holes within it. Each ring is a closed sequence of [longitude, latitude]
+// positions, following GeoJSON conventions.
+type Polygon [][][2]float64
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         json.RawMessage        `json:"id"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+}
+
+// stringProperty returns the value of the named property of f, or an empty
+// string if it is absent or isn't a string.
+func (f *geoJSONFeature) stringProperty(name string) string {
+	s, _ := f.Properties[name].(string)
+	return s
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func (g *GTFS) processLocations(r io.Reader) error {
+	var fc geoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&fc); err != nil {
+		return err
+	}
+
+	if fc.Type != "FeatureCollection" {
+		return fmt.Errorf("invalid GeoJSON type: %s", fc.Type)
+	}
+
+	g.locationsByID = map[string]*Location{}
+
+	for _, f := range fc.Features {
+		id, err := parseGeoJSONID(f.ID)
+		if err != nil {
+			return err
+		}
+
+		polygons, err := parseGeoJSONPolygons(f.Geometry)
+		if err != nil {
+			return fmt.Errorf("invalid geometry for location %s: %v", id, err)
+		}
+
+		l := &Location{
+			ID:          id,
+			Name:        f.stringProperty("stop_name"),
+			Description: f.stringProperty("stop_desc"),
+			Polygons:    polygons,
+		}
+
+		g.Locations = append(g.Locations, l)
+		g.locationsByID[l.ID] = l
+	}
+
+	return nil
+}
+
+func (g *GTFS) locationByID(id string) *Location {
+	return g.locationsByID[id]
+}
+
+// Contains reports whether the point at lat, lon lies within l.
+func (l *Location) Contains(lat, lon float64) bool {
+	for _, p := range l.Polygons {
+		if p.contains(lat, lon) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p Polygon) contains(lat, lon float64) bool {
+	if len(p) == 0 || !ringContains(p[0], lat, lon) {
+		return false
+	}
+
+	for _, hole := range p[1:] {
+		if ringContains(hole, lat, lon) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ringContains uses the even-odd rule to determine whether a point lies within
+// a ring.
+func ringContains(ring [][2]float64, lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+func parseGeoJSONID(raw json.RawMessage) (string, error) {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil && id != "" {
+		return id, nil
+	}
+
+	// The GeoJSON spec also allows numeric IDs
+	var num json.Number
+	if err := json.Unmarshal(raw, &num); err == nil {
+		return num.String(), nil
+	}
+
+	return "", fmt.Errorf("invalid feature ID: %s", string(raw))
+}
+
+func parseGeoJSONPolygons(geom geoJSONGeometry) ([]Polygon, error) {
+	switch geom.Type {
+	case "Polygon":
+		var p Polygon
+		if err := json.Unmarshal(geom.Coordinates, &p); err != nil {
+			return nil, err
+		}
+
+		return []Polygon{p}, nil
+	case "MultiPolygon":
+		var ps []Polygon
+		if err := json.Unmarshal(geom.Coordinates, &ps); err != nil {
+			return nil, err
+		}
+
+		return ps, nil
+	default:
+		return nil, fmt.Errorf("unsupported geometry type: %s", geom.Type)
+	}
+}
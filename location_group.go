@@ -0,0 +1,79 @@
+package gtfs
+
+import (
+	"fmt"
+	"io"
+)
+
+// A LocationGroup is a group of stops at which demand-responsive (GTFS-Flex)
+// service picks up or drops off riders.
+//
+// Fields correspond to columns in location_groups.txt and
+// location_group_stops.txt.
+type LocationGroup struct {
+	ID    string
+	Name  string
+	Stops []*Stop
+}
+
+var locationGroupFields = map[string]bool{
+	"location_group_id":   true,
+	"location_group_name": false,
+}
+
+var locationGroupStopFields = map[string]bool{
+	"location_group_id": true,
+	"stop_id":           true,
+}
+
+func (g *GTFS) processLocationGroups(r io.Reader) error {
+	res, err := readCSVWithHeadings(r, locationGroupFields, g.strictMode)
+	if err != nil {
+		return err
+	}
+
+	g.locationGroupsByID = map[string]*LocationGroup{}
+
+	for _, row := range res {
+		lg := &LocationGroup{
+			ID:   row["location_group_id"],
+			Name: row["location_group_name"],
+		}
+
+		g.LocationGroups = append(g.LocationGroups, lg)
+		g.locationGroupsByID[lg.ID] = lg
+	}
+
+	return nil
+}
+
+func (g *GTFS) processLocationGroupStops(r io.Reader) error {
+	res, err := readCSVWithHeadings(r, locationGroupStopFields, g.strictMode)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range res {
+		lg := g.locationGroupByID(row["location_group_id"])
+		if lg == nil {
+			return fmt.Errorf("invalid location group ID: %s", row["location_group_id"])
+		}
+
+		s := g.stopByID(row["stop_id"])
+		if s == nil {
+			if g.strictMode {
+				return fmt.Errorf("invalid stop ID: %s for location group %s", row["stop_id"], lg.ID)
+			}
+
+			continue
+		}
+
+		lg.Stops = append(lg.Stops, s)
+	}
+
+	return nil
+}
+
+func (g *GTFS) locationGroupByID(id string) *LocationGroup {
+	return g.locationGroupsByID[id]
+}
@@ -0,0 +1,184 @@
+package gtfs
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testLocationGroupsCSVValid = `location_group_id,location_group_name
+lg-1,Hospitals
+lg-2,`
+
+const testLocationGroupStopsCSVValid = `location_group_id,stop_id
+lg-1,1
+lg-1,2
+lg-2,2`
+
+const testLocationGroupStopsCSVInvalidStop = `location_group_id,stop_id
+lg-1,3`
+
+const testLocationGroupStopsCSVInvalidGroup = `location_group_id,stop_id
+lg-3,1`
+
+func TestGTFS_processLocationGroups(t *testing.T) {
+	testLocationGroup1 := &LocationGroup{
+		ID:   "lg-1",
+		Name: "Hospitals",
+	}
+	testLocationGroup2 := &LocationGroup{
+		ID: "lg-2",
+	}
+	type args struct {
+		r io.Reader
+	}
+	tests := []struct {
+		name                   string
+		args                   args
+		wantErr                bool
+		wantLocationGroups     []*LocationGroup
+		wantLocationGroupsByID map[string]*LocationGroup
+	}{
+		{
+			name: "Valid",
+			args: args{
+				r: strings.NewReader(testLocationGroupsCSVValid),
+			},
+			wantErr: false,
+			wantLocationGroups: []*LocationGroup{
+				testLocationGroup1,
+				testLocationGroup2,
+			},
+			wantLocationGroupsByID: map[string]*LocationGroup{
+				"lg-1": testLocationGroup1,
+				"lg-2": testLocationGroup2,
+			},
+		},
+		{
+			name: "Empty",
+			args: args{
+				r: strings.NewReader(""),
+			},
+			wantErr:                true,
+			wantLocationGroups:     nil,
+			wantLocationGroupsByID: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{}
+			if err := g.processLocationGroups(tt.args.r); (err != nil) != tt.wantErr {
+				t.Errorf("GTFS.processLocationGroups() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(g.LocationGroups, tt.wantLocationGroups) {
+				t.Errorf("GTFS.processLocationGroups() LocationGroups = %v, wantLocationGroups %v", g.LocationGroups, tt.wantLocationGroups)
+			}
+			if !reflect.DeepEqual(g.locationGroupsByID, tt.wantLocationGroupsByID) {
+				t.Errorf("GTFS.processLocationGroups() locationGroupsByID = %v, wantLocationGroupsByID %v", g.locationGroupsByID, tt.wantLocationGroupsByID)
+			}
+		})
+	}
+}
+
+func TestGTFS_processLocationGroupStops(t *testing.T) {
+	testStop1 := &Stop{
+		ID: "1",
+	}
+	testStop2 := &Stop{
+		ID: "2",
+	}
+	type fields struct {
+		strictMode bool
+	}
+	type args struct {
+		r io.Reader
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantErr   bool
+		wantStops map[string][]*Stop
+	}{
+		{
+			name: "Valid",
+			fields: fields{
+				strictMode: false,
+			},
+			args: args{
+				r: strings.NewReader(testLocationGroupStopsCSVValid),
+			},
+			wantErr: false,
+			wantStops: map[string][]*Stop{
+				"lg-1": {testStop1, testStop2},
+				"lg-2": {testStop2},
+			},
+		},
+		{
+			name: "Invalid Stop (non-strict)",
+			fields: fields{
+				strictMode: false,
+			},
+			args: args{
+				r: strings.NewReader(testLocationGroupStopsCSVInvalidStop),
+			},
+			wantErr: false,
+			wantStops: map[string][]*Stop{
+				"lg-1": nil,
+				"lg-2": nil,
+			},
+		},
+		{
+			name: "Invalid Stop (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				r: strings.NewReader(testLocationGroupStopsCSVInvalidStop),
+			},
+			wantErr: true,
+			wantStops: map[string][]*Stop{
+				"lg-1": nil,
+				"lg-2": nil,
+			},
+		},
+		{
+			name: "Invalid Location Group",
+			fields: fields{
+				strictMode: false,
+			},
+			args: args{
+				r: strings.NewReader(testLocationGroupStopsCSVInvalidGroup),
+			},
+			wantErr: true,
+			wantStops: map[string][]*Stop{
+				"lg-1": nil,
+				"lg-2": nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{
+				stopsByID: map[string]*Stop{
+					"1": testStop1,
+					"2": testStop2,
+				},
+				locationGroupsByID: map[string]*LocationGroup{
+					"lg-1": {ID: "lg-1"},
+					"lg-2": {ID: "lg-2"},
+				},
+				strictMode: tt.fields.strictMode,
+			}
+			if err := g.processLocationGroupStops(tt.args.r); (err != nil) != tt.wantErr {
+				t.Errorf("GTFS.processLocationGroupStops() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for id, want := range tt.wantStops {
+				if got := g.locationGroupByID(id).Stops; !reflect.DeepEqual(got, want) {
+					t.Errorf("GTFS.processLocationGroupStops() Stops[%s] = %v, want %v", id, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package gtfs
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testLocationsGeoJSONValid = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "id": "zone-1",
+      "properties": {"stop_name": "Downtown Zone", "stop_desc": "All of downtown"},
+      "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]], [[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]]}
+    },
+    {
+      "type": "Feature",
+      "id": 2,
+      "properties": {"stop_name": null, "capacity": 12, "active": true},
+      "geometry": {"type": "MultiPolygon", "coordinates": [[[[20, 20], [21, 20], [21, 21], [20, 20]]]]}
+    }
+  ]
+}`
+
+const testLocationsGeoJSONInvalidGeometry = `{
+  "type": "FeatureCollection",
+  "features": [
+    {
+      "type": "Feature",
+      "id": "zone-1",
+      "properties": {},
+      "geometry": {"type": "Point", "coordinates": [0, 0]}
+    }
+  ]
+}`
+
+const testLocationsGeoJSONInvalidType = `{"type": "Feature"}`
+
+var testLocation1 = &Location{
+	ID:          "zone-1",
+	Name:        "Downtown Zone",
+	Description: "All of downtown",
+	Polygons: []Polygon{
+		{
+			{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+			{{4, 4}, {6, 4}, {6, 6}, {4, 6}, {4, 4}},
+		},
+	},
+}
+
+func TestGTFS_processLocations(t *testing.T) {
+	testLocation2 := &Location{
+		ID: "2",
+		Polygons: []Polygon{
+			{
+				{{20, 20}, {21, 20}, {21, 21}, {20, 20}},
+			},
+		},
+	}
+	type args struct {
+		r io.Reader
+	}
+	tests := []struct {
+		name              string
+		args              args
+		wantErr           bool
+		wantLocations     []*Location
+		wantLocationsByID map[string]*Location
+	}{
+		{
+			name: "Valid",
+			args: args{
+				r: strings.NewReader(testLocationsGeoJSONValid),
+			},
+			wantErr: false,
+			wantLocations: []*Location{
+				testLocation1,
+				testLocation2,
+			},
+			wantLocationsByID: map[string]*Location{
+				"zone-1": testLocation1,
+				"2":      testLocation2,
+			},
+		},
+		{
+			name: "Empty",
+			args: args{
+				r: strings.NewReader(""),
+			},
+			wantErr:           true,
+			wantLocations:     nil,
+			wantLocationsByID: nil,
+		},
+		{
+			name: "Invalid Geometry",
+			args: args{
+				r: strings.NewReader(testLocationsGeoJSONInvalidGeometry),
+			},
+			wantErr:           true,
+			wantLocations:     nil,
+			wantLocationsByID: map[string]*Location{},
+		},
+		{
+			name: "Invalid Type",
+			args: args{
+				r: strings.NewReader(testLocationsGeoJSONInvalidType),
+			},
+			wantErr:           true,
+			wantLocations:     nil,
+			wantLocationsByID: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{}
+			if err := g.processLocations(tt.args.r); (err != nil) != tt.wantErr {
+				t.Errorf("GTFS.processLocations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(g.Locations, tt.wantLocations) {
+				t.Errorf("GTFS.processLocations() Locations = %v, wantLocations %v", g.Locations, tt.wantLocations)
+			}
+			if !reflect.DeepEqual(g.locationsByID, tt.wantLocationsByID) {
+				t.Errorf("GTFS.processLocations() locationsByID = %v, wantLocationsByID %v", g.locationsByID, tt.wantLocationsByID)
+			}
+		})
+	}
+}
+
+func TestLocation_Contains(t *testing.T) {
+	type args struct {
+		lat float64
+		lon float64
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "Inside",
+			args: args{
+				lat: 2,
+				lon: 2,
+			},
+			want: true,
+		},
+		{
+			name: "Inside Hole",
+			args: args{
+				lat: 5,
+				lon: 5,
+			},
+			want: false,
+		},
+		{
+			name: "Outside",
+			args: args{
+				lat: 11,
+				lon: 5,
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testLocation1.Contains(tt.args.lat, tt.args.lon); got != tt.want {
+				t.Errorf("Location.Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,603 @@
+package gtfs
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MergeOptions specifies options used when merging feeds.
+type MergeOptions struct {
+	// Prefixes contains the prefix used for IDs from each feed, in the same
+	// order as the feeds. If a feed has no prefix, one is generated from its
+	// position (e.g. "f2_" for the second feed).
+	Prefixes []string
+
+	// PrefixAll causes every ID from every feed to be prefixed. By default,
+	// IDs are only prefixed when they collide with an ID from an earlier
+	// feed.
+	//
+	// Since prefixed IDs from different feeds never match, entities are then
+	// only merged if they are stops or services identical to one from an
+	// earlier feed apart from their IDs. Prefixing is not reported as a
+	// conflict, but further renaming of prefixed IDs that still collide is.
+	PrefixAll bool
+
+	// FeedInfo, if set, is used as the feed info of the merged feed instead of
+	// reconciling the feed info of each feed.
+	FeedInfo *FeedInfo
+}
+
+// MergeResolution specifies how a conflict was resolved when merging feeds.
+type MergeResolution int
+
+const (
+	// MergeResolutionRenamed indicates that an entity's ID collided with the
+	// ID of a different entity from an earlier feed, so it was renamed.
+	MergeResolutionRenamed MergeResolution = iota
+
+	// MergeResolutionDeduplicated indicates that an entity was identical to
+	// an entity from an earlier feed, so the two were merged.
+	MergeResolutionDeduplicated
+
+	// MergeResolutionDiscarded indicates that a value conflicted with a value
+	// from an earlier feed and was discarded.
+	MergeResolutionDiscarded
+)
+
+// A MergeConflict is a conflict between feeds that was resolved when merging
+// them.
+type MergeConflict struct {
+	// Feed is the index of the feed containing the conflicting entity.
+	Feed int
+
+	// Table is the name of the file, without extension, containing the
+	// conflicting entity (e.g. "stops"). Block and zone IDs, which aren't
+	// defined in a file of their own, use "blocks" and "zones".
+	Table string
+
+	// ID is the original ID of the conflicting entity, or the name of the
+	// conflicting field in feed_info.txt.
+	ID string
+
+	Resolution MergeResolution
+
+	// NewID is the ID of the entity in the merged feed. For discarded feed
+	// info values, it is the value that was kept.
+	NewID string
+}
+
+func (c *MergeConflict) String() string {
+	switch c.Resolution {
+	case MergeResolutionRenamed:
+		return fmt.Sprintf("feed %d: %s %s renamed to %s", c.Feed, c.Table, c.ID, c.NewID)
+	case MergeResolutionDeduplicated:
+		return fmt.Sprintf("feed %d: %s %s merged with %s", c.Feed, c.Table, c.ID, c.NewID)
+	default:
+		return fmt.Sprintf("feed %d: %s %s discarded in favor of %s", c.Feed, c.Table, c.ID, c.NewID)
+	}
+}
+
+type merger struct {
+	opts      MergeOptions
+	res       *GTFS
+	conflicts []*MergeConflict
+
+	// Content of stops and services from earlier feeds, used to detect
+	// identical entities with different IDs
+	stopsByContent    map[Stop]*Stop
+	servicesByContent map[string]*Service
+
+	// Block and zone IDs used by earlier feeds
+	blockIDs map[string]bool
+	zoneIDs  map[string]bool
+
+	attributionIDs map[string]bool
+	transfers      map[Transfer]bool
+	translations   map[Translation]bool
+}
+
+// Merge combines feeds into a single feed, returning the merged feed along with
+// every conflict that was resolved while merging. None of the feeds are
+// modified.
+//
+// Entities from each feed are added in order. When an entity has the same ID
+// as an entity from an earlier feed, the two are merged if they are identical,
+// and otherwise the later entity is renamed by prefixing its ID. Stops and
+// services that are identical to a stop or service from an earlier feed apart
+// from their IDs are also merged. References between entities, including
+// translations, are updated to match.
+//
+// Block and zone IDs are renamed in the same way when they are used by trips
+// or stops that weren't merged with those of an earlier feed using the same
+// ID, so that unrelated blocks and fare zones from different feeds are kept
+// apart. Fare rules are updated to match.
+//
+// Unless opts.FeedInfo is set, the merged feed info covers the dates of all
+// feeds, and other fields are taken from the first feed that sets them. If the
+// feeds have different languages, the merged feed's language is "mul".
+func Merge(opts MergeOptions, feeds ...*GTFS) (*GTFS, []*MergeConflict) {
+	m := &merger{
+		opts:              opts,
+		res:               &GTFS{},
+		stopsByContent:    map[Stop]*Stop{},
+		servicesByContent: map[string]*Service{},
+		blockIDs:          map[string]bool{},
+		zoneIDs:           map[string]bool{},
+		attributionIDs:    map[string]bool{},
+		transfers:         map[Transfer]bool{},
+		translations:      map[Translation]bool{},
+	}
+	m.res.index()
+
+	for i, g := range feeds {
+		if i == 0 {
+			m.res.strictMode = g.strictMode
+		}
+
+		m.merge(i, g)
+	}
+
+	if opts.FeedInfo != nil {
+		m.res.FeedInfo = *opts.FeedInfo
+	}
+
+	m.res.index()
+
+	return m.res, m.conflicts
+}
+
+func (m *merger) prefix(feed int) string {
+	if feed < len(m.opts.Prefixes) && m.opts.Prefixes[feed] != "" {
+		return m.opts.Prefixes[feed]
+	}
+
+	return fmt.Sprintf("f%d_", feed+1)
+}
+
+// id returns the ID to use in the merged feed for an entity from feed, given
+// a function reporting whether an ID is already taken. Renames are recorded as
+// conflicts.
+func (m *merger) id(feed int, table, id string, taken func(string) bool) string {
+	base := id
+	if m.opts.PrefixAll {
+		base = m.prefix(feed) + id
+	}
+
+	res := base
+	for n := 1; taken(res); n++ {
+		res = m.prefix(feed) + id
+		if n > 1 {
+			res = fmt.Sprintf("%s%s_%d", m.prefix(feed), id, n)
+		}
+	}
+
+	if res != base {
+		m.conflict(feed, table, id, MergeResolutionRenamed, res)
+	}
+
+	return res
+}
+
+// sharedID returns the ID to use in the merged feed for a block or zone ID
+// from feed, which is shared by several entities rather than defined by one.
+// IDs used by earlier feeds are renamed, consistently within feed: renamed
+// maps the IDs from feed that have already been seen to their new IDs.
+func (m *merger) sharedID(feed int, table, id string, earlier map[string]bool, renamed map[string]string) string {
+	if id == "" {
+		return ""
+	}
+
+	if res, ok := renamed[id]; ok {
+		return res
+	}
+
+	used := map[string]bool{}
+	for _, res := range renamed {
+		used[res] = true
+	}
+
+	res := m.id(feed, table, id, func(id string) bool { return earlier[id] || used[id] })
+	renamed[id] = res
+
+	return res
+}
+
+// renamedZones returns zones with each zone ID replaced by its new ID from
+// renamed, if it has one.
+func renamedZones(zones []string, renamed map[string]string) []string {
+	if zones == nil {
+		return nil
+	}
+
+	res := make([]string, len(zones))
+	for i, z := range zones {
+		res[i] = z
+		if r, ok := renamed[z]; ok {
+			res[i] = r
+		}
+	}
+
+	return res
+}
+
+func (m *merger) conflict(feed int, table, id string, resolution MergeResolution, newID string) {
+	m.conflicts = append(m.conflicts, &MergeConflict{
+		Feed:       feed,
+		Table:      table,
+		ID:         id,
+		Resolution: resolution,
+		NewID:      newID,
+	})
+}
+
+func (m *merger) merge(feed int, g *GTFS) {
+	res := m.res
+	c := newCopier()
+
+	// Stops and services are only deduplicated by content across feeds, not
+	// within a single feed
+	stopsByContent := map[Stop]*Stop{}
+	servicesByContent := map[string]*Service{}
+
+	// New IDs of the blocks and zones used by entities that weren't merged
+	blocks := map[string]string{}
+	zones := map[string]string{}
+
+	for _, a := range g.Agencies {
+		cp := c.agency(a)
+		if existing := res.agenciesByID[cp.ID]; existing != nil && *existing == *cp {
+			c.agencies[a] = existing
+			m.conflict(feed, "agency", a.ID, MergeResolutionDeduplicated, existing.ID)
+			continue
+		}
+
+		cp.ID = m.id(feed, "agency", cp.ID, func(id string) bool { return res.agenciesByID[id] != nil })
+		res.agenciesByID[cp.ID] = cp
+		res.Agencies = append(res.Agencies, cp)
+	}
+
+	var addStop func(s *Stop)
+	addStop = func(s *Stop) {
+		if s == nil {
+			return
+		}
+
+		if _, ok := c.stops[s]; ok {
+			return
+		}
+
+		// Parent stations must be merged before the stops within them
+		addStop(s.ParentStation)
+
+		cp := c.stop(s)
+		content := *cp
+		content.ID = ""
+
+		// Stops from earlier feeds are keyed by their content after their
+		// zones were renamed, so they only match stops in the same zone
+		existing := res.stopsByID[cp.ID]
+		if existing == nil || *existing != *cp {
+			existing = m.stopsByContent[content]
+		}
+
+		if existing != nil {
+			c.stops[s] = existing
+			m.conflict(feed, "stops", s.ID, MergeResolutionDeduplicated, existing.ID)
+			return
+		}
+
+		cp.ID = m.id(feed, "stops", cp.ID, func(id string) bool { return res.stopsByID[id] != nil })
+		cp.ZoneID = m.sharedID(feed, "zones", cp.ZoneID, m.zoneIDs, zones)
+		res.stopsByID[cp.ID] = cp
+		res.Stops = append(res.Stops, cp)
+
+		content = *cp
+		content.ID = ""
+		stopsByContent[content] = cp
+	}
+
+	for _, s := range g.Stops {
+		addStop(s)
+	}
+
+	for _, r := range g.Routes {
+		cp := c.route(r)
+		if existing := res.routesByID[cp.ID]; existing != nil && *existing == *cp {
+			c.routes[r] = existing
+			m.conflict(feed, "routes", r.ID, MergeResolutionDeduplicated, existing.ID)
+			continue
+		}
+
+		cp.ID = m.id(feed, "routes", cp.ID, func(id string) bool { return res.routesByID[id] != nil })
+		res.routesByID[cp.ID] = cp
+		res.Routes = append(res.Routes, cp)
+	}
+
+	for _, s := range g.Services {
+		cp := c.service(s)
+		content := serviceContent(cp)
+
+		existing := res.servicesByID[cp.ID]
+		if existing == nil || serviceContent(existing) != content {
+			existing = m.servicesByContent[content]
+		}
+
+		if existing != nil {
+			c.services[s] = existing
+			m.conflict(feed, "calendar", s.ID, MergeResolutionDeduplicated, existing.ID)
+			continue
+		}
+
+		cp.ID = m.id(feed, "calendar", cp.ID, func(id string) bool { return res.servicesByID[id] != nil })
+		res.servicesByID[cp.ID] = cp
+		res.Services = append(res.Services, cp)
+		servicesByContent[content] = cp
+	}
+
+	for _, s := range g.Shapes {
+		cp := c.shape(s)
+		if existing := res.shapesByID[cp.ID]; existing != nil && reflect.DeepEqual(existing, cp) {
+			c.shapes[s] = existing
+			m.conflict(feed, "shapes", s.ID, MergeResolutionDeduplicated, existing.ID)
+			continue
+		}
+
+		cp.ID = m.id(feed, "shapes", cp.ID, func(id string) bool { return res.shapesByID[id] != nil })
+		res.shapesByID[cp.ID] = cp
+		res.Shapes = append(res.Shapes, cp)
+	}
+
+	for _, l := range g.Locations {
+		cp := c.location(l)
+		if existing := res.locationsByID[cp.ID]; existing != nil && reflect.DeepEqual(existing, cp) {
+			c.locations[l] = existing
+			m.conflict(feed, "locations", l.ID, MergeResolutionDeduplicated, existing.ID)
+			continue
+		}
+
+		cp.ID = m.id(feed, "locations", cp.ID, func(id string) bool { return res.locationsByID[id] != nil })
+		res.locationsByID[cp.ID] = cp
+		res.Locations = append(res.Locations, cp)
+	}
+
+	for _, lg := range g.LocationGroups {
+		cp := c.locationGroup(lg)
+		if existing := res.locationGroupsByID[cp.ID]; existing != nil && reflect.DeepEqual(existing, cp) {
+			c.locationGroups[lg] = existing
+			m.conflict(feed, "location_groups", lg.ID, MergeResolutionDeduplicated, existing.ID)
+			continue
+		}
+
+		cp.ID = m.id(feed, "location_groups", cp.ID, func(id string) bool { return res.locationGroupsByID[id] != nil })
+		res.locationGroupsByID[cp.ID] = cp
+		res.LocationGroups = append(res.LocationGroups, cp)
+	}
+
+	for _, br := range g.BookingRules {
+		cp := c.bookingRule(br)
+		if existing := res.bookingRulesByID[cp.ID]; existing != nil && *existing == *cp {
+			c.bookingRules[br] = existing
+			m.conflict(feed, "booking_rules", br.ID, MergeResolutionDeduplicated, existing.ID)
+			continue
+		}
+
+		cp.ID = m.id(feed, "booking_rules", cp.ID, func(id string) bool { return res.bookingRulesByID[id] != nil })
+		res.bookingRulesByID[cp.ID] = cp
+		res.BookingRules = append(res.BookingRules, cp)
+	}
+
+	for _, t := range g.Trips {
+		cp := c.trip(t)
+		if existing := res.tripsByID[cp.ID]; existing != nil && reflect.DeepEqual(existing, cp) {
+			c.trips[t] = existing
+			m.conflict(feed, "trips", t.ID, MergeResolutionDeduplicated, existing.ID)
+			continue
+		}
+
+		cp.ID = m.id(feed, "trips", cp.ID, func(id string) bool { return res.tripsByID[id] != nil })
+		cp.BlockID = m.sharedID(feed, "blocks", cp.BlockID, m.blockIDs, blocks)
+		res.tripsByID[cp.ID] = cp
+		res.Trips = append(res.Trips, cp)
+	}
+
+	for _, f := range g.Fares {
+		cp := c.filteredFare(f, nil)
+		if cp == nil {
+			continue
+		}
+
+		cp.OriginZones = renamedZones(cp.OriginZones, zones)
+		cp.DestinationZones = renamedZones(cp.DestinationZones, zones)
+		cp.ContainsZones = renamedZones(cp.ContainsZones, zones)
+
+		if existing := res.faresByID[cp.ID]; existing != nil && reflect.DeepEqual(existing, cp) {
+			m.conflict(feed, "fare_attributes", f.ID, MergeResolutionDeduplicated, existing.ID)
+			continue
+		}
+
+		cp.ID = m.id(feed, "fare_attributes", cp.ID, func(id string) bool { return res.faresByID[id] != nil })
+		res.faresByID[cp.ID] = cp
+		res.Fares = append(res.Fares, cp)
+	}
+
+	for _, t := range g.Transfers {
+		cp := c.filteredTransfer(t)
+		if cp == nil || m.transfers[*cp] {
+			continue
+		}
+
+		m.transfers[*cp] = true
+		res.Transfers = append(res.Transfers, cp)
+	}
+
+	var attributions []*Attribution
+	for _, a := range g.Attributions {
+		cp := c.filteredAttribution(a)
+		if cp == nil {
+			continue
+		}
+
+		if cp.ID != "" {
+			if existing := m.attribution(cp.ID); existing != nil && *existing == *cp {
+				c.attributions[a] = existing
+				m.conflict(feed, "attributions", a.ID, MergeResolutionDeduplicated, existing.ID)
+				continue
+			}
+
+			cp.ID = m.id(feed, "attributions", cp.ID, func(id string) bool { return m.attributionIDs[id] })
+			m.attributionIDs[cp.ID] = true
+		}
+
+		attributions = append(attributions, cp)
+	}
+	res.Attributions = append(res.Attributions, attributions...)
+
+	for _, t := range g.Translations {
+		cp := *t
+		cp.RecordID = c.translatedRecordID(g, t)
+		if m.translations[cp] {
+			continue
+		}
+
+		m.translations[cp] = true
+		res.Translations = append(res.Translations, &cp)
+	}
+
+	for content, s := range stopsByContent {
+		m.stopsByContent[content] = s
+	}
+
+	for content, s := range servicesByContent {
+		m.servicesByContent[content] = s
+	}
+
+	for _, t := range res.Trips {
+		if t.BlockID != "" {
+			m.blockIDs[t.BlockID] = true
+		}
+	}
+
+	for _, s := range res.Stops {
+		if s.ZoneID != "" {
+			m.zoneIDs[s.ZoneID] = true
+		}
+	}
+
+	for _, f := range res.Fares {
+		for _, zones := range [][]string{f.OriginZones, f.DestinationZones, f.ContainsZones} {
+			for _, z := range zones {
+				m.zoneIDs[z] = true
+			}
+		}
+	}
+
+	m.mergeFeedInfo(feed, g.FeedInfo)
+}
+
+func (m *merger) attribution(id string) *Attribution {
+	for _, a := range m.res.Attributions {
+		if a.ID == id {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// translatedRecordID returns the ID of the copy of the record translated by t,
+// which is from g.
+func (c *copier) translatedRecordID(g *GTFS, t *Translation) string {
+	if t.RecordID == "" {
+		return ""
+	}
+
+	switch t.TableName {
+	case "agency":
+		if cp, ok := c.agencies[g.agencyByID(t.RecordID)]; ok {
+			return cp.ID
+		}
+	case "stops":
+		if cp, ok := c.stops[g.stopByID(t.RecordID)]; ok {
+			return cp.ID
+		}
+	case "routes":
+		if cp, ok := c.routes[g.routeByID(t.RecordID)]; ok {
+			return cp.ID
+		}
+	case "trips", "stop_times":
+		if cp, ok := c.trips[g.tripByID(t.RecordID)]; ok {
+			return cp.ID
+		}
+	case "attributions":
+		for _, a := range g.Attributions {
+			if a.ID != t.RecordID {
+				continue
+			}
+
+			if cp, ok := c.attributions[a]; ok {
+				return cp.ID
+			}
+		}
+	}
+
+	return t.RecordID
+}
+
+// mergeFeedInfo reconciles info, from feed, with the feed info of the merged
+// feed.
+func (m *merger) mergeFeedInfo(feed int, info FeedInfo) {
+	res := &m.res.FeedInfo
+
+	if info.StartDate != "" && (res.StartDate == "" || info.StartDate < res.StartDate) {
+		res.StartDate = info.StartDate
+	}
+
+	if info.EndDate != "" && (res.EndDate == "" || info.EndDate > res.EndDate) {
+		res.EndDate = info.EndDate
+	}
+
+	fields := []struct {
+		name string
+		dst  *string
+		val  string
+	}{
+		{"feed_publisher_name", &res.PublisherName, info.PublisherName},
+		{"feed_publisher_url", &res.PublisherURL, info.PublisherURL},
+		{"feed_lang", &res.Lang, info.Lang},
+		{"feed_version", &res.Version, info.Version},
+		{"feed_contact_email", &res.ContactEmail, info.ContactEmail},
+		{"feed_contact_url", &res.ContactURL, info.ContactURL},
+	}
+	for _, f := range fields {
+		switch {
+		case f.val == "" || f.val == *f.dst:
+		case *f.dst == "":
+			*f.dst = f.val
+		case f.name == "feed_lang":
+			// Feeds in different languages make a multilingual feed
+			*f.dst = "mul"
+		default:
+			m.conflict(feed, "feed_info", f.name, MergeResolutionDiscarded, *f.dst)
+		}
+	}
+}
+
+// serviceContent returns a string identifying the dates on which s is active,
+// ignoring its ID.
+func serviceContent(s *Service) string {
+	days := []bool{s.Monday, s.Tuesday, s.Wednesday, s.Thursday, s.Friday, s.Saturday, s.Sunday}
+
+	var b strings.Builder
+	for _, d := range days {
+		if d {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+
+	fmt.Fprintf(&b, "|%s|%s|%s|%s", s.StartDate, s.EndDate, strings.Join(s.AdditionalDates, ","), strings.Join(s.ExceptDates, ","))
+
+	return b.String()
+}
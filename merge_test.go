@@ -0,0 +1,284 @@
+package gtfs
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newMergeTestFeeds() (*GTFS, *GTFS) {
+	agency := Agency{ID: "agency", Name: "Transit", Timezone: "America/New_York"}
+	weekday := Service{ID: "weekday", Monday: true, Tuesday: true, Wednesday: true, Thursday: true, Friday: true, StartDate: "20190101", EndDate: "20191231"}
+
+	a1 := agency
+	s1 := &Stop{ID: "s1", Name: "First", Latitude: 40.0, Longitude: -75.0}
+	s2 := &Stop{ID: "s2", Name: "Second", Latitude: 40.1, Longitude: -75.0}
+	r1 := &Route{ID: "r1", Agency: &a1, ShortName: "1"}
+	wk1 := weekday
+	t1 := newBlockTestTrip("t1", "", &wk1, []*Stop{s1, s2}, []string{"08:00:00", "08:10:00"})
+	t1.Route = r1
+
+	g1 := &GTFS{
+		Agencies:  []*Agency{&a1},
+		Stops:     []*Stop{s1, s2},
+		Routes:    []*Route{r1},
+		Services:  []*Service{&wk1},
+		Trips:     []*Trip{t1},
+		Fares:     []*Fare{{ID: "fare", Price: "2.00", CurrencyType: "USD"}},
+		Transfers: []*Transfer{{From: s1, To: s2, Type: TransferTypeRecommended}},
+		FeedInfo: FeedInfo{
+			PublisherName: "Publisher",
+			Lang:          "en",
+			StartDate:     "20190101",
+			EndDate:       "20190630",
+		},
+		Translations: []*Translation{
+			{TableName: "stops", FieldName: "stop_name", RecordID: "s2", Language: "fr", Translation: "Deuxième"},
+		},
+	}
+	g1.index()
+
+	a2 := agency
+	s1b := *s1
+	other := &Stop{ID: "other", Name: "Second", Latitude: 40.1, Longitude: -75.0}
+	s2b := &Stop{ID: "s2", Name: "Elsewhere", Latitude: 41.0, Longitude: -75.0}
+	r1b := &Route{ID: "r1", Agency: &a2, ShortName: "1X"}
+	wk2 := weekday
+	wk2.ID = "mon-fri"
+	t1b := newBlockTestTrip("t1", "", &wk2, []*Stop{&s1b, other, s2b}, []string{"09:00:00", "09:10:00", "09:20:00"})
+	t1b.Route = r1b
+
+	g2 := &GTFS{
+		Agencies:  []*Agency{&a2},
+		Stops:     []*Stop{&s1b, other, s2b},
+		Routes:    []*Route{r1b},
+		Services:  []*Service{&wk2},
+		Trips:     []*Trip{t1b},
+		Fares:     []*Fare{{ID: "fare", Price: "2.50", CurrencyType: "USD"}},
+		Transfers: []*Transfer{{From: &s1b, To: other, Type: TransferTypeRecommended}},
+		FeedInfo: FeedInfo{
+			PublisherName: "Other Publisher",
+			Lang:          "fr",
+			Version:       "2",
+			StartDate:     "20190601",
+			EndDate:       "20191231",
+		},
+		Translations: []*Translation{
+			{TableName: "stops", FieldName: "stop_name", RecordID: "s2", Language: "fr", Translation: "Ailleurs"},
+		},
+	}
+	g2.index()
+
+	return g1, g2
+}
+
+func TestMerge(t *testing.T) {
+	g1, g2 := newMergeTestFeeds()
+	before := filterTestIDs(g2)
+
+	res, conflicts := Merge(MergeOptions{}, g1, g2)
+
+	want := map[string][]string{
+		"agencies":     {"agency"},
+		"stops":        {"s1", "s2", "f2_s2"},
+		"routes":       {"r1", "f2_r1"},
+		"services":     {"weekday"},
+		"trips":        {"t1", "f2_t1"},
+		"stop_times":   {"t1:s1", "t1:s2", "f2_t1:s1", "f2_t1:s2", "f2_t1:f2_s2"},
+		"fares":        {"fare", "f2_fare"},
+		"transfers":    {"s1:s2"},
+		"translations": {"Deuxième", "Ailleurs"},
+	}
+	if got := filterTestIDs(res); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %v, want %v", got, want)
+	}
+
+	wantConflicts := []*MergeConflict{
+		{Feed: 1, Table: "agency", ID: "agency", Resolution: MergeResolutionDeduplicated, NewID: "agency"},
+		{Feed: 1, Table: "stops", ID: "s1", Resolution: MergeResolutionDeduplicated, NewID: "s1"},
+		{Feed: 1, Table: "stops", ID: "other", Resolution: MergeResolutionDeduplicated, NewID: "s2"},
+		{Feed: 1, Table: "stops", ID: "s2", Resolution: MergeResolutionRenamed, NewID: "f2_s2"},
+		{Feed: 1, Table: "routes", ID: "r1", Resolution: MergeResolutionRenamed, NewID: "f2_r1"},
+		{Feed: 1, Table: "calendar", ID: "mon-fri", Resolution: MergeResolutionDeduplicated, NewID: "weekday"},
+		{Feed: 1, Table: "trips", ID: "t1", Resolution: MergeResolutionRenamed, NewID: "f2_t1"},
+		{Feed: 1, Table: "fare_attributes", ID: "fare", Resolution: MergeResolutionRenamed, NewID: "f2_fare"},
+		{Feed: 1, Table: "feed_info", ID: "feed_publisher_name", Resolution: MergeResolutionDiscarded, NewID: "Publisher"},
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		for _, c := range conflicts {
+			t.Logf("conflict: %v", c)
+		}
+		t.Errorf("Merge() conflicts = %v, want %v", conflicts, wantConflicts)
+	}
+
+	wantFeedInfo := FeedInfo{
+		PublisherName: "Publisher",
+		Lang:          "mul",
+		Version:       "2",
+		StartDate:     "20190101",
+		EndDate:       "20191231",
+	}
+	if res.FeedInfo != wantFeedInfo {
+		t.Errorf("Merge() FeedInfo = %+v, want %+v", res.FeedInfo, wantFeedInfo)
+	}
+
+	// References must point to entities in the merged feed
+	trip := res.tripByID("f2_t1")
+	if trip.Route != res.routeByID("f2_r1") || trip.Route.Agency != res.agencyByID("agency") || trip.Service != res.serviceByID("weekday") {
+		t.Errorf("Merge() trip references entities outside of the merged feed")
+	}
+
+	if got := res.TranslateStopName(res.stopByID("f2_s2"), "fr"); got != "Ailleurs" {
+		t.Errorf("GTFS.TranslateStopName() on merged feed = %v, want %v", got, "Ailleurs")
+	}
+
+	if after := filterTestIDs(g2); !reflect.DeepEqual(after, before) {
+		t.Errorf("Merge() modified feed: %v, want %v", after, before)
+	}
+}
+
+func TestMerge_prefixAll(t *testing.T) {
+	g1, g2 := newMergeTestFeeds()
+
+	res, conflicts := Merge(MergeOptions{Prefixes: []string{"a:", "b:"}, PrefixAll: true}, g1, g2)
+
+	want := map[string][]string{
+		"agencies":     {"a:agency", "b:agency"},
+		"stops":        {"a:s1", "a:s2", "b:s2"},
+		"routes":       {"a:r1", "b:r1"},
+		"services":     {"a:weekday"},
+		"trips":        {"a:t1", "b:t1"},
+		"stop_times":   {"a:t1:a:s1", "a:t1:a:s2", "b:t1:a:s1", "b:t1:a:s2", "b:t1:b:s2"},
+		"fares":        {"a:fare", "b:fare"},
+		"transfers":    {"a:s1:a:s2"},
+		"translations": {"Deuxième", "Ailleurs"},
+	}
+	if got := filterTestIDs(res); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %v, want %v", got, want)
+	}
+
+	wantConflicts := []*MergeConflict{
+		{Feed: 1, Table: "stops", ID: "s1", Resolution: MergeResolutionDeduplicated, NewID: "a:s1"},
+		{Feed: 1, Table: "stops", ID: "other", Resolution: MergeResolutionDeduplicated, NewID: "a:s2"},
+		{Feed: 1, Table: "calendar", ID: "mon-fri", Resolution: MergeResolutionDeduplicated, NewID: "a:weekday"},
+		{Feed: 1, Table: "feed_info", ID: "feed_publisher_name", Resolution: MergeResolutionDiscarded, NewID: "Publisher"},
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		for _, c := range conflicts {
+			t.Logf("conflict: %v", c)
+		}
+		t.Errorf("Merge() conflicts = %v, want %v", conflicts, wantConflicts)
+	}
+}
+
+func TestMerge_blocksAndZones(t *testing.T) {
+	newFeed := func(stopID, name string) *GTFS {
+		s1 := &Stop{ID: stopID + "1", Name: name + " 1", ZoneID: "z1"}
+		s2 := &Stop{ID: stopID + "2", Name: name + " 2", ZoneID: "z2"}
+		trip := newBlockTestTrip(stopID, "b1", nil, []*Stop{s1, s2}, []string{"08:00:00", "08:10:00"})
+
+		g := &GTFS{
+			Stops: []*Stop{s1, s2},
+			Trips: []*Trip{trip},
+			Fares: []*Fare{{ID: stopID, Price: "2.00", OriginZones: []string{"z1"}, DestinationZones: []string{"z2"}, ContainsZones: []string{"z1", "z3"}}},
+		}
+		g.index()
+
+		return g
+	}
+
+	g1 := newFeed("a", "First")
+	g2 := newFeed("b", "Second")
+
+	res, conflicts := Merge(MergeOptions{}, g1, g2)
+
+	var blocks, zones []string
+	for _, trip := range res.Trips {
+		blocks = append(blocks, trip.BlockID)
+	}
+
+	for _, s := range res.Stops {
+		zones = append(zones, s.ZoneID)
+	}
+
+	if want := []string{"b1", "f2_b1"}; !reflect.DeepEqual(blocks, want) {
+		t.Errorf("Merge() block IDs = %v, want %v", blocks, want)
+	}
+
+	if want := []string{"z1", "z2", "f2_z1", "f2_z2"}; !reflect.DeepEqual(zones, want) {
+		t.Errorf("Merge() zone IDs = %v, want %v", zones, want)
+	}
+
+	fare := res.Fares[1]
+	if !reflect.DeepEqual(fare.OriginZones, []string{"f2_z1"}) || !reflect.DeepEqual(fare.DestinationZones, []string{"f2_z2"}) || !reflect.DeepEqual(fare.ContainsZones, []string{"f2_z1", "z3"}) {
+		t.Errorf("Merge() fare = %+v, want renamed zones", fare)
+	}
+
+	if !reflect.DeepEqual(g2.Fares[0].OriginZones, []string{"z1"}) {
+		t.Errorf("Merge() modified fare zones of feed")
+	}
+
+	wantConflicts := []*MergeConflict{
+		{Feed: 1, Table: "zones", ID: "z1", Resolution: MergeResolutionRenamed, NewID: "f2_z1"},
+		{Feed: 1, Table: "zones", ID: "z2", Resolution: MergeResolutionRenamed, NewID: "f2_z2"},
+		{Feed: 1, Table: "blocks", ID: "b1", Resolution: MergeResolutionRenamed, NewID: "f2_b1"},
+	}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		for _, c := range conflicts {
+			t.Logf("conflict: %v", c)
+		}
+		t.Errorf("Merge() conflicts = %v, want %v", conflicts, wantConflicts)
+	}
+
+	// Stops are only merged by content with stops in the same zone of the
+	// merged feed
+	res, _ = Merge(MergeOptions{}, g1, g2, newFeed("c", "Second"))
+	if len(res.Stops) != 6 || res.Stops[4].ZoneID == res.Stops[2].ZoneID {
+		t.Errorf("Merge() stops = %+v, want third feed's stops kept apart from renamed zones", res.Stops)
+	}
+
+	// Identical feeds are merged without renaming blocks or zones
+	res, _ = Merge(MergeOptions{}, g1, newFeed("a", "First"))
+	if len(res.Trips) != 1 || len(res.Stops) != 2 || len(res.Fares) != 1 || res.Stops[0].ZoneID != "z1" {
+		t.Errorf("Merge() of identical feeds = %+v, want a single copy", res)
+	}
+}
+
+func TestMerge_prefixAllCollision(t *testing.T) {
+	g1, g2 := newMergeTestFeeds()
+
+	_, conflicts := Merge(MergeOptions{Prefixes: []string{"a:", "a:"}, PrefixAll: true}, g1, g2)
+
+	want := &MergeConflict{Feed: 1, Table: "agency", ID: "agency", Resolution: MergeResolutionRenamed, NewID: "a:agency_2"}
+	if len(conflicts) == 0 || !reflect.DeepEqual(conflicts[0], want) {
+		t.Errorf("Merge() conflicts = %v, want %v first", conflicts, want)
+	}
+}
+
+func TestMerge_attributionTranslations(t *testing.T) {
+	newFeed := func(name, translation string) *GTFS {
+		g := &GTFS{
+			Attributions: []*Attribution{{ID: "attr", OrganizationName: name, IsProducer: true}},
+			Translations: []*Translation{
+				{TableName: "attributions", FieldName: "organization_name", RecordID: "attr", Language: "fr", Translation: translation},
+			},
+		}
+		g.index()
+
+		return g
+	}
+
+	res, _ := Merge(MergeOptions{}, newFeed("First", "Premier"), newFeed("Second", "Deuxième"))
+
+	if len(res.Attributions) != 2 || res.Attributions[1].ID != "f2_attr" {
+		t.Fatalf("Merge() attributions = %+v, want attr and f2_attr", res.Attributions)
+	}
+
+	var got []string
+	for _, tr := range res.Translations {
+		got = append(got, tr.RecordID)
+	}
+
+	if want := []string{"attr", "f2_attr"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() translation record IDs = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,414 @@
+package realtime
+
+import (
+	"strings"
+	"time"
+)
+
+// An Alert describes a disruption to service.
+type Alert struct {
+	// ActivePeriods contains the times during which the alert should be
+	// shown. If empty, the alert should be shown for as long as it is in
+	// the feed.
+	ActivePeriods []TimeRange
+
+	// InformedEntities contains the agencies, routes, trips and stops
+	// affected by the alert.
+	InformedEntities []*EntitySelector
+
+	Cause         Cause
+	Effect        Effect
+	SeverityLevel SeverityLevel
+
+	URL             TranslatedString
+	HeaderText      TranslatedString
+	DescriptionText TranslatedString
+
+	TTSHeaderText      TranslatedString
+	TTSDescriptionText TranslatedString
+
+	CauseDetail  TranslatedString
+	EffectDetail TranslatedString
+}
+
+// A TimeRange is a time interval. If Start or End is zero, the interval is
+// open-ended in that direction.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains returns whether t is within r. Both ends of r are inclusive.
+func (r TimeRange) Contains(t time.Time) bool {
+	return (r.Start.IsZero() || !t.Before(r.Start)) && (r.End.IsZero() || !t.After(r.End))
+}
+
+// IsActiveAt returns whether a should be shown at t.
+func (a *Alert) IsActiveAt(t time.Time) bool {
+	if len(a.ActivePeriods) == 0 {
+		return true
+	}
+
+	for _, r := range a.ActivePeriods {
+		if r.Contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// An EntitySelector identifies an entity affected by an alert. All fields that
+// are set must match; for example, a selector with both RouteID and StopID
+// identifies the route at the stop only.
+type EntitySelector struct {
+	AgencyID    string
+	RouteID     string
+	RouteType   *int32
+	DirectionID *uint32
+	Trip        *TripDescriptor
+	StopID      string
+}
+
+// A TranslatedString is text with translations into multiple languages.
+type TranslatedString []Translation
+
+// A Translation is the text of a TranslatedString in a single language.
+// Language is a BCP-47 language code, and may be empty if the text is in the
+// feed's default language.
+type Translation struct {
+	Text     string
+	Language string
+}
+
+// Text returns the text of s in the specified language. If s has no text in
+// that language, the text without a language is returned, or the first
+// translation if there is none.
+func (s TranslatedString) Text(language string) string {
+	for _, t := range s {
+		if strings.EqualFold(t.Language, language) {
+			return t.Text
+		}
+	}
+
+	for _, t := range s {
+		if t.Language == "" {
+			return t.Text
+		}
+	}
+
+	if len(s) > 0 {
+		return s[0].Text
+	}
+
+	return ""
+}
+
+// Cause indicates the cause of an alert.
+type Cause int
+
+const (
+	// CauseUnknown means that the cause is unknown.
+	CauseUnknown Cause = iota
+
+	// CauseOther means that the cause isn't represented by other values.
+	CauseOther
+
+	// CauseTechnicalProblem means that the alert was caused by a technical
+	// problem.
+	CauseTechnicalProblem
+
+	// CauseStrike means that the alert was caused by a strike.
+	CauseStrike
+
+	// CauseDemonstration means that the alert was caused by a demonstration.
+	CauseDemonstration
+
+	// CauseAccident means that the alert was caused by an accident.
+	CauseAccident
+
+	// CauseHoliday means that the alert was caused by a holiday.
+	CauseHoliday
+
+	// CauseWeather means that the alert was caused by weather.
+	CauseWeather
+
+	// CauseMaintenance means that the alert was caused by maintenance.
+	CauseMaintenance
+
+	// CauseConstruction means that the alert was caused by construction.
+	CauseConstruction
+
+	// CausePoliceActivity means that the alert was caused by police
+	// activity.
+	CausePoliceActivity
+
+	// CauseMedicalEmergency means that the alert was caused by a medical
+	// emergency.
+	CauseMedicalEmergency
+)
+
+// Effect indicates the effect of an alert on service.
+type Effect int
+
+const (
+	// EffectUnknown means that the effect is unknown.
+	EffectUnknown Effect = iota
+
+	// EffectNoService means that there is no service.
+	EffectNoService
+
+	// EffectReducedService means that service is reduced.
+	EffectReducedService
+
+	// EffectSignificantDelays means that there are significant delays.
+	EffectSignificantDelays
+
+	// EffectDetour means that service is detoured.
+	EffectDetour
+
+	// EffectAdditionalService means that additional service is provided.
+	EffectAdditionalService
+
+	// EffectModifiedService means that service is modified.
+	EffectModifiedService
+
+	// EffectOther means that the effect isn't represented by other values.
+	EffectOther
+
+	// EffectStopMoved means that a stop has moved.
+	EffectStopMoved
+
+	// EffectNoEffect means that the alert has no effect on service, and is
+	// informational only.
+	EffectNoEffect
+
+	// EffectAccessibilityIssue means that accessibility is affected, but
+	// service isn't.
+	EffectAccessibilityIssue
+)
+
+// SeverityLevel indicates the severity of an alert.
+type SeverityLevel int
+
+const (
+	// SeverityUnknown means that the severity is unknown.
+	SeverityUnknown SeverityLevel = iota
+
+	// SeverityInfo means that the alert is informational.
+	SeverityInfo
+
+	// SeverityWarning means that the alert is a warning.
+	SeverityWarning
+
+	// SeveritySevere means that the alert is severe.
+	SeveritySevere
+)
+
+func (a *Alert) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			var r TimeRange
+			if err = d.embedded(r.decode); err == nil {
+				a.ActivePeriods = append(a.ActivePeriods, r)
+			}
+		case 5:
+			s := &EntitySelector{}
+			if err = d.embedded(s.decode); err == nil {
+				a.InformedEntities = append(a.InformedEntities, s)
+			}
+		case 6:
+			var v uint64
+			v, err = d.uint64()
+			if c, ok := parseCause(v); ok {
+				a.Cause = c
+			}
+		case 7:
+			var v uint64
+			v, err = d.uint64()
+			if e, ok := parseEffect(v); ok {
+				a.Effect = e
+			}
+		case 8:
+			err = d.embedded(a.URL.decode)
+		case 10:
+			err = d.embedded(a.HeaderText.decode)
+		case 11:
+			err = d.embedded(a.DescriptionText.decode)
+		case 12:
+			err = d.embedded(a.TTSHeaderText.decode)
+		case 13:
+			err = d.embedded(a.TTSDescriptionText.decode)
+		case 14:
+			var v uint64
+			v, err = d.uint64()
+			if s, ok := parseSeverityLevel(v); ok {
+				a.SeverityLevel = s
+			}
+		case 17:
+			err = d.embedded(a.CauseDetail.decode)
+		case 18:
+			err = d.embedded(a.EffectDetail.decode)
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func (r *TimeRange) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			r.Start, err = d.timestamp()
+		case 2:
+			r.End, err = d.timestamp()
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func (s *EntitySelector) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			s.AgencyID, err = d.string()
+		case 2:
+			s.RouteID, err = d.string()
+		case 3:
+			var v int32
+			v, err = d.int32()
+			s.RouteType = &v
+		case 4:
+			if s.Trip == nil {
+				s.Trip = &TripDescriptor{}
+			}
+			err = d.embedded(s.Trip.decode)
+		case 5:
+			s.StopID, err = d.string()
+		case 6:
+			var v uint32
+			v, err = d.uint32()
+			s.DirectionID = &v
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func (s *TranslatedString) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		if d.field != 1 {
+			return d.skip()
+		}
+
+		var t Translation
+		err := d.embedded(func(b []byte) error {
+			return decodeMessage(b, func(d *decoder) error {
+				var err error
+				switch d.field {
+				case 1:
+					t.Text, err = d.string()
+				case 2:
+					t.Language, err = d.string()
+				default:
+					err = d.skip()
+				}
+
+				return err
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		*s = append(*s, t)
+
+		return nil
+	})
+}
+
+func parseCause(v uint64) (Cause, bool) {
+	switch v {
+	case 1:
+		return CauseUnknown, true
+	case 2:
+		return CauseOther, true
+	case 3:
+		return CauseTechnicalProblem, true
+	case 4:
+		return CauseStrike, true
+	case 5:
+		return CauseDemonstration, true
+	case 6:
+		return CauseAccident, true
+	case 7:
+		return CauseHoliday, true
+	case 8:
+		return CauseWeather, true
+	case 9:
+		return CauseMaintenance, true
+	case 10:
+		return CauseConstruction, true
+	case 11:
+		return CausePoliceActivity, true
+	case 12:
+		return CauseMedicalEmergency, true
+	default:
+		return CauseUnknown, false
+	}
+}
+
+func parseEffect(v uint64) (Effect, bool) {
+	switch v {
+	case 1:
+		return EffectNoService, true
+	case 2:
+		return EffectReducedService, true
+	case 3:
+		return EffectSignificantDelays, true
+	case 4:
+		return EffectDetour, true
+	case 5:
+		return EffectAdditionalService, true
+	case 6:
+		return EffectModifiedService, true
+	case 7:
+		return EffectOther, true
+	case 8:
+		return EffectUnknown, true
+	case 9:
+		return EffectStopMoved, true
+	case 10:
+		return EffectNoEffect, true
+	case 11:
+		return EffectAccessibilityIssue, true
+	default:
+		return EffectUnknown, false
+	}
+}
+
+func parseSeverityLevel(v uint64) (SeverityLevel, bool) {
+	switch v {
+	case 1:
+		return SeverityUnknown, true
+	case 2:
+		return SeverityInfo, true
+	case 3:
+		return SeverityWarning, true
+	case 4:
+		return SeveritySevere, true
+	default:
+		return SeverityUnknown, false
+	}
+}
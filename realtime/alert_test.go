@@ -0,0 +1,114 @@
+package realtime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAlert_decode(t *testing.T) {
+	feed := loadTestFeed(t, "alerts.pb")
+
+	routeType := int32(3)
+	want := &Alert{
+		ActivePeriods: []TimeRange{
+			{
+				Start: time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC),
+				End:   time.Date(2023, 11, 14, 23, 13, 20, 0, time.UTC),
+			},
+			{
+				Start: time.Date(2023, 11, 15, 22, 13, 20, 0, time.UTC),
+			},
+		},
+		InformedEntities: []*EntitySelector{
+			{AgencyID: "agency"},
+			{RouteID: "r1", DirectionID: uint32Ptr(1)},
+			{Trip: &TripDescriptor{TripID: "t1"}},
+			{StopID: "a", RouteType: &routeType},
+		},
+		Cause:         CauseMaintenance,
+		Effect:        EffectDetour,
+		SeverityLevel: SeverityWarning,
+		URL:           TranslatedString{{Text: "https://example.com/alerts/1"}},
+		HeaderText: TranslatedString{
+			{Text: "Detour on route 1", Language: "en"},
+			{Text: "Déviation sur la ligne 1", Language: "fr"},
+		},
+		DescriptionText: TranslatedString{{Text: "Buses are detoured due to maintenance."}},
+		CauseDetail:     TranslatedString{{Text: "Track work", Language: "en"}},
+		EffectDetail:    TranslatedString{{Text: "Stops skipped", Language: "en"}},
+	}
+	if got := feed.Entities[0].Alert; !reflect.DeepEqual(got, want) {
+		t.Errorf("Alert = %+v, want %+v", got, want)
+	}
+
+	// Unknown causes and effects are ignored
+	want = &Alert{
+		Cause:      CauseUnknown,
+		Effect:     EffectUnknown,
+		HeaderText: TranslatedString{{Text: "Service change"}},
+	}
+	if got := feed.Entities[1].Alert; !reflect.DeepEqual(got, want) {
+		t.Errorf("Alert = %+v, want %+v", got, want)
+	}
+}
+
+func TestAlert_IsActiveAt(t *testing.T) {
+	start := time.Date(2023, 11, 14, 8, 0, 0, 0, time.UTC)
+	a := &Alert{
+		ActivePeriods: []TimeRange{
+			{Start: start, End: start.Add(time.Hour)},
+			{Start: start.Add(24 * time.Hour)},
+		},
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"before", start.Add(-time.Second), false},
+		{"start", start, true},
+		{"end", start.Add(time.Hour), true},
+		{"between", start.Add(2 * time.Hour), false},
+		{"open-ended", start.Add(48 * time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.IsActiveAt(tt.t); got != tt.want {
+				t.Errorf("Alert.IsActiveAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !(&Alert{}).IsActiveAt(start) {
+		t.Errorf("Alert.IsActiveAt() without active periods = false, want true")
+	}
+}
+
+func TestTranslatedString_Text(t *testing.T) {
+	s := TranslatedString{
+		{Text: "Detour", Language: "en"},
+		{Text: "Déviation", Language: "fr"},
+	}
+
+	tests := []struct {
+		name     string
+		s        TranslatedString
+		language string
+		want     string
+	}{
+		{"exact", s, "fr", "Déviation"},
+		{"case insensitive", s, "EN", "Detour"},
+		{"first", s, "de", "Detour"},
+		{"default", append(TranslatedString{{Text: "Umleitung", Language: "de"}}, Translation{Text: "Detour"}), "es", "Detour"},
+		{"empty", nil, "en", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Text(tt.language); got != tt.want {
+				t.Errorf("TranslatedString.Text() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package realtime
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+// A Departure is a single departure from a stop, as shown on a departure
+// board.
+type Departure struct {
+	// Trip is the static trip, or nil if the trip was added and isn't in the
+	// static feed.
+	Trip     *gtfs.Trip
+	Route    *gtfs.Route
+	Headsign string
+
+	// Stop is the stop from which the trip departs. If a station was
+	// queried, it is one of the stops within the station.
+	Stop *gtfs.Stop
+
+	// ServiceDate is midnight at the start of the service date of the trip
+	// instance, in the agency's timezone.
+	ServiceDate time.Time
+
+	// Scheduled is the scheduled departure time, or zero if the trip was
+	// added without one.
+	Scheduled time.Time
+
+	// Predicted is the predicted departure time, or zero if there is no
+	// realtime information for the departure.
+	Predicted time.Time
+
+	Canceled bool
+	Skipped  bool
+
+	// Prediction is the realtime prediction for the trip instance, or nil if
+	// there is none.
+	Prediction *TripPrediction
+}
+
+// Time returns the predicted departure time of d if there is one, or the
+// scheduled departure time otherwise.
+func (d *Departure) Time() time.Time {
+	if !d.Predicted.IsZero() {
+		return d.Predicted
+	}
+
+	return d.Scheduled
+}
+
+// Delay returns the difference between the predicted and scheduled departure
+// times of d, or zero if either is unknown.
+func (d *Departure) Delay() time.Duration {
+	if d.Predicted.IsZero() || d.Scheduled.IsZero() {
+		return 0
+	}
+
+	return d.Predicted.Sub(d.Scheduled)
+}
+
+// Departures returns all departures from stop that are expected between from
+// (inclusive) and from+window (exclusive), in order of their expected times.
+// Predicted times are used where available, and scheduled times otherwise.
+//
+// As in gtfs.GTFS.StopDepartures, only stop times at which passengers may
+// board are included, the final stop of each trip is excluded, and if stop is
+// a station, departures from all stops within it are included. Canceled trips
+// and skipped stops are included, so that riders can be informed of them,
+// but deleted trips are not.
+func (s *Schedule) Departures(stop *gtfs.Stop, from time.Time, window time.Duration) ([]*Departure, error) {
+	until := from.Add(window)
+
+	// Include the previous service day, whose trips may continue after
+	// midnight
+	first := from.In(s.location).AddDate(0, 0, -1)
+	last := until.In(s.location)
+	date := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, s.location)
+	lastDate := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, s.location)
+
+	var res []*Departure
+	include := func(d *Departure) {
+		if d.Prediction != nil && d.Prediction.Descriptor.ScheduleRelationship == TripDeleted {
+			return
+		}
+
+		t := d.Time()
+		if !t.Before(from) && t.Before(until) {
+			res = append(res, d)
+		}
+	}
+
+	for ; !date.After(lastDate); date = date.AddDate(0, 0, 1) {
+		start := serviceDayStart(date)
+		dateKey := date.Format(dateFormat)
+
+		for _, t := range s.static.Trips {
+			if t.Service == nil || !t.Service.IsActiveOn(date) {
+				continue
+			}
+
+			var offsets []time.Duration
+			for i, st := range t.Stops {
+				if i == len(t.Stops)-1 || st.PickupType == gtfs.PickupTypeNone || st.DepartureTime == "" || !servesStop(st.Stop, stop) {
+					continue
+				}
+
+				dep, err := st.DepartureOffset()
+				if err != nil {
+					return nil, err
+				}
+
+				if offsets == nil {
+					if offsets, err = t.InstanceOffsets(); err != nil {
+						return nil, err
+					}
+				}
+
+				for _, offset := range offsets {
+					d := &Departure{
+						Trip:        t,
+						Route:       t.Route,
+						Headsign:    headsign(t, st),
+						Stop:        st.Stop,
+						ServiceDate: date,
+						Scheduled:   start.Add(dep + offset),
+					}
+
+					if p := s.predictions[tripInstance{tripID: t.ID, date: dateKey, offset: offset}]; p != nil {
+						sp := p.Stops[i]
+						d.Prediction = p
+						d.Stop = sp.Stop
+						d.Predicted = sp.PredictedDeparture
+						d.Canceled = p.Canceled()
+						d.Skipped = sp.Skipped
+					}
+
+					include(d)
+				}
+			}
+		}
+	}
+
+	for _, p := range s.predictions {
+		if p.Trip != nil {
+			continue
+		}
+
+		for i, sp := range p.Stops {
+			if i == len(p.Stops)-1 || !servesStop(sp.Stop, stop) {
+				continue
+			}
+
+			include(&Departure{
+				Route:       p.Route,
+				Stop:        sp.Stop,
+				ServiceDate: p.ServiceDate,
+				Scheduled:   sp.ScheduledDeparture,
+				Predicted:   sp.PredictedDeparture,
+				Canceled:    p.Canceled(),
+				Skipped:     sp.Skipped,
+				Prediction:  p,
+			})
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Time().Before(res[j].Time())
+	})
+
+	return res, nil
+}
+
+// servesStop returns whether a vehicle at s serves stop, either because it is
+// stop or because it is within stop, which is a station.
+func servesStop(s, stop *gtfs.Stop) bool {
+	if s == nil {
+		return false
+	}
+
+	return s == stop || (s.ParentStation == stop && stop.LocationType == gtfs.LocationTypeStation)
+}
+
+// headsign returns the headsign shown for t at st.
+func headsign(t *gtfs.Trip, st *gtfs.StopTime) string {
+	if st.Headsign != "" {
+		return st.Headsign
+	}
+
+	return t.Headsign
+}
@@ -0,0 +1,122 @@
+package realtime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Departures(t *testing.T) {
+	s := newTestSchedule(t)
+
+	err := s.Apply(newTestFeed(IncrementalityFullDataset, map[string]*TripUpdate{
+		"delayed": {
+			Trip: TripDescriptor{TripID: "t1"},
+			StopTimeUpdates: []*StopTimeUpdate{
+				{StopSequence: uint32Ptr(1), Departure: delayEvent(3 * time.Minute), AssignedStopID: "a2"},
+				{StopSequence: uint32Ptr(2), ScheduleRelationship: StopTimeSkipped},
+			},
+		},
+		"canceled": {
+			Trip: TripDescriptor{TripID: "t2", ScheduleRelationship: TripCanceled},
+		},
+		"deleted": {
+			Trip: TripDescriptor{TripID: "freq", StartTime: "10:00:00", ScheduleRelationship: TripDeleted},
+		},
+		"added": {
+			Trip: TripDescriptor{TripID: "extra", RouteID: "r1", ScheduleRelationship: TripAdded},
+			StopTimeUpdates: []*StopTimeUpdate{
+				{StopID: "b", Departure: &StopTimeEvent{Time: scheduleTestTime(9, 45)}},
+				{StopID: "d", Arrival: &StopTimeEvent{Time: scheduleTestTime(10, 0)}},
+			},
+		},
+	}))
+	if err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	// describe returns a summary of each departure
+	describe := func(deps []*Departure) []string {
+		var res []string
+		for _, d := range deps {
+			trip := "added"
+			if d.Trip != nil {
+				trip = d.Trip.ID
+			}
+
+			desc := trip + "@" + d.Stop.ID + " " + d.Time().In(scheduleTestLocation).Format("15:04")
+			if d.Delay() != 0 {
+				desc += " +" + d.Delay().String()
+			}
+
+			if d.Canceled {
+				desc += " canceled"
+			}
+
+			if d.Skipped {
+				desc += " skipped"
+			}
+
+			res = append(res, desc)
+		}
+
+		return res
+	}
+
+	tests := []struct {
+		name   string
+		stop   string
+		from   time.Time
+		window time.Duration
+		want   []string
+	}{
+		{
+			name:   "station",
+			stop:   "station",
+			from:   scheduleTestTime(8, 0),
+			window: 3 * time.Hour,
+			want: []string{
+				"t1@a2 08:03 +3m0s",
+				"t2@a 09:00 canceled",
+				"freq@a 10:30",
+			},
+		},
+		{
+			name:   "skipped and added",
+			stop:   "b",
+			from:   scheduleTestTime(8, 0),
+			window: 2 * time.Hour,
+			want: []string{
+				"t1@b 08:10 skipped",
+				"t2@b 09:10 canceled",
+				"added@b 09:45",
+			},
+		},
+		{
+			name:   "predicted time outside window",
+			stop:   "a",
+			from:   scheduleTestTime(7, 0),
+			window: time.Hour + 2*time.Minute,
+			want:   nil,
+		},
+		{
+			name:   "previous service day",
+			stop:   "b",
+			from:   time.Date(2023, 11, 15, 0, 0, 0, 0, scheduleTestLocation),
+			window: 15 * time.Minute,
+			want:   []string{"late@b 00:10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps, err := s.Departures(s.stopsByID[tt.stop], tt.from, tt.window)
+			if err != nil {
+				t.Fatalf("Schedule.Departures() error = %v", err)
+			}
+
+			if got := describe(deps); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Schedule.Departures() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package realtime
+
+import (
+	"github.com/dpearson/gtfs"
+)
+
+// A TripDescriptor identifies a single instance of a trip.
+//
+// Trips with absolute times are normally identified by TripID alone.
+// Frequency-based trips also require StartTime and StartDate, and trips that
+// aren't in the static feed may be identified by RouteID, DirectionID,
+// StartTime and StartDate instead.
+type TripDescriptor struct {
+	TripID      string
+	RouteID     string
+	DirectionID *uint32
+
+	// StartTime is the scheduled start time of the trip instance, in the
+	// same format as times in stop_times.txt.
+	StartTime string
+
+	// StartDate is the service date of the trip instance, in the YYYYMMDD
+	// format.
+	StartDate string
+
+	ScheduleRelationship TripScheduleRelationship
+}
+
+// TripScheduleRelationship indicates the relationship between a trip instance
+// and the static schedule.
+type TripScheduleRelationship int
+
+const (
+	// TripScheduled indicates that the trip is running in accordance with
+	// its schedule, possibly with delays.
+	TripScheduled TripScheduleRelationship = iota
+
+	// TripAdded indicates that the trip was added in addition to the
+	// schedule.
+	TripAdded
+
+	// TripUnscheduled indicates that the trip is running without an
+	// associated schedule.
+	TripUnscheduled
+
+	// TripCanceled indicates that the scheduled trip was removed, and that
+	// riders should be informed of its cancellation.
+	TripCanceled
+
+	// TripReplacement indicates that the trip replaces a scheduled trip with
+	// new stop times.
+	TripReplacement
+
+	// TripDuplicated indicates that the trip is a copy of a scheduled trip
+	// running at a different time.
+	TripDuplicated
+
+	// TripDeleted indicates that the scheduled trip was removed and should
+	// not be shown to riders.
+	TripDeleted
+
+	// TripNew indicates that the trip is a new trip that isn't in the
+	// schedule, replacing the deprecated use of TripAdded for such trips.
+	TripNew
+)
+
+// A VehicleDescriptor identifies a vehicle.
+type VehicleDescriptor struct {
+	ID                   string
+	Label                string
+	LicensePlate         string
+	WheelchairAccessible gtfs.WheelchairAccessible
+}
+
+func (t *TripDescriptor) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			t.TripID, err = d.string()
+		case 2:
+			t.StartTime, err = d.string()
+		case 3:
+			t.StartDate, err = d.string()
+		case 4:
+			var v uint64
+			v, err = d.uint64()
+			if r, ok := parseTripScheduleRelationship(v); ok {
+				t.ScheduleRelationship = r
+			}
+		case 5:
+			t.RouteID, err = d.string()
+		case 6:
+			var v uint32
+			v, err = d.uint32()
+			t.DirectionID = &v
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func parseTripScheduleRelationship(v uint64) (TripScheduleRelationship, bool) {
+	switch v {
+	case 0:
+		return TripScheduled, true
+	case 1:
+		return TripAdded, true
+	case 2:
+		return TripUnscheduled, true
+	case 3:
+		return TripCanceled, true
+	case 5:
+		return TripReplacement, true
+	case 6:
+		return TripDuplicated, true
+	case 7:
+		return TripDeleted, true
+	case 8:
+		return TripNew, true
+	default:
+		return TripScheduled, false
+	}
+}
+
+func (v *VehicleDescriptor) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			v.ID, err = d.string()
+		case 2:
+			v.Label, err = d.string()
+		case 3:
+			v.LicensePlate, err = d.string()
+		case 4:
+			var val uint64
+			val, err = d.uint64()
+			if w, ok := parseWheelchairAccessible(val); ok {
+				v.WheelchairAccessible = w
+			}
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func parseWheelchairAccessible(v uint64) (gtfs.WheelchairAccessible, bool) {
+	switch v {
+	case 0, 1:
+		return gtfs.WheelchairAccessibleUnknown, true
+	case 2:
+		return gtfs.WheelchairAccessibleYes, true
+	case 3:
+		return gtfs.WheelchairAccessibleNo, true
+	default:
+		return gtfs.WheelchairAccessibleUnknown, false
+	}
+}
@@ -0,0 +1,16 @@
+// Package realtime provides functionality for reading GTFS-Realtime feeds.
+//
+// Feeds are decoded from the protocol buffer wire format without depending on
+// any packages outside of the standard library. Trip updates, vehicle positions
+// and service alerts are supported. Fields that aren't supported, including
+// extensions and experimental fields, are ignored, as are enum values that
+// aren't recognized, which leave fields with their default values.
+//
+// A Schedule applies trip updates to a static feed loaded with the gtfs
+// package, providing predicted arrival and departure times for trips and
+// departure boards for stops.
+//
+// Optional numeric fields whose zero values are meaningful are represented by
+// pointers, which are nil if the field is absent. Timestamps are converted to
+// UTC times, and are zero if absent.
+package realtime
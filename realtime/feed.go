@@ -0,0 +1,161 @@
+package realtime
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// A FeedMessage is the contents of a GTFS-Realtime feed.
+type FeedMessage struct {
+	Header   FeedHeader
+	Entities []*FeedEntity
+}
+
+// FeedHeader contains metadata about a feed.
+type FeedHeader struct {
+	Version        string
+	Incrementality Incrementality
+	Timestamp      time.Time
+	FeedVersion    string
+}
+
+// Incrementality indicates whether a feed contains all current realtime
+// information or only changes since previous messages.
+type Incrementality int
+
+const (
+	// IncrementalityFullDataset indicates that each message contains all
+	// current realtime information, replacing previous messages.
+	IncrementalityFullDataset Incrementality = iota
+
+	// IncrementalityDifferential indicates that each message only contains
+	// updates to previous messages.
+	IncrementalityDifferential
+)
+
+// A FeedEntity is a single update within a feed. Normally, exactly one of
+// TripUpdate, Vehicle and Alert is set; all are nil if the entity only contains
+// data that isn't supported.
+type FeedEntity struct {
+	ID        string
+	IsDeleted bool
+
+	TripUpdate *TripUpdate
+	Vehicle    *VehiclePosition
+	Alert      *Alert
+}
+
+// Parse decodes a GTFS-Realtime feed from its protocol buffer encoding.
+func Parse(data []byte) (*FeedMessage, error) {
+	res := &FeedMessage{}
+	err := decodeMessage(data, func(d *decoder) error {
+		switch d.field {
+		case 1:
+			if err := d.embedded(res.Header.decode); err != nil {
+				return fmt.Errorf("error parsing header: %v", err)
+			}
+		case 2:
+			e := &FeedEntity{}
+			if err := d.embedded(e.decode); err != nil {
+				return fmt.Errorf("error parsing entity %d: %v", len(res.Entities), err)
+			}
+
+			res.Entities = append(res.Entities, e)
+		default:
+			return d.skip()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Read reads all data from r and decodes it as a GTFS-Realtime feed.
+func Read(r io.Reader) (*FeedMessage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+func (h *FeedHeader) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			h.Version, err = d.string()
+		case 2:
+			var v uint64
+			v, err = d.uint64()
+			if i, ok := parseIncrementality(v); ok {
+				h.Incrementality = i
+			}
+		case 3:
+			h.Timestamp, err = d.timestamp()
+		case 4:
+			h.FeedVersion, err = d.string()
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func parseIncrementality(v uint64) (Incrementality, bool) {
+	switch v {
+	case 0:
+		return IncrementalityFullDataset, true
+	case 1:
+		return IncrementalityDifferential, true
+	default:
+		return IncrementalityFullDataset, false
+	}
+}
+
+func (e *FeedEntity) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			e.ID, err = d.string()
+		case 2:
+			e.IsDeleted, err = d.bool()
+		case 3:
+			if e.TripUpdate == nil {
+				e.TripUpdate = &TripUpdate{}
+			}
+			err = d.embedded(e.TripUpdate.decode)
+			if err != nil {
+				err = fmt.Errorf("error parsing trip update: %v", err)
+			}
+		case 4:
+			if e.Vehicle == nil {
+				e.Vehicle = &VehiclePosition{}
+			}
+			err = d.embedded(e.Vehicle.decode)
+			if err != nil {
+				err = fmt.Errorf("error parsing vehicle position: %v", err)
+			}
+		case 5:
+			if e.Alert == nil {
+				e.Alert = &Alert{}
+			}
+			err = d.embedded(e.Alert.decode)
+			if err != nil {
+				err = fmt.Errorf("error parsing alert: %v", err)
+			}
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
@@ -0,0 +1,106 @@
+package realtime
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func loadTestFeed(t *testing.T, name string) *FeedMessage {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("error reading %s: %v", name, err)
+	}
+
+	feed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(%s) error = %v", name, err)
+	}
+
+	return feed
+}
+
+func TestParse(t *testing.T) {
+	feed := loadTestFeed(t, "trip_updates.pb")
+
+	wantHeader := FeedHeader{
+		Version:        "2.0",
+		Incrementality: IncrementalityFullDataset,
+		Timestamp:      time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC),
+		FeedVersion:    "v42",
+	}
+	if feed.Header != wantHeader {
+		t.Errorf("Parse() header = %+v, want %+v", feed.Header, wantHeader)
+	}
+
+	var ids []string
+	for _, e := range feed.Entities {
+		ids = append(ids, e.ID)
+	}
+
+	if got, want := len(ids), 5; got != want {
+		t.Fatalf("Parse() returned %d entities (%v), want %d", got, ids, want)
+	}
+
+	deleted := feed.Entities[3]
+	if !deleted.IsDeleted || deleted.TripUpdate != nil || deleted.Vehicle != nil || deleted.Alert != nil {
+		t.Errorf("Parse() deleted entity = %+v", deleted)
+	}
+
+	// Entities with unsupported contents are kept, but empty
+	shape := feed.Entities[4]
+	if shape.ID != "shape" || shape.TripUpdate != nil || shape.Vehicle != nil || shape.Alert != nil {
+		t.Errorf("Parse() shape entity = %+v", shape)
+	}
+
+	vehicles := loadTestFeed(t, "vehicle_positions.pb")
+	if vehicles.Header.Incrementality != IncrementalityDifferential {
+		t.Errorf("Parse() incrementality = %v, want %v", vehicles.Header.Incrementality, IncrementalityDifferential)
+	}
+}
+
+func TestRead(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "alerts.pb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if len(feed.Entities) != 2 || feed.Entities[0].Alert == nil {
+		t.Errorf("Read() = %+v", feed)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated key", []byte{0x80}},
+		{"truncated header", []byte{0x0a, 0x05, 0x0a, 0x03, '2'}},
+		{"field zero", []byte{0x00, 0x01}},
+		{"header wrong wire type", []byte{0x08, 0x01}},
+		{"entity id wrong wire type", []byte{0x12, 0x02, 0x08, 0x01}},
+		{"invalid wire type", []byte{0x0f}},
+		{"unexpected end of group", []byte{0x0c}},
+		{"unterminated group", []byte{0xc3, 0x3e, 0x08, 0x01}},
+		{"mismatched group", []byte{0xc3, 0x3e, 0xcc, 0x3e}},
+		{"trip descriptor wrong wire type", []byte{0x12, 0x04, 0x1a, 0x02, 0x08, 0x01}},
+		{"truncated fixed32", []byte{0x12, 0x07, 0x22, 0x05, 0x12, 0x03, 0x0d, 0x00, 0x00}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if feed, err := Parse(tt.data); err == nil {
+				t.Errorf("Parse() = %+v, want error", feed)
+			}
+		})
+	}
+}
@@ -0,0 +1,629 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+// dateFormat is the layout of dates in GTFS files, for use with time.Parse.
+const dateFormat = "20060102"
+
+// A Schedule combines a static GTFS feed with realtime trip updates, providing
+// predicted arrival and departure times for individual trip instances.
+type Schedule struct {
+	static   *gtfs.GTFS
+	location *time.Location
+
+	tripsByID  map[string]*gtfs.Trip
+	stopsByID  map[string]*gtfs.Stop
+	routesByID map[string]*gtfs.Route
+
+	predictions map[tripInstance]*TripPrediction
+
+	// entities contains the trip instance updated by each entity, and owners
+	// the entity that updated each trip instance
+	entities map[string]tripInstance
+	owners   map[tripInstance]string
+}
+
+// A tripInstance identifies a single run of a trip on a service day. Runs of
+// frequency-based trips are distinguished by their offsets.
+type tripInstance struct {
+	tripID string
+	date   string
+	offset time.Duration
+}
+
+// A TripPrediction contains the realtime state of a single trip instance.
+type TripPrediction struct {
+	// Trip is the static trip, or nil if the trip was added and isn't in the
+	// static feed.
+	Trip  *gtfs.Trip
+	Route *gtfs.Route
+
+	// ServiceDate is midnight at the start of the service date of the trip
+	// instance, in the agency's timezone.
+	ServiceDate time.Time
+
+	// Offset is the amount of time added to the times of Trip's stop times
+	// for this instance, which is non-zero for runs of frequency-based
+	// trips.
+	Offset time.Duration
+
+	Descriptor TripDescriptor
+	Vehicle    *VehicleDescriptor
+	Timestamp  time.Time
+
+	// Stops contains a prediction for each of Trip's stop times, or for each
+	// stop served by an added trip.
+	Stops []*StopTimePrediction
+}
+
+// Canceled returns whether p's trip instance was canceled or deleted.
+func (p *TripPrediction) Canceled() bool {
+	return p.Descriptor.ScheduleRelationship == TripCanceled || p.Descriptor.ScheduleRelationship == TripDeleted
+}
+
+// Added returns whether p's trip instance was added in addition to the static
+// schedule.
+func (p *TripPrediction) Added() bool {
+	return p.Descriptor.ScheduleRelationship == TripAdded || p.Descriptor.ScheduleRelationship == TripNew
+}
+
+// A StopTimePrediction contains the scheduled and predicted times of a trip
+// instance at a single stop. Times are zero if unknown.
+type StopTimePrediction struct {
+	// StopTime is the static stop time, or nil for stops served by added
+	// trips.
+	StopTime *gtfs.StopTime
+
+	// Stop is the stop served, which differs from StopTime's stop if the
+	// trip was assigned to a different stop.
+	Stop *gtfs.Stop
+
+	ScheduledArrival   time.Time
+	ScheduledDeparture time.Time
+	PredictedArrival   time.Time
+	PredictedDeparture time.Time
+
+	Skipped bool
+}
+
+// Arrival returns the predicted arrival time of p if there is one, or the
+// scheduled arrival time otherwise.
+func (p *StopTimePrediction) Arrival() time.Time {
+	if !p.PredictedArrival.IsZero() {
+		return p.PredictedArrival
+	}
+
+	return p.ScheduledArrival
+}
+
+// Departure returns the predicted departure time of p if there is one, or the
+// scheduled departure time otherwise.
+func (p *StopTimePrediction) Departure() time.Time {
+	if !p.PredictedDeparture.IsZero() {
+		return p.PredictedDeparture
+	}
+
+	return p.ScheduledDeparture
+}
+
+// NewSchedule returns a Schedule for g with no realtime information. Times are
+// interpreted in the timezone of g's first agency, or UTC if it has none.
+func NewSchedule(g *gtfs.GTFS) (*Schedule, error) {
+	loc := time.UTC
+	if len(g.Agencies) > 0 && g.Agencies[0].Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(g.Agencies[0].Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid agency timezone: %v", err)
+		}
+	}
+
+	s := &Schedule{
+		static:      g,
+		location:    loc,
+		tripsByID:   make(map[string]*gtfs.Trip, len(g.Trips)),
+		stopsByID:   make(map[string]*gtfs.Stop, len(g.Stops)),
+		routesByID:  make(map[string]*gtfs.Route, len(g.Routes)),
+		predictions: map[tripInstance]*TripPrediction{},
+		entities:    map[string]tripInstance{},
+		owners:      map[tripInstance]string{},
+	}
+
+	for _, t := range g.Trips {
+		s.tripsByID[t.ID] = t
+	}
+
+	for _, stop := range g.Stops {
+		s.stopsByID[stop.ID] = stop
+	}
+
+	for _, r := range g.Routes {
+		s.routesByID[r.ID] = r
+	}
+
+	return s, nil
+}
+
+// Apply updates s with the trip updates in feed.
+//
+// If feed contains a full dataset, all previous predictions are replaced.
+// Otherwise, predictions are updated for each entity in feed, and removed for
+// deleted entities and entities without trip updates.
+//
+// Trip updates that can't be matched to a trip instance operating in the
+// static schedule are skipped, and an error describing each of them is
+// returned. So are trip updates for a trip instance that another entity has
+// already updated, until that entity is deleted. Skipped updates leave any
+// previous prediction from the same entity in place. All other trip updates
+// are still applied.
+func (s *Schedule) Apply(feed *FeedMessage) error {
+	if feed.Header.Incrementality == IncrementalityFullDataset {
+		s.predictions = map[tripInstance]*TripPrediction{}
+		s.entities = map[string]tripInstance{}
+		s.owners = map[tripInstance]string{}
+	}
+
+	timestamp := feed.Header.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	var errs []error
+	for _, e := range feed.Entities {
+		if e.IsDeleted || e.TripUpdate == nil {
+			s.remove(e.ID)
+			continue
+		}
+
+		p, key, err := s.predict(e.TripUpdate, timestamp)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entity %s: %v", e.ID, err))
+			continue
+		}
+
+		if owner, ok := s.owners[key]; ok && owner != e.ID {
+			errs = append(errs, fmt.Errorf("entity %s: trip instance already updated by entity %s", e.ID, owner))
+			continue
+		}
+
+		s.remove(e.ID)
+		s.predictions[key] = p
+		s.entities[e.ID] = key
+		s.owners[key] = e.ID
+	}
+
+	return errors.Join(errs...)
+}
+
+// remove removes the prediction from the entity with the specified ID, if
+// there is one.
+func (s *Schedule) remove(id string) {
+	key, ok := s.entities[id]
+	if !ok {
+		return
+	}
+
+	delete(s.predictions, key)
+	delete(s.entities, id)
+	delete(s.owners, key)
+}
+
+// Prediction returns the prediction for the run of trip on the service day
+// beginning on date with the specified offset, which is zero for trips with
+// absolute times and one of the trip's instance offsets otherwise, or nil if
+// there is no realtime information for it.
+func (s *Schedule) Prediction(trip *gtfs.Trip, date time.Time, offset time.Duration) *TripPrediction {
+	return s.predictions[tripInstance{tripID: trip.ID, date: date.Format(dateFormat), offset: offset}]
+}
+
+// predict returns a prediction for the trip instance updated by u.
+func (s *Schedule) predict(u *TripUpdate, timestamp time.Time) (*TripPrediction, tripInstance, error) {
+	td := u.Trip
+	if td.ScheduleRelationship == TripDuplicated {
+		return nil, tripInstance{}, errors.New("duplicated trips aren't supported")
+	}
+
+	if td.ScheduleRelationship == TripNew || (td.ScheduleRelationship == TripAdded && s.tripsByID[td.TripID] == nil) {
+		return s.predictAdded(u, timestamp)
+	}
+
+	trip, date, offset, err := s.match(&td, timestamp)
+	if err != nil {
+		return nil, tripInstance{}, err
+	}
+
+	p := &TripPrediction{
+		Trip:        trip,
+		Route:       trip.Route,
+		ServiceDate: date,
+		Offset:      offset,
+		Descriptor:  td,
+		Vehicle:     u.Vehicle,
+		Timestamp:   u.Timestamp,
+	}
+
+	start := serviceDayStart(date)
+	for _, st := range trip.Stops {
+		sp := &StopTimePrediction{
+			StopTime: st,
+			Stop:     st.Stop,
+		}
+
+		if arr, err := st.ArrivalOffset(); err == nil {
+			sp.ScheduledArrival = start.Add(arr + offset)
+		}
+
+		if dep, err := st.DepartureOffset(); err == nil {
+			sp.ScheduledDeparture = start.Add(dep + offset)
+		}
+
+		p.Stops = append(p.Stops, sp)
+	}
+
+	if !p.Canceled() {
+		if err := s.propagate(p, u); err != nil {
+			return nil, tripInstance{}, err
+		}
+	}
+
+	key := tripInstance{tripID: trip.ID, date: date.Format(dateFormat), offset: offset}
+
+	return p, key, nil
+}
+
+// propagate sets the predicted times of the stops in p from the stop time
+// updates in u.
+//
+// As described in the GTFS-Realtime specification, the delay at each updated
+// stop applies to all subsequent stops until the next update, with the
+// departure delay of a stop becoming the arrival delay of the next. An arrival
+// delay also applies to the departure from the same stop if no departure delay
+// is given. Stops before the first update use the trip's delay, if any. Stops
+// with no data have no predictions, and neither do subsequent stops until the
+// next update. Skipped stops have no predictions, but delays propagate past
+// them.
+func (s *Schedule) propagate(p *TripPrediction, u *TripUpdate) error {
+	updates := make([]*StopTimeUpdate, len(p.Stops))
+	start := 0
+	for _, stu := range u.StopTimeUpdates {
+		i := p.stopIndex(stu, start)
+		if i < 0 {
+			return fmt.Errorf("no stop matches update for stop %s", stopTimeUpdateID(stu))
+		}
+
+		updates[i] = stu
+		start = i + 1
+	}
+
+	delay := u.Delay
+	for i, sp := range p.Stops {
+		arrDelay, depDelay := delay, delay
+
+		var arrTime, depTime time.Time
+		if stu := updates[i]; stu != nil {
+			if stop := s.stopsByID[stu.AssignedStopID]; stop != nil {
+				sp.Stop = stop
+			}
+
+			switch stu.ScheduleRelationship {
+			case StopTimeNoData, StopTimeUnscheduled:
+				arrDelay, depDelay, delay = nil, nil, nil
+			case StopTimeSkipped:
+				sp.Skipped = true
+			default:
+				if d := eventDelay(stu.Arrival, sp.ScheduledArrival); d != nil {
+					arrDelay, depDelay = d, d
+				}
+
+				if d := eventDelay(stu.Departure, sp.ScheduledDeparture); d != nil {
+					depDelay = d
+				}
+
+				if stu.Arrival != nil {
+					arrTime = stu.Arrival.Time
+				}
+
+				if stu.Departure != nil {
+					depTime = stu.Departure.Time
+				}
+
+				delay = depDelay
+			}
+		}
+
+		if sp.Skipped {
+			continue
+		}
+
+		sp.PredictedArrival = predictedTime(arrTime, sp.ScheduledArrival, arrDelay)
+		sp.PredictedDeparture = predictedTime(depTime, sp.ScheduledDeparture, depDelay)
+
+		// Vehicles can't depart before they arrive
+		if !sp.PredictedDeparture.IsZero() && sp.PredictedDeparture.Before(sp.PredictedArrival) {
+			sp.PredictedDeparture = sp.PredictedArrival
+		}
+	}
+
+	return nil
+}
+
+// stopIndex returns the index of the stop in p updated by u, searching from
+// start when matching by stop ID, or -1 if there is none.
+func (p *TripPrediction) stopIndex(u *StopTimeUpdate, start int) int {
+	for i, sp := range p.Stops {
+		if u.StopSequence != nil {
+			if sp.StopTime.Sequence == uint64(*u.StopSequence) {
+				return i
+			}
+		} else if i >= start && sp.StopTime.Stop != nil && sp.StopTime.Stop.ID == u.StopID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// predictAdded returns a prediction for a trip that isn't in the static
+// schedule. Its stops are those in the stop time updates in u.
+func (s *Schedule) predictAdded(u *TripUpdate, timestamp time.Time) (*TripPrediction, tripInstance, error) {
+	td := u.Trip
+	if td.TripID == "" {
+		return nil, tripInstance{}, errors.New("added trip has no trip ID")
+	}
+
+	date, err := s.serviceDate(&td, timestamp, nil)
+	if err != nil {
+		return nil, tripInstance{}, err
+	}
+
+	p := &TripPrediction{
+		Route:       s.routesByID[td.RouteID],
+		ServiceDate: date,
+		Descriptor:  td,
+		Vehicle:     u.Vehicle,
+		Timestamp:   u.Timestamp,
+	}
+
+	for _, stu := range u.StopTimeUpdates {
+		stop := s.stopsByID[stu.StopID]
+		if assigned := s.stopsByID[stu.AssignedStopID]; assigned != nil {
+			stop = assigned
+		}
+
+		if stop == nil {
+			return nil, tripInstance{}, fmt.Errorf("unknown stop: %s", stu.StopID)
+		}
+
+		sp := &StopTimePrediction{
+			Stop:    stop,
+			Skipped: stu.ScheduleRelationship == StopTimeSkipped,
+		}
+
+		if stu.Arrival != nil {
+			sp.ScheduledArrival = stu.Arrival.ScheduledTime
+			sp.PredictedArrival = predictedTime(stu.Arrival.Time, stu.Arrival.ScheduledTime, stu.Arrival.Delay)
+		}
+
+		if stu.Departure != nil {
+			sp.ScheduledDeparture = stu.Departure.ScheduledTime
+			sp.PredictedDeparture = predictedTime(stu.Departure.Time, stu.Departure.ScheduledTime, stu.Departure.Delay)
+		}
+
+		if sp.PredictedDeparture.IsZero() {
+			sp.PredictedDeparture = sp.PredictedArrival
+		}
+
+		if sp.PredictedArrival.IsZero() {
+			sp.PredictedArrival = sp.PredictedDeparture
+		}
+
+		p.Stops = append(p.Stops, sp)
+	}
+
+	key := tripInstance{tripID: td.TripID, date: date.Format(dateFormat)}
+
+	return p, key, nil
+}
+
+// match returns the static trip instance identified by td, along with its
+// service date and offset.
+//
+// Trips are matched by trip ID if it is set, and otherwise by route, direction
+// and start time. Frequency-based trips require a start time to identify the
+// run.
+func (s *Schedule) match(td *TripDescriptor, timestamp time.Time) (*gtfs.Trip, time.Time, time.Duration, error) {
+	var startTime time.Duration
+	if td.StartTime != "" {
+		var err error
+		startTime, err = gtfs.ParseTime(td.StartTime)
+		if err != nil {
+			return nil, time.Time{}, 0, fmt.Errorf("invalid start time: %s", td.StartTime)
+		}
+	}
+
+	if td.TripID != "" {
+		trip := s.tripsByID[td.TripID]
+		if trip == nil {
+			return nil, time.Time{}, 0, fmt.Errorf("unknown trip: %s", td.TripID)
+		}
+
+		date, err := s.serviceDate(td, timestamp, trip)
+		if err != nil {
+			return nil, time.Time{}, 0, err
+		}
+
+		if trip.Service == nil || !trip.Service.IsActiveOn(date) {
+			return nil, time.Time{}, 0, fmt.Errorf("trip %s doesn't operate on %s", trip.ID, date.Format(dateFormat))
+		}
+
+		if trip.AbsoluteTimes {
+			return trip, date, 0, nil
+		}
+
+		if td.StartTime == "" {
+			return nil, time.Time{}, 0, fmt.Errorf("no start time for frequency-based trip: %s", trip.ID)
+		}
+
+		offset, ok := runOffset(trip, startTime)
+		if !ok {
+			return nil, time.Time{}, 0, fmt.Errorf("trip %s has no run starting at %s", trip.ID, td.StartTime)
+		}
+
+		return trip, date, offset, nil
+	}
+
+	if td.RouteID == "" || td.StartTime == "" {
+		return nil, time.Time{}, 0, errors.New("trip descriptor has neither a trip ID nor a route and start time")
+	}
+
+	for _, trip := range s.static.Trips {
+		if trip.Route == nil || trip.Route.ID != td.RouteID || trip.Service == nil {
+			continue
+		}
+
+		if td.DirectionID != nil && trip.DirectionID != strconv.FormatUint(uint64(*td.DirectionID), 10) {
+			continue
+		}
+
+		date, err := s.serviceDate(td, timestamp, trip)
+		if err != nil {
+			return nil, time.Time{}, 0, err
+		}
+
+		if !trip.Service.IsActiveOn(date) {
+			continue
+		}
+
+		if offset, ok := runOffset(trip, startTime); ok {
+			return trip, date, offset, nil
+		}
+	}
+
+	return nil, time.Time{}, 0, fmt.Errorf("no trip on route %s starts at %s", td.RouteID, td.StartTime)
+}
+
+// runOffset returns the offset of the run of trip that departs from its first
+// stop at startTime, and whether there is such a run.
+//
+// Runs of frequency-based trips without exact times may start at any time
+// within the period of one of the trip's frequencies, so the offset of the
+// scheduled run in that period departing closest to startTime is returned for
+// them.
+func runOffset(trip *gtfs.Trip, startTime time.Duration) (time.Duration, bool) {
+	if len(trip.Stops) == 0 {
+		return 0, false
+	}
+
+	first, err := trip.Stops[0].DepartureOffset()
+	if err != nil {
+		return 0, false
+	}
+
+	if trip.AbsoluteTimes {
+		return 0, first == startTime
+	}
+
+	for _, f := range trip.Frequencies {
+		start, startErr := gtfs.ParseTime(f.StartTime)
+		end, endErr := gtfs.ParseTime(f.EndTime)
+		if startErr != nil || endErr != nil || f.HeadwaySeconds == 0 || startTime < start || startTime >= end {
+			continue
+		}
+
+		headway := time.Duration(f.HeadwaySeconds) * time.Second
+		run := start + (startTime-start)/headway*headway
+		if f.ExactTimes {
+			if run != startTime {
+				continue
+			}
+
+			return run - first, true
+		}
+
+		if next := run + headway; next < end && next-startTime < startTime-run {
+			run = next
+		}
+
+		return run - first, true
+	}
+
+	return 0, false
+}
+
+// serviceDate returns the service date of the trip instance identified by td.
+// If td has no start date, the date of timestamp is used, unless trip only
+// operates on the previous service day (i.e. it started before midnight).
+func (s *Schedule) serviceDate(td *TripDescriptor, timestamp time.Time, trip *gtfs.Trip) (time.Time, error) {
+	if td.StartDate != "" {
+		date, err := time.ParseInLocation(dateFormat, td.StartDate, s.location)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid start date: %s", td.StartDate)
+		}
+
+		return date, nil
+	}
+
+	t := timestamp.In(s.location)
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.location)
+	if trip != nil && trip.Service != nil && !trip.Service.IsActiveOn(date) && trip.Service.IsActiveOn(date.AddDate(0, 0, -1)) {
+		return date.AddDate(0, 0, -1), nil
+	}
+
+	return date, nil
+}
+
+// serviceDayStart returns the time from which times in stop_times.txt are
+// measured on the service day beginning on date, which is noon minus 12 hours
+// to account for daylight saving time changes.
+func serviceDayStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, date.Location()).Add(-12 * time.Hour)
+}
+
+// eventDelay returns the delay of e relative to scheduled, or nil if it is
+// unknown.
+func eventDelay(e *StopTimeEvent, scheduled time.Time) *time.Duration {
+	if e == nil {
+		return nil
+	}
+
+	if e.Delay != nil {
+		return e.Delay
+	}
+
+	if e.Time.IsZero() || scheduled.IsZero() {
+		return nil
+	}
+
+	d := e.Time.Sub(scheduled)
+
+	return &d
+}
+
+// predictedTime returns explicit if it is set, or scheduled adjusted by delay
+// otherwise. The zero time is returned if neither is known.
+func predictedTime(explicit, scheduled time.Time, delay *time.Duration) time.Time {
+	if !explicit.IsZero() {
+		return explicit
+	}
+
+	if scheduled.IsZero() || delay == nil {
+		return time.Time{}
+	}
+
+	return scheduled.Add(*delay)
+}
+
+func stopTimeUpdateID(u *StopTimeUpdate) string {
+	if u.StopSequence != nil {
+		return fmt.Sprintf("sequence %d", *u.StopSequence)
+	}
+
+	return u.StopID
+}
@@ -0,0 +1,551 @@
+package realtime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+var scheduleTestLocation, _ = time.LoadLocation("America/New_York")
+
+// scheduleTestTime returns the specified time on 2023-11-14, a Tuesday, in the
+// test feed's timezone.
+func scheduleTestTime(hour, minute int) time.Time {
+	return time.Date(2023, 11, 14, hour, minute, 0, 0, scheduleTestLocation)
+}
+
+func newScheduleTestTrip(id string, route *gtfs.Route, service *gtfs.Service, stops []*gtfs.Stop, times []string) *gtfs.Trip {
+	t := &gtfs.Trip{
+		ID:            id,
+		Route:         route,
+		Service:       service,
+		DirectionID:   "0",
+		Headsign:      "Delta",
+		AbsoluteTimes: true,
+	}
+
+	for i, stop := range stops {
+		t.Stops = append(t.Stops, &gtfs.StopTime{
+			Stop:          stop,
+			ArrivalTime:   times[i],
+			DepartureTime: times[i],
+			Sequence:      uint64(i + 1),
+		})
+	}
+
+	return t
+}
+
+func newScheduleTestFeed() *gtfs.GTFS {
+	station := &gtfs.Stop{ID: "station", LocationType: gtfs.LocationTypeStation}
+	a := &gtfs.Stop{ID: "a", ParentStation: station}
+	a2 := &gtfs.Stop{ID: "a2", ParentStation: station}
+	b := &gtfs.Stop{ID: "b"}
+	c := &gtfs.Stop{ID: "c"}
+	d := &gtfs.Stop{ID: "d"}
+	stops := []*gtfs.Stop{a, b, c, d}
+
+	route := &gtfs.Route{ID: "r1"}
+	weekday := &gtfs.Service{ID: "weekday", Monday: true, Tuesday: true, Wednesday: true, Thursday: true, Friday: true, StartDate: "20230101", EndDate: "20231231"}
+	tuesday := &gtfs.Service{ID: "tuesday", Tuesday: true, StartDate: "20230101", EndDate: "20231231"}
+
+	t1 := newScheduleTestTrip("t1", route, weekday, stops, []string{"08:00:00", "08:10:00", "08:20:00", "08:30:00"})
+	t2 := newScheduleTestTrip("t2", route, weekday, stops, []string{"09:00:00", "09:10:00", "09:20:00", "09:30:00"})
+	late := newScheduleTestTrip("late", route, tuesday, []*gtfs.Stop{a, b, c}, []string{"23:50:00", "24:10:00", "24:20:00"})
+
+	freq := newScheduleTestTrip("freq", route, weekday, []*gtfs.Stop{a, b}, []string{"00:00:00", "00:10:00"})
+	freq.DirectionID = "1"
+	freq.AbsoluteTimes = false
+	freq.Frequencies = []gtfs.Frequency{{StartTime: "10:00:00", EndTime: "11:00:00", HeadwaySeconds: 1800, ExactTimes: true}}
+
+	return &gtfs.GTFS{
+		Agencies: []*gtfs.Agency{{ID: "agency", Timezone: "America/New_York"}},
+		Stops:    []*gtfs.Stop{station, a, a2, b, c, d},
+		Routes:   []*gtfs.Route{route},
+		Services: []*gtfs.Service{weekday, tuesday},
+		Trips:    []*gtfs.Trip{t1, t2, late, freq},
+	}
+}
+
+func newTestSchedule(t *testing.T) *Schedule {
+	t.Helper()
+
+	s, err := NewSchedule(newScheduleTestFeed())
+	if err != nil {
+		t.Fatalf("NewSchedule() error = %v", err)
+	}
+
+	return s
+}
+
+func newTestFeed(incrementality Incrementality, updates map[string]*TripUpdate) *FeedMessage {
+	feed := &FeedMessage{
+		Header: FeedHeader{
+			Version:        "2.0",
+			Incrementality: incrementality,
+			Timestamp:      scheduleTestTime(7, 55),
+		},
+	}
+
+	for id, u := range updates {
+		feed.Entities = append(feed.Entities, &FeedEntity{ID: id, TripUpdate: u})
+	}
+
+	return feed
+}
+
+func delayEvent(d time.Duration) *StopTimeEvent {
+	return &StopTimeEvent{Delay: &d}
+}
+
+// predictedTimes returns the predicted arrival and departure times of each stop
+// in p as HH:MM strings, with "-" for unknown times and "skipped" for skipped
+// stops.
+func predictedTimes(p *TripPrediction) []string {
+	format := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+
+		return t.In(scheduleTestLocation).Format("15:04")
+	}
+
+	var res []string
+	for _, sp := range p.Stops {
+		if sp.Skipped {
+			res = append(res, "skipped")
+			continue
+		}
+
+		res = append(res, format(sp.PredictedArrival)+"/"+format(sp.PredictedDeparture))
+	}
+
+	return res
+}
+
+func TestSchedule_Apply_propagation(t *testing.T) {
+	minute := time.Minute
+	tests := []struct {
+		name    string
+		update  *TripUpdate
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "departure delay propagates downstream",
+			update: &TripUpdate{
+				StopTimeUpdates: []*StopTimeUpdate{
+					{StopSequence: uint32Ptr(2), Departure: delayEvent(2 * time.Minute)},
+				},
+			},
+			want: []string{"-/-", "-/08:12", "08:22/08:22", "08:32/08:32"},
+		},
+		{
+			name: "arrival time applies to departure",
+			update: &TripUpdate{
+				StopTimeUpdates: []*StopTimeUpdate{
+					{StopID: "b", Arrival: &StopTimeEvent{Time: scheduleTestTime(8, 13)}},
+				},
+			},
+			want: []string{"-/-", "08:13/08:13", "08:23/08:23", "08:33/08:33"},
+		},
+		{
+			name: "trip delay and no data",
+			update: &TripUpdate{
+				Delay: &minute,
+				StopTimeUpdates: []*StopTimeUpdate{
+					{StopSequence: uint32Ptr(3), ScheduleRelationship: StopTimeNoData},
+				},
+			},
+			want: []string{"08:01/08:01", "08:11/08:11", "-/-", "-/-"},
+		},
+		{
+			name: "skipped stop",
+			update: &TripUpdate{
+				StopTimeUpdates: []*StopTimeUpdate{
+					{StopSequence: uint32Ptr(1), Departure: delayEvent(5 * time.Minute)},
+					{StopSequence: uint32Ptr(2), ScheduleRelationship: StopTimeSkipped},
+				},
+			},
+			want: []string{"-/08:05", "skipped", "08:25/08:25", "08:35/08:35"},
+		},
+		{
+			name: "recovered delay",
+			update: &TripUpdate{
+				StopTimeUpdates: []*StopTimeUpdate{
+					{StopSequence: uint32Ptr(1), Departure: delayEvent(5 * time.Minute)},
+					{StopSequence: uint32Ptr(3), Arrival: delayEvent(time.Minute), Departure: delayEvent(-time.Minute)},
+				},
+			},
+			want: []string{"-/08:05", "08:15/08:15", "08:21/08:21", "08:29/08:29"},
+		},
+		{
+			name: "unknown stop",
+			update: &TripUpdate{
+				StopTimeUpdates: []*StopTimeUpdate{
+					{StopID: "x", Departure: delayEvent(time.Minute)},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSchedule(t)
+
+			tt.update.Trip = TripDescriptor{TripID: "t1", StartDate: "20231114"}
+			err := s.Apply(newTestFeed(IncrementalityFullDataset, map[string]*TripUpdate{"e": tt.update}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Schedule.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			p := s.Prediction(s.tripsByID["t1"], scheduleTestTime(0, 0), 0)
+			if tt.wantErr {
+				if p != nil {
+					t.Errorf("Schedule.Apply() added prediction for invalid update")
+				}
+				return
+			}
+
+			if got := predictedTimes(p); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Schedule.Apply() predictions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Apply_matching(t *testing.T) {
+	dir0, dir1 := uint32(0), uint32(1)
+	tests := []struct {
+		name       string
+		trip       TripDescriptor
+		timestamp  time.Time
+		wantTrip   string
+		wantDate   string
+		wantOffset time.Duration
+		wantErr    bool
+	}{
+		{
+			name:     "trip ID",
+			trip:     TripDescriptor{TripID: "t2"},
+			wantTrip: "t2",
+			wantDate: "20231114",
+		},
+		{
+			name:     "start date",
+			trip:     TripDescriptor{TripID: "t2", StartDate: "20231115"},
+			wantTrip: "t2",
+			wantDate: "20231115",
+		},
+		{
+			name:      "previous service day",
+			trip:      TripDescriptor{TripID: "late"},
+			timestamp: time.Date(2023, 11, 15, 0, 5, 0, 0, scheduleTestLocation),
+			wantTrip:  "late",
+			wantDate:  "20231114",
+		},
+		{
+			name:     "route, direction and start time",
+			trip:     TripDescriptor{RouteID: "r1", DirectionID: &dir0, StartTime: "09:00:00"},
+			wantTrip: "t2",
+			wantDate: "20231114",
+		},
+		{
+			name:       "frequency-based trip",
+			trip:       TripDescriptor{TripID: "freq", StartTime: "10:30:00"},
+			wantTrip:   "freq",
+			wantDate:   "20231114",
+			wantOffset: 10*time.Hour + 30*time.Minute,
+		},
+		{
+			name:       "frequency-based trip by route",
+			trip:       TripDescriptor{RouteID: "r1", DirectionID: &dir1, StartTime: "10:00:00"},
+			wantTrip:   "freq",
+			wantDate:   "20231114",
+			wantOffset: 10 * time.Hour,
+		},
+		{
+			name:    "frequency-based trip without start time",
+			trip:    TripDescriptor{TripID: "freq"},
+			wantErr: true,
+		},
+		{
+			name:    "frequency-based trip between runs",
+			trip:    TripDescriptor{TripID: "freq", StartTime: "10:15:00"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown trip",
+			trip:    TripDescriptor{TripID: "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "start date without service",
+			trip:    TripDescriptor{TripID: "t1", StartDate: "20231118"},
+			wantErr: true,
+		},
+		{
+			name:    "no matching start time",
+			trip:    TripDescriptor{RouteID: "r1", StartTime: "09:05:00"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicated",
+			trip:    TripDescriptor{TripID: "t1", ScheduleRelationship: TripDuplicated},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSchedule(t)
+
+			feed := newTestFeed(IncrementalityFullDataset, map[string]*TripUpdate{"e": {Trip: tt.trip}})
+			if !tt.timestamp.IsZero() {
+				feed.Header.Timestamp = tt.timestamp
+			}
+
+			err := s.Apply(feed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Schedule.Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if len(s.predictions) != 0 {
+					t.Errorf("Schedule.Apply() added prediction for invalid update")
+				}
+				return
+			}
+
+			want := tripInstance{tripID: tt.wantTrip, date: tt.wantDate, offset: tt.wantOffset}
+			if p, ok := s.predictions[want]; !ok || len(s.predictions) != 1 {
+				t.Errorf("Schedule.Apply() predictions = %v, want %v", s.predictions, want)
+			} else if p.Trip.ID != tt.wantTrip || p.ServiceDate.Format(dateFormat) != tt.wantDate || p.Offset != tt.wantOffset {
+				t.Errorf("Schedule.Apply() prediction = %+v", p)
+			}
+		})
+	}
+}
+
+func TestSchedule_Apply_frequencyTrip(t *testing.T) {
+	s := newTestSchedule(t)
+
+	err := s.Apply(newTestFeed(IncrementalityFullDataset, map[string]*TripUpdate{
+		"e": {
+			Trip: TripDescriptor{TripID: "freq", StartTime: "10:30:00"},
+			StopTimeUpdates: []*StopTimeUpdate{
+				{StopSequence: uint32Ptr(1), Departure: delayEvent(time.Minute)},
+			},
+		},
+	}))
+	if err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	p := s.Prediction(s.tripsByID["freq"], scheduleTestTime(0, 0), 10*time.Hour+30*time.Minute)
+	if got, want := predictedTimes(p), []string{"-/10:31", "10:41/10:41"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Schedule.Apply() predictions = %v, want %v", got, want)
+	}
+}
+
+func TestSchedule_Apply_inexactFrequencyTrip(t *testing.T) {
+	s := newTestSchedule(t)
+	freq := s.tripsByID["freq"]
+	freq.Frequencies = []gtfs.Frequency{
+		{StartTime: "10:00:00", EndTime: "11:00:00", HeadwaySeconds: 1800},
+		{StartTime: "12:00:00", EndTime: "13:00:00", HeadwaySeconds: 1800},
+	}
+
+	// Runs without exact times are matched to the closest scheduled run in
+	// any of the trip's frequencies
+	err := s.Apply(newTestFeed(IncrementalityFullDataset, map[string]*TripUpdate{
+		"e": {
+			Trip: TripDescriptor{TripID: "freq", StartTime: "10:40:00"},
+			StopTimeUpdates: []*StopTimeUpdate{
+				{StopSequence: uint32Ptr(1), Departure: &StopTimeEvent{Time: scheduleTestTime(10, 42)}},
+			},
+		},
+		"later": {
+			Trip: TripDescriptor{TripID: "freq", StartTime: "12:20:00"},
+		},
+	}))
+	if err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	p := s.Prediction(freq, scheduleTestTime(0, 0), 10*time.Hour+30*time.Minute)
+	if p == nil {
+		t.Fatalf("Schedule.Apply() predictions = %v, want prediction for 10:30 run", s.predictions)
+	}
+
+	if got, want := predictedTimes(p), []string{"-/10:42", "10:52/10:52"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Schedule.Apply() predictions = %v, want %v", got, want)
+	}
+
+	if s.Prediction(freq, scheduleTestTime(0, 0), 12*time.Hour+30*time.Minute) == nil {
+		t.Errorf("Schedule.Apply() predictions = %v, want prediction for 12:30 run", s.predictions)
+	}
+
+	deps, err := s.Departures(s.stopsByID["a"], scheduleTestTime(10, 0), time.Hour)
+	if err != nil {
+		t.Fatalf("Schedule.Departures() error = %v", err)
+	}
+
+	if len(deps) != 2 || deps[1].Prediction != p || !deps[1].Predicted.Equal(scheduleTestTime(10, 42)) {
+		t.Errorf("Schedule.Departures() = %+v, want predicted 10:30 run", deps)
+	}
+}
+
+func TestSchedule_Apply_duplicateTripInstance(t *testing.T) {
+	s := newTestSchedule(t)
+
+	update := func(d time.Duration) *TripUpdate {
+		return &TripUpdate{
+			Trip:            TripDescriptor{TripID: "t1"},
+			StopTimeUpdates: []*StopTimeUpdate{{StopSequence: uint32Ptr(1), Departure: delayEvent(d)}},
+		}
+	}
+
+	if err := s.Apply(newTestFeed(IncrementalityDifferential, map[string]*TripUpdate{"e1": update(time.Minute)})); err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	// A second entity can't update the same trip instance
+	if err := s.Apply(newTestFeed(IncrementalityDifferential, map[string]*TripUpdate{"e2": update(2 * time.Minute)})); err == nil {
+		t.Errorf("Schedule.Apply() error = nil, want error for duplicate trip instance")
+	}
+
+	p := s.Prediction(s.tripsByID["t1"], scheduleTestTime(0, 0), 0)
+	if p == nil || predictedTimes(p)[0] != "-/08:01" {
+		t.Fatalf("Schedule.Apply() prediction = %+v, want prediction from first entity", p)
+	}
+
+	// Deleting the rejected entity leaves the prediction in place
+	feed := newTestFeed(IncrementalityDifferential, nil)
+	feed.Entities = append(feed.Entities, &FeedEntity{ID: "e2", IsDeleted: true})
+	if err := s.Apply(feed); err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	if s.Prediction(s.tripsByID["t1"], scheduleTestTime(0, 0), 0) != p {
+		t.Errorf("Schedule.Apply() removed prediction of another entity")
+	}
+
+	// Once the first entity is deleted, the trip instance can be updated again
+	feed = newTestFeed(IncrementalityDifferential, map[string]*TripUpdate{"e2": update(2 * time.Minute)})
+	feed.Entities = append([]*FeedEntity{{ID: "e1", IsDeleted: true}}, feed.Entities...)
+	if err := s.Apply(feed); err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	if p := s.Prediction(s.tripsByID["t1"], scheduleTestTime(0, 0), 0); p == nil || predictedTimes(p)[0] != "-/08:02" {
+		t.Errorf("Schedule.Apply() prediction = %+v, want prediction from second entity", p)
+	}
+}
+
+func TestSchedule_Apply_canceledAndAdded(t *testing.T) {
+	s := newTestSchedule(t)
+
+	err := s.Apply(newTestFeed(IncrementalityFullDataset, map[string]*TripUpdate{
+		"canceled": {
+			Trip: TripDescriptor{TripID: "t2", ScheduleRelationship: TripCanceled},
+			StopTimeUpdates: []*StopTimeUpdate{
+				{StopSequence: uint32Ptr(1), Departure: delayEvent(time.Minute)},
+			},
+		},
+		"added": {
+			Trip: TripDescriptor{TripID: "extra", RouteID: "r1", ScheduleRelationship: TripAdded},
+			StopTimeUpdates: []*StopTimeUpdate{
+				{StopID: "a", Departure: &StopTimeEvent{Time: scheduleTestTime(12, 0)}},
+				{StopID: "b", ScheduleRelationship: StopTimeSkipped},
+				{StopID: "c", Arrival: &StopTimeEvent{ScheduledTime: scheduleTestTime(12, 15), Delay: delayEvent(time.Minute).Delay}},
+			},
+		},
+		"new": {
+			Trip: TripDescriptor{TripID: "new", RouteID: "r1", ScheduleRelationship: TripNew},
+			StopTimeUpdates: []*StopTimeUpdate{
+				{StopID: "a", Departure: &StopTimeEvent{Time: scheduleTestTime(13, 0)}},
+			},
+		},
+	}))
+	if err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	canceled := s.Prediction(s.tripsByID["t2"], scheduleTestTime(0, 0), 0)
+	if !canceled.Canceled() || canceled.Added() {
+		t.Errorf("TripPrediction.Canceled() = false, want true")
+	}
+
+	if got, want := predictedTimes(canceled), []string{"-/-", "-/-", "-/-", "-/-"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Schedule.Apply() canceled predictions = %v, want %v", got, want)
+	}
+
+	added := s.predictions[tripInstance{tripID: "extra", date: "20231114"}]
+	if added == nil || !added.Added() || added.Trip != nil || added.Route == nil || added.Route.ID != "r1" {
+		t.Fatalf("Schedule.Apply() added prediction = %+v", added)
+	}
+
+	if got, want := predictedTimes(added), []string{"12:00/12:00", "skipped", "12:16/12:16"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Schedule.Apply() added predictions = %v, want %v", got, want)
+	}
+
+	if got := added.Stops[2].ScheduledArrival; !got.Equal(scheduleTestTime(12, 15)) {
+		t.Errorf("Schedule.Apply() added scheduled arrival = %v", got)
+	}
+
+	if p := s.predictions[tripInstance{tripID: "new", date: "20231114"}]; p == nil || !p.Added() || p.Trip != nil {
+		t.Errorf("Schedule.Apply() new prediction = %+v", p)
+	}
+}
+
+func TestSchedule_Apply_incrementality(t *testing.T) {
+	s := newTestSchedule(t)
+
+	update := func(tripID string) *TripUpdate {
+		return &TripUpdate{Trip: TripDescriptor{TripID: tripID}}
+	}
+
+	if err := s.Apply(newTestFeed(IncrementalityFullDataset, map[string]*TripUpdate{"e1": update("t1"), "e2": update("t2")})); err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	// Differential updates replace and delete individual entities
+	feed := newTestFeed(IncrementalityDifferential, map[string]*TripUpdate{"e1": update("late")})
+	feed.Entities = append(feed.Entities, &FeedEntity{ID: "e2", IsDeleted: true})
+	if err := s.Apply(feed); err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	if len(s.predictions) != 1 || s.Prediction(s.tripsByID["late"], scheduleTestTime(0, 0), 0) == nil {
+		t.Errorf("Schedule.Apply() differential predictions = %v", s.predictions)
+	}
+
+	// Invalid updates leave the entity's previous prediction in place
+	if err := s.Apply(newTestFeed(IncrementalityDifferential, map[string]*TripUpdate{"e1": update("unknown")})); err == nil {
+		t.Errorf("Schedule.Apply() error = nil, want error for unknown trip")
+	}
+
+	if len(s.predictions) != 1 || s.Prediction(s.tripsByID["late"], scheduleTestTime(0, 0), 0) == nil {
+		t.Errorf("Schedule.Apply() predictions after invalid update = %v", s.predictions)
+	}
+
+	// Full datasets replace all predictions
+	if err := s.Apply(newTestFeed(IncrementalityFullDataset, map[string]*TripUpdate{"e3": update("t2")})); err != nil {
+		t.Fatalf("Schedule.Apply() error = %v", err)
+	}
+
+	if len(s.predictions) != 1 || s.Prediction(s.tripsByID["t2"], scheduleTestTime(0, 0), 0) == nil {
+		t.Errorf("Schedule.Apply() full dataset predictions = %v", s.predictions)
+	}
+}
+
+func TestNewSchedule_invalidTimezone(t *testing.T) {
+	g := newScheduleTestFeed()
+	g.Agencies[0].Timezone = "Invalid/Timezone"
+
+	if _, err := NewSchedule(g); err == nil {
+		t.Errorf("NewSchedule() error = nil, want error")
+	}
+}
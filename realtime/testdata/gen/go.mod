@@ -0,0 +1,5 @@
+module github.com/dpearson/gtfs/realtime/testdata/gen
+
+go 1.23
+
+require google.golang.org/protobuf v1.36.11
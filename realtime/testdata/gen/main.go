@@ -0,0 +1,161 @@
+// Command gen generates the GTFS-Realtime feeds in the parent directory, which
+// are used to test decoding. It is a separate module so that the realtime
+// package doesn't depend on the protocol buffer library.
+//
+// Run it from this directory with:
+//
+//	go run .
+package main
+
+import (
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// m is an encoded message, with methods appending fields to it.
+type m []byte
+
+// str appends a string field.
+func (b m) str(n protowire.Number, s string) m {
+	b = protowire.AppendTag(b, n, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+// msg appends an embedded message field.
+func (b m) msg(n protowire.Number, c m) m {
+	b = protowire.AppendTag(b, n, protowire.BytesType)
+	return protowire.AppendBytes(b, c)
+}
+
+// vi appends a varint field.
+func (b m) vi(n protowire.Number, v uint64) m {
+	b = protowire.AppendTag(b, n, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// f32 appends a float field.
+func (b m) f32(n protowire.Number, v float32) m {
+	b = protowire.AppendTag(b, n, protowire.Fixed32Type)
+	return protowire.AppendFixed32(b, math.Float32bits(v))
+}
+
+// f64 appends a double field.
+func (b m) f64(n protowire.Number, v float64) m {
+	b = protowire.AppendTag(b, n, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+// group appends a group field.
+func (b m) group(n protowire.Number, c m) m {
+	b = protowire.AppendTag(b, n, protowire.StartGroupType)
+	b = append(b, c...)
+	return protowire.AppendTag(b, n, protowire.EndGroupType)
+}
+
+// neg returns the varint encoding of a negative int64 field.
+func neg(v int64) uint64 { return uint64(v) }
+
+// header returns a feed header with the specified timestamp and
+// incrementality, along with an extension.
+func header(ts uint64, incr uint64) m {
+	h := m{}.str(1, "2.0")
+	if incr != 0 {
+		h = h.vi(2, incr)
+	}
+	return h.vi(3, ts).str(4, "v42").str(1000, "producer extension")
+}
+
+// tl returns a translation of a translated string.
+func tl(text, lang string) m {
+	t := m{}.str(1, text)
+	if lang != "" {
+		t = t.str(2, lang)
+	}
+	return t
+}
+
+// write writes feed to the named file in the parent directory.
+func write(name string, feed m) {
+	if err := os.WriteFile(filepath.Join("..", name), feed, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+	// Trip updates
+	tu1 := m{}.
+		msg(1, m{}.str(1, "t1").str(3, "20231114").vi(4, 0).str(5, "r1").vi(6, 0)).
+		msg(3, m{}.str(1, "v1").str(2, "Bus 1").vi(4, 2)).
+		msg(2, m{}.vi(1, 1).str(4, "a").msg(3, m{}.vi(1, 60))).
+		msg(2, m{}.vi(1, 2).str(4, "b").
+			msg(2, m{}.vi(2, 1700000300).vi(3, 30)).
+			msg(3, m{}.vi(2, 1700000320).vi(1, neg(-15))).
+			msg(6, m{}.str(1, "b2").vi(1000, 7)).
+			f64(1001, 2.5)).
+		msg(2, m{}.vi(1, 3).str(4, "c").vi(5, 1)).
+		vi(4, 1699999990).
+		vi(5, 60).
+		vi(1003, 12345)
+	tu2 := m{}.
+		msg(1, m{}.str(1, "t2").vi(4, 3)).
+		msg(6, m{}.str(1, "t2_dup").str(2, "20231115").str(3, "25:10:00").str(4, "s2"))
+	tu3 := m{}.msg(1, m{}.str(1, "t3").vi(4, 99))
+	feed := m{}.msg(1, header(1700000000, 0)).
+		msg(2, m{}.str(1, "tu1").msg(3, tu1)).
+		msg(2, m{}.str(1, "tu2").msg(3, tu2)).
+		msg(2, m{}.str(1, "tu3").msg(3, tu3)).
+		msg(2, m{}.str(1, "deleted").vi(2, 1)).
+		msg(2, m{}.str(1, "shape").msg(6, m{}.str(1, "s1").str(2, "encoded polyline"))).
+		msg(1005, m{}.str(1, "ignored"))
+	write("trip_updates.pb", feed)
+
+	// Vehicle positions
+	v1 := m{}.
+		msg(1, m{}.str(1, "t1").str(2, "08:00:00").str(3, "20231114")).
+		msg(8, m{}.str(1, "v1").str(2, "Bus 1").str(3, "ABC123").vi(4, 3)).
+		msg(2, m{}.f32(1, 40.5).f32(2, -75.25).f32(3, 90).f64(4, 1234.5).f32(5, 10).group(1000, m{}.vi(1, 1).str(2, "x"))).
+		vi(3, 2).
+		str(7, "b").
+		vi(4, 1).
+		vi(5, 1700000100).
+		vi(6, 1).
+		vi(9, 1).
+		vi(10, 40).
+		msg(11, m{}.str(1, "car1")).
+		f32(9000, 1.5)
+	v2 := m{}.
+		msg(2, m{}.f32(1, 41).f32(2, -76)).
+		vi(4, 99).
+		vi(9, 0)
+	feed = m{}.msg(1, header(1700000100, 1)).
+		msg(2, m{}.str(1, "v1").msg(4, v1)).
+		msg(2, m{}.str(1, "v2").msg(4, v2))
+	write("vehicle_positions.pb", feed)
+
+	// Alerts
+	a := m{}.
+		msg(1, m{}.vi(1, 1700000000).vi(2, 1700003600)).
+		msg(1, m{}.vi(1, 1700086400)).
+		msg(5, m{}.str(1, "agency")).
+		msg(5, m{}.str(2, "r1").vi(6, 1)).
+		msg(5, m{}.msg(4, m{}.str(1, "t1"))).
+		msg(5, m{}.str(5, "a").vi(3, 3)).
+		vi(6, 9).
+		vi(7, 4).
+		msg(8, m{}.msg(1, tl("https://example.com/alerts/1", ""))).
+		msg(10, m{}.msg(1, tl("Detour on route 1", "en")).msg(1, tl("Déviation sur la ligne 1", "fr"))).
+		msg(11, m{}.msg(1, tl("Buses are detoured due to maintenance.", ""))).
+		msg(15, m{}.msg(1, m{}.str(1, "https://example.com/map.png").str(2, "image/png"))).
+		vi(14, 3).
+		msg(17, m{}.msg(1, tl("Track work", "en"))).
+		msg(18, m{}.msg(1, tl("Stops skipped", "en"))).
+		msg(9000, m{}.str(1, "extension"))
+	feed = m{}.msg(1, header(1700000000, 0)).
+		msg(2, m{}.str(1, "alert1").msg(5, a)).
+		msg(2, m{}.str(1, "alert2").msg(5, m{}.vi(6, 99).vi(7, 99).msg(10, m{}.msg(1, tl("Service change", "")))))
+	write("alerts.pb", feed)
+}
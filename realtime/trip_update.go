@@ -0,0 +1,229 @@
+package realtime
+
+import (
+	"time"
+)
+
+// A TripUpdate contains realtime changes to a trip instance.
+type TripUpdate struct {
+	Trip    TripDescriptor
+	Vehicle *VehicleDescriptor
+
+	// StopTimeUpdates contains updates to the trip's stop times, ordered by
+	// stop sequence. Each update also applies to subsequent stops without
+	// updates of their own.
+	StopTimeUpdates []*StopTimeUpdate
+
+	// Timestamp is the time at which the trip's progress was last measured.
+	Timestamp time.Time
+
+	// Delay is the current schedule deviation of the trip, used when there
+	// are no stop time updates that apply.
+	Delay *time.Duration
+
+	// TripProperties contains updated properties of the trip, for trips that
+	// are duplicated or replaced.
+	TripProperties *TripProperties
+}
+
+// A StopTimeUpdate contains realtime changes to a single stop time of a trip.
+// The stop time is identified by StopSequence, StopID or both.
+type StopTimeUpdate struct {
+	StopSequence *uint32
+	StopID       string
+
+	Arrival   *StopTimeEvent
+	Departure *StopTimeEvent
+
+	ScheduleRelationship StopTimeScheduleRelationship
+
+	// AssignedStopID is the ID of the stop that the trip serves instead of
+	// the scheduled stop (e.g. a different platform), if any.
+	AssignedStopID string
+}
+
+// A StopTimeEvent contains the predicted timing of an arrival or departure. At
+// least one of Delay and Time is normally set. If both are, Time takes
+// precedence.
+type StopTimeEvent struct {
+	Delay *time.Duration
+	Time  time.Time
+
+	// Uncertainty is the expected error in Delay and Time, or nil if
+	// unknown. Zero indicates that the time is certain.
+	Uncertainty *time.Duration
+
+	// ScheduledTime is the scheduled time of the event, for trips that
+	// aren't in the static schedule.
+	ScheduledTime time.Time
+}
+
+// StopTimeScheduleRelationship indicates the relationship between a stop time
+// and the static schedule.
+type StopTimeScheduleRelationship int
+
+const (
+	// StopTimeScheduled indicates that the vehicle is proceeding in
+	// accordance with the schedule, possibly with delays.
+	StopTimeScheduled StopTimeScheduleRelationship = iota
+
+	// StopTimeSkipped indicates that the stop is skipped.
+	StopTimeSkipped
+
+	// StopTimeNoData indicates that there is no realtime information for the
+	// stop, and that the static schedule should be used.
+	StopTimeNoData
+
+	// StopTimeUnscheduled indicates that the vehicle is operating a
+	// frequency-based trip without a fixed schedule.
+	StopTimeUnscheduled
+)
+
+// TripProperties contains updated properties of a trip.
+type TripProperties struct {
+	TripID    string
+	StartDate string
+	StartTime string
+	ShapeID   string
+}
+
+func (t *TripUpdate) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			err = d.embedded(t.Trip.decode)
+		case 2:
+			u := &StopTimeUpdate{}
+			if err = d.embedded(u.decode); err == nil {
+				t.StopTimeUpdates = append(t.StopTimeUpdates, u)
+			}
+		case 3:
+			if t.Vehicle == nil {
+				t.Vehicle = &VehicleDescriptor{}
+			}
+			err = d.embedded(t.Vehicle.decode)
+		case 4:
+			t.Timestamp, err = d.timestamp()
+		case 5:
+			var v time.Duration
+			v, err = d.seconds()
+			t.Delay = &v
+		case 6:
+			if t.TripProperties == nil {
+				t.TripProperties = &TripProperties{}
+			}
+			err = d.embedded(t.TripProperties.decode)
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func (u *StopTimeUpdate) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			var v uint32
+			v, err = d.uint32()
+			u.StopSequence = &v
+		case 2:
+			if u.Arrival == nil {
+				u.Arrival = &StopTimeEvent{}
+			}
+			err = d.embedded(u.Arrival.decode)
+		case 3:
+			if u.Departure == nil {
+				u.Departure = &StopTimeEvent{}
+			}
+			err = d.embedded(u.Departure.decode)
+		case 4:
+			u.StopID, err = d.string()
+		case 5:
+			var v uint64
+			v, err = d.uint64()
+			if r, ok := parseStopTimeScheduleRelationship(v); ok {
+				u.ScheduleRelationship = r
+			}
+		case 6:
+			err = d.embedded(func(b []byte) error {
+				return decodeMessage(b, func(d *decoder) error {
+					if d.field != 1 {
+						return d.skip()
+					}
+
+					var err error
+					u.AssignedStopID, err = d.string()
+
+					return err
+				})
+			})
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func (e *StopTimeEvent) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			var v time.Duration
+			v, err = d.seconds()
+			e.Delay = &v
+		case 2:
+			e.Time, err = d.timestamp()
+		case 3:
+			var v time.Duration
+			v, err = d.seconds()
+			e.Uncertainty = &v
+		case 4:
+			e.ScheduledTime, err = d.timestamp()
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func parseStopTimeScheduleRelationship(v uint64) (StopTimeScheduleRelationship, bool) {
+	switch v {
+	case 0:
+		return StopTimeScheduled, true
+	case 1:
+		return StopTimeSkipped, true
+	case 2:
+		return StopTimeNoData, true
+	case 3:
+		return StopTimeUnscheduled, true
+	default:
+		return StopTimeScheduled, false
+	}
+}
+
+func (p *TripProperties) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			p.TripID, err = d.string()
+		case 2:
+			p.StartDate, err = d.string()
+		case 3:
+			p.StartTime, err = d.string()
+		case 4:
+			p.ShapeID, err = d.string()
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
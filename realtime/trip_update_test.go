@@ -0,0 +1,132 @@
+package realtime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func TestTripUpdate_decode(t *testing.T) {
+	feed := loadTestFeed(t, "trip_updates.pb")
+
+	tests := []struct {
+		id   string
+		want *TripUpdate
+	}{
+		{
+			id: "tu1",
+			want: &TripUpdate{
+				Trip: TripDescriptor{
+					TripID:      "t1",
+					RouteID:     "r1",
+					DirectionID: uint32Ptr(0),
+					StartDate:   "20231114",
+				},
+				Vehicle: &VehicleDescriptor{
+					ID:                   "v1",
+					Label:                "Bus 1",
+					WheelchairAccessible: gtfs.WheelchairAccessibleYes,
+				},
+				StopTimeUpdates: []*StopTimeUpdate{
+					{
+						StopSequence: uint32Ptr(1),
+						StopID:       "a",
+						Departure:    &StopTimeEvent{Delay: durationPtr(time.Minute)},
+					},
+					{
+						StopSequence: uint32Ptr(2),
+						StopID:       "b",
+						Arrival: &StopTimeEvent{
+							Time:        time.Date(2023, 11, 14, 22, 18, 20, 0, time.UTC),
+							Uncertainty: durationPtr(30 * time.Second),
+						},
+						Departure: &StopTimeEvent{
+							Delay: durationPtr(-15 * time.Second),
+							Time:  time.Date(2023, 11, 14, 22, 18, 40, 0, time.UTC),
+						},
+						AssignedStopID: "b2",
+					},
+					{
+						StopSequence:         uint32Ptr(3),
+						StopID:               "c",
+						ScheduleRelationship: StopTimeSkipped,
+					},
+				},
+				Timestamp: time.Date(2023, 11, 14, 22, 13, 10, 0, time.UTC),
+				Delay:     durationPtr(time.Minute),
+			},
+		},
+		{
+			id: "tu2",
+			want: &TripUpdate{
+				Trip: TripDescriptor{
+					TripID:               "t2",
+					ScheduleRelationship: TripCanceled,
+				},
+				TripProperties: &TripProperties{
+					TripID:    "t2_dup",
+					StartDate: "20231115",
+					StartTime: "25:10:00",
+					ShapeID:   "s2",
+				},
+			},
+		},
+		{
+			// Unknown schedule relationships are ignored
+			id: "tu3",
+			want: &TripUpdate{
+				Trip: TripDescriptor{
+					TripID:               "t3",
+					ScheduleRelationship: TripScheduled,
+				},
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			e := feed.Entities[i]
+			if e.ID != tt.id {
+				t.Fatalf("entity ID = %v, want %v", e.ID, tt.id)
+			}
+
+			if !reflect.DeepEqual(e.TripUpdate, tt.want) {
+				t.Errorf("TripUpdate = %+v, want %+v", e.TripUpdate, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTripScheduleRelationship(t *testing.T) {
+	tests := []struct {
+		val    uint64
+		want   TripScheduleRelationship
+		wantOK bool
+	}{
+		{0, TripScheduled, true},
+		{1, TripAdded, true},
+		{2, TripUnscheduled, true},
+		{3, TripCanceled, true},
+		{4, TripScheduled, false},
+		{5, TripReplacement, true},
+		{6, TripDuplicated, true},
+		{7, TripDeleted, true},
+		{8, TripNew, true},
+		{9, TripScheduled, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseTripScheduleRelationship(tt.val)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseTripScheduleRelationship(%d) = %v, %v, want %v, %v", tt.val, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package realtime
+
+import (
+	"time"
+)
+
+// A VehiclePosition contains the realtime position and status of a vehicle.
+type VehiclePosition struct {
+	Trip     *TripDescriptor
+	Vehicle  *VehicleDescriptor
+	Position *Position
+
+	// CurrentStopSequence and StopID identify the stop that CurrentStatus
+	// refers to.
+	CurrentStopSequence *uint32
+	StopID              string
+	CurrentStatus       VehicleStopStatus
+
+	// Timestamp is the time at which the vehicle's position was measured.
+	Timestamp time.Time
+
+	CongestionLevel CongestionLevel
+	OccupancyStatus OccupancyStatus
+
+	// OccupancyPercentage is the number of passengers as a percentage of the
+	// vehicle's capacity, which may exceed 100.
+	OccupancyPercentage *uint32
+}
+
+// A Position is a geographic position of a vehicle.
+type Position struct {
+	Latitude  float32
+	Longitude float32
+
+	// Bearing is the direction in which the vehicle is facing, in degrees
+	// clockwise from true north.
+	Bearing *float32
+
+	// Odometer is the distance traveled by the vehicle, in meters.
+	Odometer *float64
+
+	// Speed is the speed of the vehicle, in meters per second.
+	Speed *float32
+}
+
+// VehicleStopStatus indicates the status of a vehicle relative to its current
+// stop.
+type VehicleStopStatus int
+
+const (
+	// VehicleInTransitTo indicates that the vehicle has departed the
+	// previous stop and is in transit.
+	VehicleInTransitTo VehicleStopStatus = iota
+
+	// VehicleIncomingAt indicates that the vehicle is about to arrive at the
+	// stop.
+	VehicleIncomingAt
+
+	// VehicleStoppedAt indicates that the vehicle is standing at the stop.
+	VehicleStoppedAt
+)
+
+// CongestionLevel indicates the level of traffic congestion affecting a
+// vehicle.
+type CongestionLevel int
+
+const (
+	// CongestionLevelUnknown means that no congestion information is
+	// available.
+	CongestionLevelUnknown CongestionLevel = iota
+
+	// CongestionLevelRunningSmoothly means that traffic is flowing freely.
+	CongestionLevelRunningSmoothly
+
+	// CongestionLevelStopAndGo means that traffic is intermittently stopped.
+	CongestionLevelStopAndGo
+
+	// CongestionLevelCongestion means that traffic is congested.
+	CongestionLevelCongestion
+
+	// CongestionLevelSevere means that traffic is severely congested.
+	CongestionLevelSevere
+)
+
+// OccupancyStatus indicates how full a vehicle is.
+type OccupancyStatus int
+
+const (
+	// OccupancyUnknown means that no occupancy information is available.
+	OccupancyUnknown OccupancyStatus = iota
+
+	// OccupancyEmpty means that the vehicle has few or no passengers.
+	OccupancyEmpty
+
+	// OccupancyManySeatsAvailable means that a large number of seats are
+	// available.
+	OccupancyManySeatsAvailable
+
+	// OccupancyFewSeatsAvailable means that a small number of seats are
+	// available.
+	OccupancyFewSeatsAvailable
+
+	// OccupancyStandingRoomOnly means that only standing room is available.
+	OccupancyStandingRoomOnly
+
+	// OccupancyCrushedStandingRoomOnly means that only very little standing
+	// room is available.
+	OccupancyCrushedStandingRoomOnly
+
+	// OccupancyFull means that the vehicle is considered full, although it
+	// may still accept passengers.
+	OccupancyFull
+
+	// OccupancyNotAcceptingPassengers means that the vehicle isn't accepting
+	// passengers.
+	OccupancyNotAcceptingPassengers
+
+	// OccupancyNoDataAvailable means that the vehicle has no occupancy data
+	// at the moment.
+	OccupancyNoDataAvailable
+
+	// OccupancyNotBoardable means that the vehicle, or a carriage of it,
+	// can't be boarded by passengers.
+	OccupancyNotBoardable
+)
+
+func (v *VehiclePosition) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			if v.Trip == nil {
+				v.Trip = &TripDescriptor{}
+			}
+			err = d.embedded(v.Trip.decode)
+		case 2:
+			if v.Position == nil {
+				v.Position = &Position{}
+			}
+			err = d.embedded(v.Position.decode)
+		case 3:
+			var val uint32
+			val, err = d.uint32()
+			v.CurrentStopSequence = &val
+		case 4:
+			var val uint64
+			val, err = d.uint64()
+			if s, ok := parseVehicleStopStatus(val); ok {
+				v.CurrentStatus = s
+			}
+		case 5:
+			v.Timestamp, err = d.timestamp()
+		case 6:
+			var val uint64
+			val, err = d.uint64()
+			if c, ok := parseCongestionLevel(val); ok {
+				v.CongestionLevel = c
+			}
+		case 7:
+			v.StopID, err = d.string()
+		case 8:
+			if v.Vehicle == nil {
+				v.Vehicle = &VehicleDescriptor{}
+			}
+			err = d.embedded(v.Vehicle.decode)
+		case 9:
+			var val uint64
+			val, err = d.uint64()
+			if o, ok := parseOccupancyStatus(val); ok {
+				v.OccupancyStatus = o
+			}
+		case 10:
+			var val uint32
+			val, err = d.uint32()
+			v.OccupancyPercentage = &val
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
+
+func parseVehicleStopStatus(v uint64) (VehicleStopStatus, bool) {
+	switch v {
+	case 0:
+		return VehicleIncomingAt, true
+	case 1:
+		return VehicleStoppedAt, true
+	case 2:
+		return VehicleInTransitTo, true
+	default:
+		return VehicleInTransitTo, false
+	}
+}
+
+func parseCongestionLevel(v uint64) (CongestionLevel, bool) {
+	switch v {
+	case 0:
+		return CongestionLevelUnknown, true
+	case 1:
+		return CongestionLevelRunningSmoothly, true
+	case 2:
+		return CongestionLevelStopAndGo, true
+	case 3:
+		return CongestionLevelCongestion, true
+	case 4:
+		return CongestionLevelSevere, true
+	default:
+		return CongestionLevelUnknown, false
+	}
+}
+
+func parseOccupancyStatus(v uint64) (OccupancyStatus, bool) {
+	switch v {
+	case 0:
+		return OccupancyEmpty, true
+	case 1:
+		return OccupancyManySeatsAvailable, true
+	case 2:
+		return OccupancyFewSeatsAvailable, true
+	case 3:
+		return OccupancyStandingRoomOnly, true
+	case 4:
+		return OccupancyCrushedStandingRoomOnly, true
+	case 5:
+		return OccupancyFull, true
+	case 6:
+		return OccupancyNotAcceptingPassengers, true
+	case 7:
+		return OccupancyNoDataAvailable, true
+	case 8:
+		return OccupancyNotBoardable, true
+	default:
+		return OccupancyUnknown, false
+	}
+}
+
+func (p *Position) decode(b []byte) error {
+	return decodeMessage(b, func(d *decoder) error {
+		var err error
+		switch d.field {
+		case 1:
+			p.Latitude, err = d.float()
+		case 2:
+			p.Longitude, err = d.float()
+		case 3:
+			var v float32
+			v, err = d.float()
+			p.Bearing = &v
+		case 4:
+			var v float64
+			v, err = d.double()
+			p.Odometer = &v
+		case 5:
+			var v float32
+			v, err = d.float()
+			p.Speed = &v
+		default:
+			err = d.skip()
+		}
+
+		return err
+	})
+}
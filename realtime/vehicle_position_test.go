@@ -0,0 +1,94 @@
+package realtime
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+func TestVehiclePosition_decode(t *testing.T) {
+	feed := loadTestFeed(t, "vehicle_positions.pb")
+
+	bearing, speed, odometer := float32(90), float32(10), 1234.5
+	tests := []struct {
+		id   string
+		want *VehiclePosition
+	}{
+		{
+			id: "v1",
+			want: &VehiclePosition{
+				Trip: &TripDescriptor{
+					TripID:    "t1",
+					StartTime: "08:00:00",
+					StartDate: "20231114",
+				},
+				Vehicle: &VehicleDescriptor{
+					ID:                   "v1",
+					Label:                "Bus 1",
+					LicensePlate:         "ABC123",
+					WheelchairAccessible: gtfs.WheelchairAccessibleNo,
+				},
+				Position: &Position{
+					Latitude:  40.5,
+					Longitude: -75.25,
+					Bearing:   &bearing,
+					Odometer:  &odometer,
+					Speed:     &speed,
+				},
+				CurrentStopSequence: uint32Ptr(2),
+				StopID:              "b",
+				CurrentStatus:       VehicleStoppedAt,
+				Timestamp:           time.Date(2023, 11, 14, 22, 15, 0, 0, time.UTC),
+				CongestionLevel:     CongestionLevelRunningSmoothly,
+				OccupancyStatus:     OccupancyManySeatsAvailable,
+				OccupancyPercentage: uint32Ptr(40),
+			},
+		},
+		{
+			// Unknown statuses are ignored, and optional fields are nil
+			id: "v2",
+			want: &VehiclePosition{
+				Position: &Position{
+					Latitude:  41,
+					Longitude: -76,
+				},
+				CurrentStatus:   VehicleInTransitTo,
+				OccupancyStatus: OccupancyEmpty,
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			e := feed.Entities[i]
+			if e.ID != tt.id {
+				t.Fatalf("entity ID = %v, want %v", e.ID, tt.id)
+			}
+
+			if !reflect.DeepEqual(e.Vehicle, tt.want) {
+				t.Errorf("Vehicle = %+v, want %+v", e.Vehicle, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseOccupancyStatus(t *testing.T) {
+	tests := []struct {
+		val    uint64
+		want   OccupancyStatus
+		wantOK bool
+	}{
+		{0, OccupancyEmpty, true},
+		{3, OccupancyStandingRoomOnly, true},
+		{7, OccupancyNoDataAvailable, true},
+		{8, OccupancyNotBoardable, true},
+		{9, OccupancyUnknown, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseOccupancyStatus(tt.val)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseOccupancyStatus(%d) = %v, %v, want %v, %v", tt.val, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package realtime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Protocol buffer wire types.
+const (
+	wireVarint     = 0
+	wireFixed64    = 1
+	wireBytes      = 2
+	wireStartGroup = 3
+	wireEndGroup   = 4
+	wireFixed32    = 5
+)
+
+var errTruncated = errors.New("unexpected end of message")
+
+// A decoder reads fields from a protocol buffer message in the wire format.
+//
+// Callers read the key of each field with next, then read its value with the
+// method corresponding to its wire type, or discard it with skip.
+type decoder struct {
+	buf []byte
+	pos int
+
+	field    int
+	wireType int
+}
+
+func newDecoder(buf []byte) *decoder {
+	return &decoder{buf: buf}
+}
+
+// next reads the key of the next field, returning false when the end of the
+// message has been reached.
+func (d *decoder) next() (bool, error) {
+	if d.pos >= len(d.buf) {
+		return false, nil
+	}
+
+	key, err := d.varint()
+	if err != nil {
+		return false, err
+	}
+
+	d.field = int(key >> 3)
+	d.wireType = int(key & 7)
+	if d.field == 0 || key>>3 > math.MaxInt32 {
+		return false, fmt.Errorf("invalid field number: %d", key>>3)
+	}
+
+	return true, nil
+}
+
+// varint reads a base 128 varint.
+func (d *decoder) varint() (uint64, error) {
+	var res uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		if d.pos >= len(d.buf) {
+			return 0, errTruncated
+		}
+
+		b := d.buf[d.pos]
+		d.pos++
+		res |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return res, nil
+		}
+	}
+
+	return 0, errors.New("varint overflows 64 bits")
+}
+
+// fixed32 reads a little-endian 32-bit value.
+func (d *decoder) fixed32() (uint32, error) {
+	if len(d.buf)-d.pos < 4 {
+		return 0, errTruncated
+	}
+
+	b := d.buf[d.pos : d.pos+4]
+	d.pos += 4
+
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24, nil
+}
+
+// fixed64 reads a little-endian 64-bit value.
+func (d *decoder) fixed64() (uint64, error) {
+	if len(d.buf)-d.pos < 8 {
+		return 0, errTruncated
+	}
+
+	b := d.buf[d.pos : d.pos+8]
+	d.pos += 8
+
+	var res uint64
+	for i := 7; i >= 0; i-- {
+		res = res<<8 | uint64(b[i])
+	}
+
+	return res, nil
+}
+
+// bytes reads a length-delimited value. The returned slice refers to the
+// decoder's buffer.
+func (d *decoder) bytes() ([]byte, error) {
+	n, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+
+	if n > uint64(len(d.buf)-d.pos) {
+		return nil, errTruncated
+	}
+
+	res := d.buf[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+
+	return res, nil
+}
+
+// The following methods read the value of the current field, checking that it
+// has the expected wire type.
+
+func (d *decoder) uint64() (uint64, error) {
+	if d.wireType != wireVarint {
+		return 0, d.wireTypeError()
+	}
+
+	return d.varint()
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	v, err := d.uint64()
+
+	return uint32(v), err
+}
+
+func (d *decoder) int32() (int32, error) {
+	v, err := d.uint64()
+
+	return int32(v), err
+}
+
+func (d *decoder) int64() (int64, error) {
+	v, err := d.uint64()
+
+	return int64(v), err
+}
+
+func (d *decoder) bool() (bool, error) {
+	v, err := d.uint64()
+
+	return v != 0, err
+}
+
+func (d *decoder) float() (float32, error) {
+	if d.wireType != wireFixed32 {
+		return 0, d.wireTypeError()
+	}
+
+	v, err := d.fixed32()
+
+	return math.Float32frombits(v), err
+}
+
+func (d *decoder) double() (float64, error) {
+	if d.wireType != wireFixed64 {
+		return 0, d.wireTypeError()
+	}
+
+	v, err := d.fixed64()
+
+	return math.Float64frombits(v), err
+}
+
+func (d *decoder) string() (string, error) {
+	b, err := d.message()
+
+	return string(b), err
+}
+
+// message reads the encoded contents of an embedded message.
+func (d *decoder) message() ([]byte, error) {
+	if d.wireType != wireBytes {
+		return nil, d.wireTypeError()
+	}
+
+	return d.bytes()
+}
+
+// embedded reads the current field as an embedded message, decoding its
+// contents with decode.
+func (d *decoder) embedded(decode func(b []byte) error) error {
+	b, err := d.message()
+	if err != nil {
+		return err
+	}
+
+	return decode(b)
+}
+
+// timestamp reads a POSIX timestamp, returning the zero time if it is zero.
+func (d *decoder) timestamp() (time.Time, error) {
+	v, err := d.int64()
+	if err != nil || v == 0 {
+		return time.Time{}, err
+	}
+
+	return time.Unix(v, 0).UTC(), nil
+}
+
+// seconds reads a signed number of seconds.
+func (d *decoder) seconds() (time.Duration, error) {
+	v, err := d.int32()
+
+	return time.Duration(v) * time.Second, err
+}
+
+func (d *decoder) wireTypeError() error {
+	return fmt.Errorf("invalid wire type %d for field %d", d.wireType, d.field)
+}
+
+// skip discards the value of the current field. It is used for fields that
+// aren't supported, including extensions, so that they are ignored.
+func (d *decoder) skip() error {
+	switch d.wireType {
+	case wireVarint:
+		_, err := d.varint()
+		return err
+	case wireFixed64:
+		_, err := d.fixed64()
+		return err
+	case wireBytes:
+		_, err := d.bytes()
+		return err
+	case wireFixed32:
+		_, err := d.fixed32()
+		return err
+	case wireStartGroup:
+		field := d.field
+		for {
+			ok, err := d.next()
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				return errTruncated
+			}
+
+			if d.wireType == wireEndGroup {
+				if d.field != field {
+					return fmt.Errorf("mismatched end of group %d", field)
+				}
+
+				return nil
+			}
+
+			if err := d.skip(); err != nil {
+				return err
+			}
+		}
+	default:
+		return d.wireTypeError()
+	}
+}
+
+// decodeMessage calls decodeField for each field in the encoded message b.
+// decodeField must read the value of the field, or skip it if it isn't
+// supported.
+func decodeMessage(b []byte, decodeField func(d *decoder) error) error {
+	d := newDecoder(b)
+	for {
+		ok, err := d.next()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return nil
+		}
+
+		if err := decodeField(d); err != nil {
+			return err
+		}
+	}
+}
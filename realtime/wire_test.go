@@ -0,0 +1,65 @@
+package realtime
+
+import (
+	"testing"
+)
+
+func Test_decoder_varint(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		want    uint64
+		wantErr bool
+	}{
+		{"single byte", []byte{0x01}, 1, false},
+		{"multiple bytes", []byte{0xac, 0x02}, 300, false},
+		{"max", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 1<<64 - 1, false},
+		{"negative int32", []byte{0xf1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 1<<64 - 15, false},
+		{"truncated", []byte{0xac}, 0, true},
+		{"overflow", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newDecoder(tt.buf).varint()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decoder.varint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("decoder.varint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_decoder_skip(t *testing.T) {
+	// Fields of every wire type, including a nested group, followed by a
+	// string in field 1
+	buf := []byte{
+		0x10, 0x96, 0x01, // field 2, varint
+		0x19, 1, 2, 3, 4, 5, 6, 7, 8, // field 3, fixed64
+		0x22, 0x02, 'x', 'y', // field 4, bytes
+		0x2d, 1, 2, 3, 4, // field 5, fixed32
+		0x33, 0x3b, 0x08, 0x01, 0x3c, 0x34, // field 6, group containing group 7
+		0x0a, 0x02, 'o', 'k', // field 1, string
+	}
+
+	var got string
+	err := decodeMessage(buf, func(d *decoder) error {
+		if d.field != 1 {
+			return d.skip()
+		}
+
+		var err error
+		got, err = d.string()
+
+		return err
+	})
+	if err != nil {
+		t.Fatalf("decodeMessage() error = %v", err)
+	}
+
+	if got != "ok" {
+		t.Errorf("decodeMessage() = %v, want ok", got)
+	}
+}
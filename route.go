@@ -27,6 +27,9 @@ type Route struct {
 	Color       string
 	TextColor   string
 	SortOrder   uint64
+
+	ContinuousPickup  ContinuousPickup
+	ContinuousDropOff ContinuousDropOff
 }
 
 var routeFields = map[string]bool{
@@ -40,6 +43,9 @@ var routeFields = map[string]bool{
 	"route_color":      false,
 	"route_text_color": false,
 	"route_sort_order": false,
+
+	"continuous_pickup":   false,
+	"continuous_drop_off": false,
 }
 
 func (g *GTFS) processRoutes(r io.Reader) error {
@@ -61,6 +67,16 @@ func (g *GTFS) processRoutes(r io.Reader) error {
 			return err
 		}
 
+		continuousPickup, err := parseContinuousPickup(row["continuous_pickup"], ContinuousPickupNone)
+		if err != nil {
+			return err
+		}
+
+		continuousDropOff, err := parseContinuousDropOff(row["continuous_drop_off"], ContinuousDropOffNone)
+		if err != nil {
+			return err
+		}
+
 		agency, err := g.agencyByIDOrDefault(row["agency_id"])
 		if err != nil {
 			return err
@@ -77,6 +93,9 @@ func (g *GTFS) processRoutes(r io.Reader) error {
 			Color:       row["route_color"],
 			TextColor:   row["route_text_color"],
 			SortOrder:   sortOrder,
+
+			ContinuousPickup:  continuousPickup,
+			ContinuousDropOff: continuousDropOff,
 		}
 
 		if r.Color == "" {
@@ -1,6 +1,9 @@
 package gtfs
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
 
 // RouteType specifies the type of vehicles operating on a route.
 type RouteType int
@@ -258,3 +261,24 @@ func parseRouteType(val string) (RouteType, error) {
 
 	return routeType, nil
 }
+
+var routeTypeCodes = func() map[RouteType]int {
+	res := make(map[RouteType]int, len(routeTypes))
+	for code, t := range routeTypes {
+		n, _ := strconv.Atoi(code)
+		res[t] = n
+	}
+
+	return res
+}()
+
+// Code returns the numeric value used to represent t in GTFS files, or -1 if t
+// is RouteTypeNotSpecified or otherwise has no such value.
+func (t RouteType) Code() int {
+	code, ok := routeTypeCodes[t]
+	if !ok {
+		return -1
+	}
+
+	return code
+}
@@ -83,3 +83,39 @@ func Test_parseRouteType(t *testing.T) {
 		})
 	}
 }
+
+func TestRouteType_Code(t *testing.T) {
+	tests := []struct {
+		name string
+		t    RouteType
+		want int
+	}{
+		{
+			name: "Bus",
+			t:    RouteTypeBus,
+			want: 3,
+		},
+		{
+			name: "Monorail",
+			t:    RouteTypeMonorail,
+			want: 12,
+		},
+		{
+			name: "Extended",
+			t:    RouteTypeExtendedHorseDrawnCarriage,
+			want: 1702,
+		},
+		{
+			name: "Not Specified",
+			t:    RouteTypeNotSpecified,
+			want: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.t.Code(); got != tt.want {
+				t.Errorf("RouteType.Code() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
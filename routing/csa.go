@@ -0,0 +1,574 @@
+package routing
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+// A ConnectionScanner plans journeys on a single service day using the
+// Connection Scan Algorithm.
+//
+// In addition to earliest arrival queries, a ConnectionScanner supports latest
+// departure queries and profile queries, which find all Pareto-optimal journeys
+// between two stops over an entire service day.
+type ConnectionScanner struct {
+	tt          *timetable
+	transfers   *transferRules
+	connections []connection
+	byArrival   []int
+}
+
+// A connection is a vehicle traveling between two consecutive stops of a trip
+// run without stopping.
+type connection struct {
+	run       *tripRun
+	pos       int
+	departure time.Duration
+	arrival   time.Duration
+}
+
+func (c *connection) from() int {
+	return c.run.stops[c.pos]
+}
+
+func (c *connection) to() int {
+	return c.run.stops[c.pos+1]
+}
+
+// NewConnectionScanner creates a ConnectionScanner for journeys on the service
+// day beginning on date.
+//
+// opts.MaxTransfers is ignored.
+func NewConnectionScanner(g *gtfs.GTFS, date time.Time, opts Options) (*ConnectionScanner, error) {
+	tt, err := newTimetable(g, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.WalkingSpeed == 0 {
+		opts.WalkingSpeed = DefaultWalkingSpeed
+	}
+
+	cs := &ConnectionScanner{
+		tt:        tt,
+		transfers: newTransferRules(tt, g, opts),
+	}
+
+	for _, run := range tt.runs {
+		for pos := 0; pos < len(run.stops)-1; pos++ {
+			cs.connections = append(cs.connections, connection{
+				run:       run,
+				pos:       pos,
+				departure: run.departures[pos],
+				arrival:   run.arrivals[pos+1],
+			})
+		}
+	}
+
+	sort.SliceStable(cs.connections, func(i, j int) bool {
+		ci, cj := &cs.connections[i], &cs.connections[j]
+		if ci.departure != cj.departure {
+			return ci.departure < cj.departure
+		}
+
+		return ci.pos < cj.pos
+	})
+
+	cs.byArrival = make([]int, len(cs.connections))
+	for i := range cs.byArrival {
+		cs.byArrival[i] = i
+	}
+
+	sort.SliceStable(cs.byArrival, func(i, j int) bool {
+		ci, cj := &cs.connections[cs.byArrival[i]], &cs.connections[cs.byArrival[j]]
+		if ci.arrival != cj.arrival {
+			return ci.arrival < cj.arrival
+		}
+
+		return ci.pos < cj.pos
+	})
+
+	return cs, nil
+}
+
+// EarliestArrival finds the itinerary from one stop to another departing no
+// earlier than departure that arrives as early as possible, or nil if the
+// destination cannot be reached.
+//
+// If from or to is a station, journeys may begin or end at any stop within it.
+func (cs *ConnectionScanner) EarliestArrival(from, to *gtfs.Stop, departure time.Duration) *Itinerary {
+	sources := cs.tt.stopsAt(from)
+	targets := cs.tt.stopsAt(to)
+	if len(sources) == 0 || len(targets) == 0 {
+		return nil
+	}
+
+	labels := make([]*label, len(cs.tt.stops))
+	for _, s := range sources {
+		labels[s] = &label{
+			kind: labelSource,
+			stop: s,
+			time: departure,
+		}
+	}
+
+	for _, s := range sources {
+		cs.walkFrom(labels, labels[s])
+	}
+
+	best := func() *label {
+		var res *label
+		for _, t := range targets {
+			if l := labels[t]; l != nil && (res == nil || l.time < res.time) {
+				res = l
+			}
+		}
+
+		return res
+	}
+
+	boarded := map[*tripRun]*label{}
+
+	start := sort.Search(len(cs.connections), func(i int) bool {
+		return cs.connections[i].departure >= departure
+	})
+
+	for i := start; i < len(cs.connections); i++ {
+		c := &cs.connections[i]
+		if b := best(); b != nil && c.departure >= b.time {
+			break
+		}
+
+		boardLabel, onBoard := boarded[c.run]
+		if !onBoard && c.run.canBoard[c.pos] {
+			if l := labels[c.from()]; l != nil {
+				ready, ok := cs.transfers.readyTime(l)
+				if ok && ready <= c.departure {
+					boardLabel = &label{
+						stop:     c.from(),
+						boardPos: c.pos,
+						prev:     l,
+					}
+					boarded[c.run] = boardLabel
+					onBoard = true
+				}
+			}
+		}
+
+		if !onBoard || !c.run.canAlight[c.pos+1] {
+			continue
+		}
+
+		if l := labels[c.to()]; l != nil && l.time <= c.arrival {
+			continue
+		}
+
+		l := &label{
+			kind:      labelTransit,
+			stop:      c.to(),
+			time:      c.arrival,
+			run:       c.run,
+			boardPos:  boardLabel.boardPos,
+			alightPos: c.pos + 1,
+			prev:      boardLabel.prev,
+		}
+		labels[l.stop] = l
+
+		cs.walkFrom(labels, l)
+	}
+
+	b := best()
+	if b == nil {
+		return nil
+	}
+
+	return cs.tt.itinerary(b, departure)
+}
+
+// walkFrom records any arrivals improved by walking from the stop reached as
+// described by l.
+func (cs *ConnectionScanner) walkFrom(labels []*label, l *label) {
+	for _, fp := range cs.transfers.footpaths[l.stop] {
+		a := l.time + fp.duration
+		if existing := labels[fp.to]; existing != nil && existing.time <= a {
+			continue
+		}
+
+		labels[fp.to] = &label{
+			kind: labelWalk,
+			stop: fp.to,
+			time: a,
+			prev: l,
+		}
+	}
+}
+
+// LatestDeparture finds the itinerary from one stop to another arriving no
+// later than arrival that departs as late as possible, or nil if no such
+// itinerary exists.
+//
+// If from or to is a station, journeys may begin or end at any stop within it.
+func (cs *ConnectionScanner) LatestDeparture(from, to *gtfs.Stop, arrival time.Duration) *Itinerary {
+	sources := cs.tt.stopsAt(from)
+	targets := cs.tt.stopsAt(to)
+	if len(sources) == 0 || len(targets) == 0 {
+		return nil
+	}
+
+	labels := make([]*label, len(cs.tt.stops))
+	for _, t := range targets {
+		labels[t] = &label{
+			kind: labelSource,
+			stop: t,
+			time: arrival,
+		}
+	}
+
+	for _, t := range targets {
+		cs.walkTo(labels, labels[t])
+	}
+
+	best := func() *label {
+		var res *label
+		for _, s := range sources {
+			if l := labels[s]; l != nil && (res == nil || l.time > res.time) {
+				res = l
+			}
+		}
+
+		return res
+	}
+
+	alighted := map[*tripRun]*label{}
+
+	end := sort.Search(len(cs.byArrival), func(i int) bool {
+		return cs.connections[cs.byArrival[i]].arrival > arrival
+	})
+
+	for i := end - 1; i >= 0; i-- {
+		c := &cs.connections[cs.byArrival[i]]
+		if b := best(); b != nil && c.arrival <= b.time {
+			break
+		}
+
+		exitLabel, onBoard := alighted[c.run]
+		if !onBoard && c.run.canAlight[c.pos+1] {
+			if l := labels[c.to()]; l != nil {
+				latest, ok := cs.transfers.latestArrival(l)
+				if ok && c.arrival <= latest {
+					exitLabel = &label{
+						stop:      c.to(),
+						alightPos: c.pos + 1,
+						prev:      l,
+					}
+					alighted[c.run] = exitLabel
+					onBoard = true
+				}
+			}
+		}
+
+		if !onBoard || !c.run.canBoard[c.pos] {
+			continue
+		}
+
+		if l := labels[c.from()]; l != nil && l.time >= c.departure {
+			continue
+		}
+
+		l := &label{
+			kind:      labelTransit,
+			stop:      c.from(),
+			time:      c.departure,
+			run:       c.run,
+			boardPos:  c.pos,
+			alightPos: exitLabel.alightPos,
+			prev:      exitLabel.prev,
+		}
+		labels[l.stop] = l
+
+		cs.walkTo(labels, l)
+	}
+
+	b := best()
+	if b == nil {
+		return nil
+	}
+
+	return cs.tt.reverseItinerary(b)
+}
+
+// walkTo records any departures improved by walking to the stop departed from
+// as described by l, which is a label from a reverse search.
+func (cs *ConnectionScanner) walkTo(labels []*label, l *label) {
+	for _, fp := range cs.transfers.footpathsTo[l.stop] {
+		d := l.time - fp.duration
+		if existing := labels[fp.from]; existing != nil && existing.time >= d {
+			continue
+		}
+
+		labels[fp.from] = &label{
+			kind:     labelWalk,
+			stop:     fp.from,
+			time:     d,
+			duration: fp.duration,
+			prev:     l,
+		}
+	}
+}
+
+// A profileEntry is a single journey to the target of a profile query.
+//
+// Entries either board run at boardPos and alight at alightPos, optionally
+// walking to the target via finalWalk, or walk to another stop via walk. In
+// both cases, the journey continues with next, if set.
+type profileEntry struct {
+	departure time.Duration
+	arrival   time.Duration
+
+	run       *tripRun
+	boardPos  int
+	alightPos int
+	finalWalk *footpath
+
+	walk *footpath
+
+	next *profileEntry
+}
+
+// A profile is a set of Pareto-optimal journeys from a single stop, sorted by
+// departure time. Later departures always arrive later.
+type profile []*profileEntry
+
+// earliest returns the journey departing no earlier than t that arrives
+// earliest, or nil if there is none.
+func (p profile) earliest(t time.Duration) *profileEntry {
+	i := sort.Search(len(p), func(i int) bool {
+		return p[i].departure >= t
+	})
+
+	if i == len(p) {
+		return nil
+	}
+
+	return p[i]
+}
+
+// insert adds e to p, unless it is dominated by an existing journey, removing
+// any journeys that e dominates. It reports whether e was added.
+func (p *profile) insert(e *profileEntry) bool {
+	entries := *p
+
+	hi := sort.Search(len(entries), func(i int) bool {
+		return entries[i].departure >= e.departure
+	})
+
+	if hi < len(entries) && entries[hi].arrival <= e.arrival {
+		return false
+	}
+
+	lo := sort.Search(hi, func(i int) bool {
+		return entries[i].arrival >= e.arrival
+	})
+
+	if hi < len(entries) && entries[hi].departure == e.departure {
+		hi++
+	}
+
+	res := make(profile, 0, len(entries)-(hi-lo)+1)
+	res = append(res, entries[:lo]...)
+	res = append(res, e)
+	res = append(res, entries[hi:]...)
+	*p = res
+
+	return true
+}
+
+type tripState struct {
+	arrival   time.Duration
+	alightPos int
+	finalWalk *footpath
+	next      *profileEntry
+}
+
+// Profile finds all Pareto-optimal itineraries from one stop to another over
+// the entire service day, where one itinerary is better than another if it
+// departs later or arrives earlier.
+//
+// Itineraries are ordered by departure time. Itineraries consisting only of
+// walking are not included. If from or to is a station, journeys may begin or
+// end at any stop within it.
+func (cs *ConnectionScanner) Profile(from, to *gtfs.Stop) []*Itinerary {
+	sources := cs.tt.stopsAt(from)
+	targets := cs.tt.stopsAt(to)
+	if len(sources) == 0 || len(targets) == 0 {
+		return nil
+	}
+
+	n := len(cs.tt.stops)
+
+	// The time needed to walk from each stop to the target, if possible
+	targetWalks := make([]time.Duration, n)
+	targetFootpaths := make([]*footpath, n)
+	for i := range targetWalks {
+		targetWalks[i] = unreached
+	}
+
+	for _, t := range targets {
+		targetWalks[t] = 0
+	}
+
+	for _, t := range targets {
+		for i := range cs.transfers.footpathsTo[t] {
+			fp := &cs.transfers.footpathsTo[t][i]
+			if fp.duration < targetWalks[fp.from] {
+				targetWalks[fp.from] = fp.duration
+				targetFootpaths[fp.from] = fp
+			}
+		}
+	}
+
+	boardProfiles := make([]profile, n)
+	walkProfiles := make([]profile, n)
+	trips := map[*tripRun]*tripState{}
+
+	for i := len(cs.connections) - 1; i >= 0; i-- {
+		c := &cs.connections[i]
+
+		state := tripState{
+			arrival: unreached,
+		}
+		if s, ok := trips[c.run]; ok {
+			state = *s
+		}
+
+		if c.run.canAlight[c.pos+1] {
+			to := c.to()
+
+			if w := targetWalks[to]; w != unreached && c.arrival+w < state.arrival {
+				state = tripState{
+					arrival:   c.arrival + w,
+					alightPos: c.pos + 1,
+					finalWalk: targetFootpaths[to],
+				}
+			}
+
+			if !cs.transfers.noTransfer[to] {
+				e := boardProfiles[to].earliest(c.arrival + cs.transfers.changeTimes[to])
+				if e != nil && e.arrival < state.arrival {
+					state = tripState{
+						arrival:   e.arrival,
+						alightPos: c.pos + 1,
+						next:      e,
+					}
+				}
+			}
+
+			if e := walkProfiles[to].earliest(c.arrival); e != nil && e.arrival < state.arrival {
+				state = tripState{
+					arrival:   e.arrival,
+					alightPos: c.pos + 1,
+					next:      e,
+				}
+			}
+		}
+
+		if state.arrival == unreached {
+			continue
+		}
+
+		trips[c.run] = &state
+
+		if !c.run.canBoard[c.pos] {
+			continue
+		}
+
+		e := &profileEntry{
+			departure: c.departure,
+			arrival:   state.arrival,
+			run:       c.run,
+			boardPos:  c.pos,
+			alightPos: state.alightPos,
+			finalWalk: state.finalWalk,
+			next:      state.next,
+		}
+
+		from := c.from()
+		if !boardProfiles[from].insert(e) {
+			continue
+		}
+
+		for j := range cs.transfers.footpathsTo[from] {
+			fp := &cs.transfers.footpathsTo[from][j]
+			walkProfiles[fp.from].insert(&profileEntry{
+				departure: c.departure - fp.duration,
+				arrival:   state.arrival,
+				walk:      fp,
+				next:      e,
+			})
+		}
+	}
+
+	var res profile
+	for _, s := range sources {
+		for _, e := range boardProfiles[s] {
+			res.insert(e)
+		}
+
+		for _, e := range walkProfiles[s] {
+			res.insert(e)
+		}
+	}
+
+	its := make([]*Itinerary, 0, len(res))
+	for _, e := range res {
+		its = append(its, cs.tt.profileItinerary(e))
+	}
+
+	return its
+}
+
+// profileItinerary reconstructs the itinerary described by e.
+func (tt *timetable) profileItinerary(e *profileEntry) *Itinerary {
+	departure := time.Duration(math.MinInt64)
+
+	var legs []*Leg
+	for ; e != nil; e = e.next {
+		if departure == math.MinInt64 {
+			departure = e.departure
+		}
+
+		if e.walk != nil {
+			legs = append(legs, &Leg{
+				From:      tt.stops[e.walk.from],
+				To:        tt.stops[e.walk.to],
+				Departure: e.departure,
+				Arrival:   e.departure + e.walk.duration,
+			})
+
+			continue
+		}
+
+		ride := &Leg{
+			Trip:      e.run.trip,
+			From:      tt.stops[e.run.stops[e.boardPos]],
+			To:        tt.stops[e.run.stops[e.alightPos]],
+			Departure: e.run.departures[e.boardPos],
+			Arrival:   e.run.arrivals[e.alightPos],
+		}
+		legs = append(legs, ride)
+
+		if e.finalWalk != nil {
+			legs = append(legs, &Leg{
+				From:      tt.stops[e.finalWalk.from],
+				To:        tt.stops[e.finalWalk.to],
+				Departure: ride.Arrival,
+				Arrival:   ride.Arrival + e.finalWalk.duration,
+			})
+		}
+	}
+
+	return newItinerary(legs, departure)
+}
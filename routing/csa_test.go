@@ -0,0 +1,258 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+func TestConnectionScanner_EarliestArrival(t *testing.T) {
+	walk := &gtfs.Transfer{
+		Type:                gtfs.TransferTypeMinimumTime,
+		MinimumTransferTime: 120,
+	}
+
+	tests := []struct {
+		name        string
+		to          string
+		opts        Options
+		departure   string
+		wantArrival string
+		wantLegs    int
+	}{
+		{
+			name:        "Transfer",
+			to:          "D",
+			departure:   "07:55:00",
+			wantArrival: "08:30:00",
+			wantLegs:    2,
+		},
+		{
+			name: "Default Change Time",
+			to:   "D",
+			opts: Options{
+				MinimumChangeTime: 6 * time.Minute,
+			},
+			departure:   "07:55:00",
+			wantArrival: "09:00:00",
+			wantLegs:    1,
+		},
+		{
+			name:        "Footpath",
+			to:          "E",
+			opts:        Options{Footpaths: []*gtfs.Transfer{walk}},
+			departure:   "08:10:00",
+			wantArrival: "08:52:00",
+			wantLegs:    2,
+		},
+		{
+			name:      "Too Late",
+			to:        "D",
+			departure: "08:10:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFeed()
+			walk.From = f.stops["C"]
+			walk.To = f.stops["E"]
+
+			cs, err := NewConnectionScanner(f.GTFS, testDate, tt.opts)
+			if err != nil {
+				t.Fatalf("NewConnectionScanner() error = %v", err)
+			}
+
+			it := cs.EarliestArrival(f.stops["A"], f.stops[tt.to], mustParseTime(t, tt.departure))
+			if tt.wantArrival == "" {
+				if it != nil {
+					t.Errorf("ConnectionScanner.EarliestArrival() = %+v, want nil", it)
+				}
+
+				return
+			}
+
+			if it == nil {
+				t.Fatalf("ConnectionScanner.EarliestArrival() = nil, want arrival at %s", tt.wantArrival)
+			}
+
+			if it.Arrival != mustParseTime(t, tt.wantArrival) || len(it.Legs) != tt.wantLegs {
+				t.Errorf("ConnectionScanner.EarliestArrival() = %+v, want %d legs arriving at %s", it, tt.wantLegs, tt.wantArrival)
+			}
+		})
+	}
+}
+
+func TestConnectionScanner_LatestDeparture(t *testing.T) {
+	walk := &gtfs.Transfer{
+		Type:                gtfs.TransferTypeMinimumTime,
+		MinimumTransferTime: 120,
+	}
+
+	tests := []struct {
+		name          string
+		to            string
+		opts          Options
+		arrival       string
+		wantDeparture string
+		wantTrip      string
+	}{
+		{
+			name:          "Transfer",
+			to:            "D",
+			arrival:       "08:45:00",
+			wantDeparture: "08:00:00",
+			wantTrip:      "1a",
+		},
+		{
+			name:          "Direct",
+			to:            "D",
+			arrival:       "09:00:00",
+			wantDeparture: "08:05:00",
+			wantTrip:      "3a",
+		},
+		{
+			name:          "Footpath",
+			to:            "E",
+			opts:          Options{Footpaths: []*gtfs.Transfer{walk}},
+			arrival:       "09:00:00",
+			wantDeparture: "08:30:00",
+			wantTrip:      "1b",
+		},
+		{
+			name:    "Too Early",
+			to:      "D",
+			arrival: "08:20:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFeed()
+			walk.From = f.stops["C"]
+			walk.To = f.stops["E"]
+
+			cs, err := NewConnectionScanner(f.GTFS, testDate, tt.opts)
+			if err != nil {
+				t.Fatalf("NewConnectionScanner() error = %v", err)
+			}
+
+			it := cs.LatestDeparture(f.stops["A"], f.stops[tt.to], mustParseTime(t, tt.arrival))
+			if tt.wantDeparture == "" {
+				if it != nil {
+					t.Errorf("ConnectionScanner.LatestDeparture() = %+v, want nil", it)
+				}
+
+				return
+			}
+
+			if it == nil {
+				t.Fatalf("ConnectionScanner.LatestDeparture() = nil, want departure at %s", tt.wantDeparture)
+			}
+
+			if it.Departure != mustParseTime(t, tt.wantDeparture) || it.Legs[0].Trip != f.trips[tt.wantTrip] {
+				t.Errorf("ConnectionScanner.LatestDeparture() = %+v, want trip %s departing at %s", it, tt.wantTrip, tt.wantDeparture)
+			}
+
+			if it.Arrival > mustParseTime(t, tt.arrival) {
+				t.Errorf("ConnectionScanner.LatestDeparture() arrival = %v, want no later than %s", it.Arrival, tt.arrival)
+			}
+
+			last := it.Legs[len(it.Legs)-1]
+			if last.To != f.stops[tt.to] {
+				t.Errorf("ConnectionScanner.LatestDeparture() ends at %s, want %s", last.To.ID, tt.to)
+			}
+		})
+	}
+}
+
+func TestConnectionScanner_Profile(t *testing.T) {
+	f := newTestFeed()
+
+	walk := &gtfs.Transfer{
+		From:                f.stops["C"],
+		To:                  f.stops["E"],
+		Type:                gtfs.TransferTypeMinimumTime,
+		MinimumTransferTime: 120,
+	}
+
+	cs, err := NewConnectionScanner(f.GTFS, testDate, Options{Footpaths: []*gtfs.Transfer{walk}})
+	if err != nil {
+		t.Fatalf("NewConnectionScanner() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		to   string
+		want [][2]string
+	}{
+		{
+			name: "Transfer",
+			to:   "D",
+			want: [][2]string{
+				{"08:00:00", "08:30:00"},
+				{"08:05:00", "09:00:00"},
+			},
+		},
+		{
+			name: "Footpath",
+			to:   "E",
+			want: [][2]string{
+				{"08:00:00", "08:22:00"},
+				{"08:30:00", "08:52:00"},
+			},
+		},
+		{
+			name: "Unreachable",
+			to:   "A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			its := cs.Profile(f.stops["A"], f.stops[tt.to])
+			if len(its) != len(tt.want) {
+				t.Fatalf("ConnectionScanner.Profile() returned %d itineraries, want %d", len(its), len(tt.want))
+			}
+
+			for i, it := range its {
+				if it.Departure != mustParseTime(t, tt.want[i][0]) || it.Arrival != mustParseTime(t, tt.want[i][1]) {
+					t.Errorf("ConnectionScanner.Profile()[%d] = %v -> %v, want %s -> %s", i, it.Departure, it.Arrival, tt.want[i][0], tt.want[i][1])
+				}
+
+				if it.Legs[0].From != f.stops["A"] || it.Legs[len(it.Legs)-1].To != f.stops[tt.to] {
+					t.Errorf("ConnectionScanner.Profile()[%d] = %+v, want journey from A to %s", i, it, tt.to)
+				}
+			}
+		})
+	}
+}
+
+func TestProfile_insert(t *testing.T) {
+	p := profile{}
+
+	for _, e := range []struct {
+		departure, arrival time.Duration
+		want               bool
+	}{
+		{10, 20, true},
+		{5, 15, true},
+		{5, 25, false},
+		{12, 20, true},
+		{3, 30, false},
+		{1, 14, true},
+	} {
+		if got := p.insert(&profileEntry{departure: e.departure, arrival: e.arrival}); got != e.want {
+			t.Errorf("profile.insert(%v, %v) = %v, want %v", e.departure, e.arrival, got, e.want)
+		}
+	}
+
+	want := [][2]time.Duration{{1, 14}, {5, 15}, {12, 20}}
+	if len(p) != len(want) {
+		t.Fatalf("profile has %d entries, want %d", len(p), len(want))
+	}
+
+	for i, e := range p {
+		if e.departure != want[i][0] || e.arrival != want[i][1] {
+			t.Errorf("profile[%d] = (%v, %v), want (%v, %v)", i, e.departure, e.arrival, want[i][0], want[i][1])
+		}
+	}
+}
@@ -0,0 +1,6 @@
+// Package routing provides journey planning over GTFS feeds loaded with the
+// gtfs package.
+//
+// All times are expressed as the amount of time elapsed since the start of the
+// service day of the date being queried, matching the times in stop_times.txt.
+package routing
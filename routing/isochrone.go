@@ -0,0 +1,214 @@
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+// DefaultCellSize is the size, in meters, of the grid cells used to render
+// isochrones when none is specified.
+const DefaultCellSize = 100.0
+
+// metersPerDegree is the approximate length, in meters, of one degree of
+// latitude.
+const metersPerDegree = 111195.0
+
+// TravelTimes finds the earliest arrival time at every stop reachable from a
+// stop departing no earlier than departure.
+//
+// Stops that cannot be reached are omitted. If from is a station, journeys may
+// begin at any stop within it.
+func (r *Router) TravelTimes(from *gtfs.Stop, departure time.Duration) map[*gtfs.Stop]time.Duration {
+	sources := r.tt.stopsAt(from)
+	if len(sources) == 0 {
+		return nil
+	}
+
+	var labels []*label
+	r.run(sources, departure, func(l []*label) {
+		labels = l
+	}, nil)
+
+	res := map[*gtfs.Stop]time.Duration{}
+	for i, l := range labels {
+		if l != nil {
+			res[r.tt.stops[i]] = l.time
+		}
+	}
+
+	return res
+}
+
+// IsochroneOptions specifies options used when rendering isochrones.
+type IsochroneOptions struct {
+	// Bands are the upper bounds of the travel time bands to render, in
+	// increasing order.
+	Bands []time.Duration
+
+	// CellSize is the size, in meters, of the grid cells making up each band.
+	// If zero, DefaultCellSize is used.
+	CellSize float64
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates [][][][]float64 `json:"coordinates"`
+}
+
+type cell struct {
+	row, col int
+}
+
+// Isochrones renders the areas reachable from a stop departing no earlier than
+// departure as a GeoJSON FeatureCollection.
+//
+// Each band in opts is rendered as a Feature with a MultiPolygon geometry
+// covering the grid cells first reached within that band, along with
+// min_seconds and max_seconds properties giving the band's range of travel
+// times. Cells are reached by walking from reachable stops in a straight line
+// at the router's walking speed.
+func (r *Router) Isochrones(from *gtfs.Stop, departure time.Duration, opts IsochroneOptions) ([]byte, error) {
+	if len(opts.Bands) == 0 {
+		return nil, errors.New("no isochrone bands specified")
+	}
+
+	for i := 1; i < len(opts.Bands); i++ {
+		if opts.Bands[i] <= opts.Bands[i-1] {
+			return nil, errors.New("isochrone bands must be in increasing order")
+		}
+	}
+
+	if opts.CellSize == 0 {
+		opts.CellSize = DefaultCellSize
+	}
+
+	maxTime := opts.Bands[len(opts.Bands)-1]
+
+	// Cells are laid out on a grid anchored at the origin, using an
+	// equirectangular approximation that is accurate over the distances
+	// covered by an isochrone
+	lat0, lon0 := from.Latitude, from.Longitude
+	cosLat := math.Cos(lat0 * math.Pi / 180)
+	cellLat := opts.CellSize / metersPerDegree
+	cellLon := cellLat / cosLat
+
+	times := map[cell]time.Duration{}
+	for s, arrival := range r.TravelTimes(from, departure) {
+		elapsed := arrival - departure
+		if elapsed > maxTime {
+			continue
+		}
+
+		radius := (maxTime - elapsed).Seconds() * r.walkingSpeed
+		row := int(math.Floor((s.Latitude - lat0) / cellLat))
+		col := int(math.Floor((s.Longitude - lon0) / cellLon))
+		span := int(math.Ceil(radius / opts.CellSize))
+
+		for i := row - span; i <= row+span; i++ {
+			for j := col - span; j <= col+span; j++ {
+				dy := (lat0 + (float64(i)+0.5)*cellLat - s.Latitude) * metersPerDegree
+				dx := (lon0 + (float64(j)+0.5)*cellLon - s.Longitude) * metersPerDegree * cosLat
+				distance := math.Hypot(dx, dy)
+				if distance > radius {
+					continue
+				}
+
+				t := elapsed + walkingTime(distance, r.walkingSpeed)
+				c := cell{i, j}
+				if existing, ok := times[c]; !ok || t < existing {
+					times[c] = t
+				}
+			}
+		}
+	}
+
+	cells := make([][]cell, len(opts.Bands))
+	for c, t := range times {
+		band := sort.Search(len(opts.Bands), func(i int) bool {
+			return opts.Bands[i] >= t
+		})
+		cells[band] = append(cells[band], c)
+	}
+
+	fc := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []geoJSONFeature{},
+	}
+
+	for band, bandCells := range cells {
+		var minTime time.Duration
+		if band > 0 {
+			minTime = opts.Bands[band-1]
+		}
+
+		polygons := [][][][]float64{}
+		for _, strip := range cellStrips(bandCells) {
+			south := lat0 + float64(strip[0].row)*cellLat
+			north := south + cellLat
+			west := lon0 + float64(strip[0].col)*cellLon
+			east := lon0 + float64(strip[1].col+1)*cellLon
+
+			polygons = append(polygons, [][][]float64{{
+				{west, south},
+				{east, south},
+				{east, north},
+				{west, north},
+				{west, south},
+			}})
+		}
+
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONGeometry{
+				Type:        "MultiPolygon",
+				Coordinates: polygons,
+			},
+			Properties: map[string]interface{}{
+				"min_seconds": int64(minTime.Seconds()),
+				"max_seconds": int64(opts.Bands[band].Seconds()),
+			},
+		})
+	}
+
+	return json.Marshal(fc)
+}
+
+// cellStrips merges horizontally adjacent cells into strips, returning the
+// first and last cell of each strip.
+func cellStrips(cells []cell) [][2]cell {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].row != cells[j].row {
+			return cells[i].row < cells[j].row
+		}
+
+		return cells[i].col < cells[j].col
+	})
+
+	var res [][2]cell
+	for _, c := range cells {
+		if n := len(res); n > 0 && res[n-1][1].row == c.row && res[n-1][1].col == c.col-1 {
+			res[n-1][1] = c
+			continue
+		}
+
+		res = append(res, [2]cell{c, c})
+	}
+
+	return res
+}
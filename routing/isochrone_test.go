@@ -0,0 +1,112 @@
+package routing
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRouter_TravelTimes(t *testing.T) {
+	f := newTestFeed()
+
+	r, err := NewRouter(f.GTFS, testDate, Options{MaxWalkDistance: 200})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	got := r.TravelTimes(f.stops["A"], mustParseTime(t, "07:55:00"))
+
+	want := map[string]string{
+		"A": "07:55:00",
+		"B": "08:10:00",
+		"C": "08:20:00",
+		"D": "08:30:00",
+	}
+	if len(got) != len(want)+1 {
+		t.Errorf("Router.TravelTimes() returned %d stops, want %d", len(got), len(want)+1)
+	}
+
+	for id, wantTime := range want {
+		if got[f.stops[id]] != mustParseTime(t, wantTime) {
+			t.Errorf("Router.TravelTimes()[%s] = %v, want %s", id, got[f.stops[id]], wantTime)
+		}
+	}
+
+	// E is about 100 meters from C, or 125 meters with the default detour
+	// factor
+	walk := got[f.stops["E"]] - mustParseTime(t, "08:20:00")
+	if walk < 90*time.Second || walk > 100*time.Second {
+		t.Errorf("Router.TravelTimes()[E] = %v, want about 97s after arriving at C", got[f.stops["E"]])
+	}
+}
+
+func TestRouter_Isochrones(t *testing.T) {
+	f := newTestFeed()
+
+	r, err := NewRouter(f.GTFS, testDate, Options{})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	if _, err := r.Isochrones(f.stops["A"], 0, IsochroneOptions{}); err == nil {
+		t.Errorf("Router.Isochrones() with no bands error = nil, want error")
+	}
+
+	if _, err := r.Isochrones(f.stops["A"], 0, IsochroneOptions{Bands: []time.Duration{time.Hour, time.Minute}}); err == nil {
+		t.Errorf("Router.Isochrones() with unordered bands error = nil, want error")
+	}
+
+	data, err := r.Isochrones(f.stops["A"], mustParseTime(t, "07:55:00"), IsochroneOptions{
+		Bands: []time.Duration{5 * time.Minute, 20 * time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("Router.Isochrones() error = %v", err)
+	}
+
+	var fc struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates [][][][2]float64
+			}
+			Properties map[string]float64
+		}
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 2 {
+		t.Fatalf("Router.Isochrones() = %s, want FeatureCollection with 2 features", data)
+	}
+
+	first, second := fc.Features[0], fc.Features[1]
+	if first.Properties["min_seconds"] != 0 || first.Properties["max_seconds"] != 300 {
+		t.Errorf("Router.Isochrones() first band properties = %v, want 0 to 300 seconds", first.Properties)
+	}
+	if second.Properties["min_seconds"] != 300 || second.Properties["max_seconds"] != 1200 {
+		t.Errorf("Router.Isochrones() second band properties = %v, want 300 to 1200 seconds", second.Properties)
+	}
+
+	// Within five minutes, only the area around A can be reached on foot
+	for _, p := range first.Geometry.Coordinates {
+		for _, pos := range p[0] {
+			if pos[1] > 0.005 || pos[1] < -0.005 {
+				t.Errorf("Router.Isochrones() first band includes %v, want only positions near A", pos)
+			}
+		}
+	}
+
+	// Within twenty minutes, B is reached by transit at 08:10
+	nearB := false
+	for _, p := range second.Geometry.Coordinates {
+		ring := p[0]
+		if ring[0][1] <= 0.01 && ring[2][1] >= 0.01 && ring[0][0] <= 0 && ring[1][0] >= 0 {
+			nearB = true
+		}
+	}
+	if !nearB {
+		t.Errorf("Router.Isochrones() second band does not cover B")
+	}
+}
@@ -0,0 +1,144 @@
+package routing
+
+import (
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+// An Itinerary is a single journey between two stops.
+type Itinerary struct {
+	Legs      []*Leg
+	Departure time.Duration
+	Arrival   time.Duration
+
+	// Transfers is the number of times a rider changes vehicles.
+	Transfers int
+}
+
+// A Leg is a single part of an itinerary, made either on board a single trip or
+// by walking between two stops.
+type Leg struct {
+	// Trip is the trip ridden during this leg, or nil for walking legs.
+	Trip *gtfs.Trip
+
+	From      *gtfs.Stop
+	To        *gtfs.Stop
+	Departure time.Duration
+	Arrival   time.Duration
+}
+
+// IsWalking reports whether l is made on foot.
+func (l *Leg) IsWalking() bool {
+	return l.Trip == nil
+}
+
+// Duration returns the total travel time of it.
+func (it *Itinerary) Duration() time.Duration {
+	return it.Arrival - it.Departure
+}
+
+func newItinerary(legs []*Leg, departure time.Duration) *Itinerary {
+	it := &Itinerary{
+		Legs:      legs,
+		Departure: departure,
+		Arrival:   departure,
+	}
+
+	rides := 0
+	for _, l := range legs {
+		if !l.IsWalking() {
+			rides++
+		}
+	}
+
+	if rides > 1 {
+		it.Transfers = rides - 1
+	}
+
+	if len(legs) > 0 {
+		if !legs[0].IsWalking() {
+			it.Departure = legs[0].Departure
+		}
+
+		it.Arrival = legs[len(legs)-1].Arrival
+	}
+
+	return it
+}
+
+type labelKind int
+
+const (
+	labelSource labelKind = iota
+	labelTransit
+	labelWalk
+)
+
+// A label records how a stop was reached.
+//
+// In forward searches, time is the arrival time at stop and prev is the label
+// from which stop was reached. In reverse searches, time is the departure time
+// from stop, prev is the label of the stop reached next, and duration is the
+// duration of walking legs.
+type label struct {
+	kind      labelKind
+	stop      int
+	time      time.Duration
+	run       *tripRun
+	boardPos  int
+	alightPos int
+	duration  time.Duration
+	prev      *label
+}
+
+// itinerary reconstructs the itinerary ending with l.
+func (tt *timetable) itinerary(l *label, departure time.Duration) *Itinerary {
+	var legs []*Leg
+	for ; l != nil && l.kind != labelSource; l = l.prev {
+		leg := &Leg{
+			To:      tt.stops[l.stop],
+			Arrival: l.time,
+		}
+
+		if l.kind == labelTransit {
+			leg.Trip = l.run.trip
+			leg.From = tt.stops[l.run.stops[l.boardPos]]
+			leg.Departure = l.run.departures[l.boardPos]
+		} else {
+			leg.From = tt.stops[l.prev.stop]
+			leg.Departure = l.prev.time
+		}
+
+		legs = append([]*Leg{leg}, legs...)
+	}
+
+	return newItinerary(legs, departure)
+}
+
+// reverseItinerary reconstructs the itinerary beginning with l, which is a label
+// from a reverse search.
+func (tt *timetable) reverseItinerary(l *label) *Itinerary {
+	departure := l.time
+
+	var legs []*Leg
+	for ; l != nil && l.kind != labelSource; l = l.prev {
+		leg := &Leg{
+			From:      tt.stops[l.stop],
+			Departure: l.time,
+		}
+
+		if l.kind == labelTransit {
+			leg.Trip = l.run.trip
+			leg.To = tt.stops[l.run.stops[l.alightPos]]
+			leg.Arrival = l.run.arrivals[l.alightPos]
+		} else {
+			leg.To = tt.stops[l.prev.stop]
+			leg.Arrival = l.time + l.duration
+		}
+
+		legs = append(legs, leg)
+	}
+
+	return newItinerary(legs, departure)
+}
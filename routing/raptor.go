@@ -0,0 +1,359 @@
+package routing
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+// DefaultMaxTransfers is the maximum number of transfers in an itinerary when
+// none is specified.
+const DefaultMaxTransfers = 5
+
+// unreached is the arrival time at stops that have not been reached.
+const unreached = time.Duration(math.MaxInt64)
+
+// Options specifies options used when planning journeys.
+type Options struct {
+	// MaxTransfers is the maximum number of transfers in an itinerary. If
+	// zero, DefaultMaxTransfers is used.
+	MaxTransfers int
+
+	// MinimumChangeTime is the time needed to change vehicles at a single stop
+	// when transfers.txt does not specify a minimum transfer time for it.
+	MinimumChangeTime time.Duration
+
+	// WalkingSpeed is the walking speed, in meters per second, used for
+	// transfers between stops that do not specify a minimum transfer time. If
+	// zero, DefaultWalkingSpeed is used.
+	WalkingSpeed float64
+
+	// Footpaths are additional walking transfers between stops. Transfers in
+	// the feed take precedence over footpaths between the same stops.
+	Footpaths []*gtfs.Transfer
+
+	// MaxWalkDistance is the maximum straight-line distance, in meters,
+	// between stops for which walking transfers are generated using
+	// gtfs.GenerateFootpaths. If zero, only transfers in the feed and
+	// Footpaths are used.
+	MaxWalkDistance float64
+}
+
+// A Router plans journeys on a single service day using the RAPTOR (Round-Based
+// Public Transit Routing) algorithm.
+type Router struct {
+	tt             *timetable
+	transfers      *transferRules
+	patterns       []*pattern
+	patternsByStop [][]patternStop
+	maxTransfers   int
+	walkingSpeed   float64
+}
+
+// A pattern is a set of trip runs along a single route that serve exactly the
+// same sequence of stops.
+//
+// Runs are sorted by departure time. Runs in a pattern are assumed not to
+// overtake one another.
+type pattern struct {
+	stops []int
+	runs  []*tripRun
+}
+
+type patternStop struct {
+	pattern  int
+	position int
+}
+
+// NewRouter creates a Router for journeys on the service day beginning on date.
+func NewRouter(g *gtfs.GTFS, date time.Time, opts Options) (*Router, error) {
+	tt, err := newTimetable(g, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MaxTransfers == 0 {
+		opts.MaxTransfers = DefaultMaxTransfers
+	}
+
+	if opts.WalkingSpeed == 0 {
+		opts.WalkingSpeed = DefaultWalkingSpeed
+	}
+
+	r := &Router{
+		tt:             tt,
+		transfers:      newTransferRules(tt, g, opts),
+		patternsByStop: make([][]patternStop, len(tt.stops)),
+		maxTransfers:   opts.MaxTransfers,
+		walkingSpeed:   opts.WalkingSpeed,
+	}
+
+	r.buildPatterns()
+
+	return r, nil
+}
+
+func (r *Router) buildPatterns() {
+	patternsByKey := map[string]*pattern{}
+	var keys []string
+
+	for _, run := range r.tt.runs {
+		key := patternKey(run)
+
+		p, ok := patternsByKey[key]
+		if !ok {
+			p = &pattern{
+				stops: run.stops,
+			}
+			patternsByKey[key] = p
+			keys = append(keys, key)
+		}
+
+		p.runs = append(p.runs, run)
+	}
+
+	// Iterate over keys in a stable order so that results are deterministic
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		p := patternsByKey[key]
+		sort.SliceStable(p.runs, func(i, j int) bool {
+			return p.runs[i].departures[0] < p.runs[j].departures[0]
+		})
+
+		idx := len(r.patterns)
+		r.patterns = append(r.patterns, p)
+
+		for pos, s := range p.stops {
+			r.patternsByStop[s] = append(r.patternsByStop[s], patternStop{
+				pattern:  idx,
+				position: pos,
+			})
+		}
+	}
+}
+
+func patternKey(run *tripRun) string {
+	var b strings.Builder
+	if run.trip.Route != nil {
+		b.WriteString(run.trip.Route.ID)
+	}
+
+	for _, s := range run.stops {
+		b.WriteByte('|')
+		b.WriteString(strconv.Itoa(s))
+	}
+
+	return b.String()
+}
+
+// Plan finds Pareto-optimal itineraries from one stop to another departing no
+// earlier than departure.
+//
+// Each itinerary returned arrives strictly earlier than all itineraries with
+// fewer transfers, and itineraries are ordered by increasing number of
+// transfers. If from or to is a station, journeys may begin or end at any stop
+// within it.
+func (r *Router) Plan(from, to *gtfs.Stop, departure time.Duration) []*Itinerary {
+	sources := r.tt.stopsAt(from)
+	targets := r.tt.stopsAt(to)
+	if len(sources) == 0 || len(targets) == 0 {
+		return nil
+	}
+
+	var res []*Itinerary
+
+	bestTarget := unreached
+	r.run(sources, departure, func(labels []*label) {
+		var best *label
+		for _, t := range targets {
+			if l := labels[t]; l != nil && (best == nil || l.time < best.time) {
+				best = l
+			}
+		}
+
+		if best != nil && best.time < bestTarget {
+			bestTarget = best.time
+			res = append(res, r.tt.itinerary(best, departure))
+		}
+	}, targets)
+
+	return res
+}
+
+// run performs a RAPTOR search from sources, calling onRound with the labels of
+// all stops after each round. The search stops early if no stops are improved
+// in a round.
+//
+// If targets are specified, arrivals later than the best arrival at any target
+// are pruned.
+func (r *Router) run(sources []int, departure time.Duration, onRound func([]*label), targets []int) {
+	n := len(r.tt.stops)
+
+	arrivals := make([]time.Duration, n)
+	for i := range arrivals {
+		arrivals[i] = unreached
+	}
+
+	labels := make([]*label, n)
+	var marked []int
+
+	for _, s := range sources {
+		arrivals[s] = departure
+		labels[s] = &label{
+			kind: labelSource,
+			stop: s,
+			time: departure,
+		}
+		marked = append(marked, s)
+	}
+
+	bound := func() time.Duration {
+		b := unreached
+		for _, t := range targets {
+			if arrivals[t] < b {
+				b = arrivals[t]
+			}
+		}
+
+		return b
+	}
+
+	marked = append(marked, r.relaxFootpaths(labels, arrivals, labels, marked, bound)...)
+	onRound(labels)
+
+	for round := 1; round <= r.maxTransfers+1 && len(marked) > 0; round++ {
+		prevLabels := append([]*label{}, labels...)
+		transitLabels := make([]*label, n)
+
+		queue := map[int]int{}
+		for _, s := range marked {
+			for _, ps := range r.patternsByStop[s] {
+				if pos, ok := queue[ps.pattern]; !ok || ps.position < pos {
+					queue[ps.pattern] = ps.position
+				}
+			}
+		}
+
+		var improved []int
+		for _, idx := range sortedKeys(queue) {
+			improved = append(improved, r.scanPattern(r.patterns[idx], queue[idx], prevLabels, labels, arrivals, transitLabels, bound)...)
+		}
+
+		improved = append(improved, r.relaxFootpaths(transitLabels, arrivals, labels, improved, bound)...)
+		marked = improved
+
+		onRound(labels)
+	}
+}
+
+// scanPattern traverses p from position start, boarding the earliest possible
+// run at each stop reached in the previous round and recording improved
+// arrivals.
+func (r *Router) scanPattern(p *pattern, start int, prevLabels, labels []*label, arrivals []time.Duration, transitLabels []*label, bound func() time.Duration) []int {
+	var improved []int
+
+	var cur *tripRun
+	curIdx := -1
+	boardPos := 0
+	var boardLabel *label
+
+	for i := start; i < len(p.stops); i++ {
+		s := p.stops[i]
+
+		if cur != nil && cur.canAlight[i] {
+			a := cur.arrivals[i]
+			if a < arrivals[s] && a < bound() {
+				l := &label{
+					kind:      labelTransit,
+					stop:      s,
+					time:      a,
+					run:       cur,
+					boardPos:  boardPos,
+					alightPos: i,
+					prev:      boardLabel,
+				}
+
+				arrivals[s] = a
+				labels[s] = l
+				transitLabels[s] = l
+				improved = append(improved, s)
+			}
+		}
+
+		prev := prevLabels[s]
+		if prev == nil {
+			continue
+		}
+
+		ready, ok := r.transfers.readyTime(prev)
+		if !ok || (cur != nil && ready > cur.departures[i]) {
+			continue
+		}
+
+		limit := len(p.runs)
+		if cur != nil {
+			limit = curIdx
+		}
+
+		idx := sort.Search(limit, func(j int) bool {
+			return p.runs[j].departures[i] >= ready
+		})
+		for idx < limit && !p.runs[idx].canBoard[i] {
+			idx++
+		}
+
+		if idx < limit {
+			cur = p.runs[idx]
+			curIdx = idx
+			boardPos = i
+			boardLabel = prev
+		}
+	}
+
+	return improved
+}
+
+// relaxFootpaths walks from each of the stops in from, as reached according to
+// fromLabels, recording any improved arrivals.
+func (r *Router) relaxFootpaths(fromLabels []*label, arrivals []time.Duration, labels []*label, from []int, bound func() time.Duration) []int {
+	var improved []int
+	for _, s := range from {
+		l := fromLabels[s]
+		if l == nil {
+			continue
+		}
+
+		for _, fp := range r.transfers.footpaths[s] {
+			a := l.time + fp.duration
+			if a >= arrivals[fp.to] || a >= bound() {
+				continue
+			}
+
+			arrivals[fp.to] = a
+			labels[fp.to] = &label{
+				kind: labelWalk,
+				stop: fp.to,
+				time: a,
+				prev: l,
+			}
+			improved = append(improved, fp.to)
+		}
+	}
+
+	return improved
+}
+
+func sortedKeys(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
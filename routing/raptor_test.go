@@ -0,0 +1,301 @@
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+var testDate = time.Date(2019, time.July, 3, 0, 0, 0, 0, time.UTC)
+
+// newTestTrip creates a trip serving stops at the given times, using each time
+// as both the arrival and departure time.
+func newTestTrip(id string, r *gtfs.Route, s *gtfs.Service, stops []*gtfs.Stop, times []string) *gtfs.Trip {
+	t := &gtfs.Trip{
+		ID:            id,
+		Route:         r,
+		Service:       s,
+		AbsoluteTimes: true,
+	}
+
+	for i, stop := range stops {
+		t.Stops = append(t.Stops, &gtfs.StopTime{
+			Stop:          stop,
+			ArrivalTime:   times[i],
+			DepartureTime: times[i],
+			Sequence:      uint64(i + 1),
+		})
+	}
+
+	return t
+}
+
+type testFeed struct {
+	*gtfs.GTFS
+	stops map[string]*gtfs.Stop
+	trips map[string]*gtfs.Trip
+}
+
+// newTestFeed creates a small feed with the following trips:
+//
+//	Route 1: A 08:00 -> B 08:10 -> C 08:20
+//	Route 1: A 08:30 -> B 08:40 -> C 08:50
+//	Route 2: B 08:15 -> D 08:30
+//	Route 3: A 08:05 -> D 09:00
+//
+// Stop E is 100 meters from stop C.
+func newTestFeed() *testFeed {
+	service := &gtfs.Service{
+		ID:        "daily",
+		Monday:    true,
+		Tuesday:   true,
+		Wednesday: true,
+		Thursday:  true,
+		Friday:    true,
+		Saturday:  true,
+		Sunday:    true,
+		StartDate: "20190101",
+		EndDate:   "20191231",
+	}
+
+	f := &testFeed{
+		GTFS: &gtfs.GTFS{
+			Services: []*gtfs.Service{service},
+		},
+		stops: map[string]*gtfs.Stop{},
+		trips: map[string]*gtfs.Trip{},
+	}
+
+	for i, id := range []string{"A", "B", "C", "D", "E"} {
+		s := &gtfs.Stop{
+			ID:        id,
+			Latitude:  float64(i) * 0.01,
+			Longitude: 0,
+		}
+		f.Stops = append(f.Stops, s)
+		f.stops[id] = s
+	}
+	f.stops["E"].Latitude = f.stops["C"].Latitude + 0.0009
+
+	route1 := &gtfs.Route{ID: "1"}
+	route2 := &gtfs.Route{ID: "2"}
+	route3 := &gtfs.Route{ID: "3"}
+	f.Routes = []*gtfs.Route{route1, route2, route3}
+
+	a, b, c, d := f.stops["A"], f.stops["B"], f.stops["C"], f.stops["D"]
+	for _, t := range []*gtfs.Trip{
+		newTestTrip("1a", route1, service, []*gtfs.Stop{a, b, c}, []string{"08:00:00", "08:10:00", "08:20:00"}),
+		newTestTrip("1b", route1, service, []*gtfs.Stop{a, b, c}, []string{"08:30:00", "08:40:00", "08:50:00"}),
+		newTestTrip("2a", route2, service, []*gtfs.Stop{b, d}, []string{"08:15:00", "08:30:00"}),
+		newTestTrip("3a", route3, service, []*gtfs.Stop{a, d}, []string{"08:05:00", "09:00:00"}),
+	} {
+		f.Trips = append(f.Trips, t)
+		f.trips[t.ID] = t
+	}
+
+	return f
+}
+
+func mustParseTime(t *testing.T, val string) time.Duration {
+	d, err := gtfs.ParseTime(val)
+	if err != nil {
+		t.Fatalf("gtfs.ParseTime() error = %v", err)
+	}
+
+	return d
+}
+
+func TestRouter_Plan(t *testing.T) {
+	f := newTestFeed()
+
+	r, err := NewRouter(f.GTFS, testDate, Options{})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	its := r.Plan(f.stops["A"], f.stops["D"], mustParseTime(t, "07:55:00"))
+	if len(its) != 2 {
+		t.Fatalf("Router.Plan() returned %d itineraries, want 2", len(its))
+	}
+
+	direct, transfer := its[0], its[1]
+	if direct.Transfers != 0 || direct.Arrival != mustParseTime(t, "09:00:00") || len(direct.Legs) != 1 || direct.Legs[0].Trip != f.trips["3a"] {
+		t.Errorf("Router.Plan() first itinerary = %+v, want direct trip 3a arriving at 09:00", direct)
+	}
+
+	if transfer.Transfers != 1 || transfer.Arrival != mustParseTime(t, "08:30:00") || len(transfer.Legs) != 2 {
+		t.Fatalf("Router.Plan() second itinerary = %+v, want one transfer arriving at 08:30", transfer)
+	}
+
+	first, second := transfer.Legs[0], transfer.Legs[1]
+	if first.Trip != f.trips["1a"] || first.From != f.stops["A"] || first.To != f.stops["B"] || first.Departure != mustParseTime(t, "08:00:00") || first.Arrival != mustParseTime(t, "08:10:00") {
+		t.Errorf("Router.Plan() first leg = %+v, want trip 1a from A at 08:00 to B at 08:10", first)
+	}
+	if second.Trip != f.trips["2a"] || second.From != f.stops["B"] || second.To != f.stops["D"] || second.Departure != mustParseTime(t, "08:15:00") {
+		t.Errorf("Router.Plan() second leg = %+v, want trip 2a from B at 08:15 to D", second)
+	}
+	if transfer.Departure != mustParseTime(t, "08:00:00") || transfer.Duration() != 30*time.Minute {
+		t.Errorf("Router.Plan() departure = %v, duration = %v, want 08:00 and 30m", transfer.Departure, transfer.Duration())
+	}
+}
+
+func TestRouter_Plan_transferRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		transfer *gtfs.Transfer
+		opts     Options
+		want     int
+	}{
+		{
+			name:     "Minimum Transfer Time Too Long",
+			transfer: &gtfs.Transfer{Type: gtfs.TransferTypeMinimumTime, MinimumTransferTime: 600},
+			want:     1,
+		},
+		{
+			name:     "Minimum Transfer Time Short Enough",
+			transfer: &gtfs.Transfer{Type: gtfs.TransferTypeMinimumTime, MinimumTransferTime: 300},
+			want:     2,
+		},
+		{
+			name:     "Transfer Not Possible",
+			transfer: &gtfs.Transfer{Type: gtfs.TransferTypeNone},
+			want:     1,
+		},
+		{
+			name: "Default Change Time",
+			opts: Options{
+				MinimumChangeTime: 6 * time.Minute,
+			},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFeed()
+			if tt.transfer != nil {
+				tt.transfer.From = f.stops["B"]
+				tt.transfer.To = f.stops["B"]
+				f.Transfers = []*gtfs.Transfer{tt.transfer}
+			}
+
+			r, err := NewRouter(f.GTFS, testDate, tt.opts)
+			if err != nil {
+				t.Fatalf("NewRouter() error = %v", err)
+			}
+
+			if got := r.Plan(f.stops["A"], f.stops["D"], mustParseTime(t, "07:55:00")); len(got) != tt.want {
+				t.Errorf("Router.Plan() returned %d itineraries, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_Plan_footpaths(t *testing.T) {
+	f := newTestFeed()
+
+	walk := &gtfs.Transfer{
+		From:                f.stops["C"],
+		To:                  f.stops["E"],
+		Type:                gtfs.TransferTypeMinimumTime,
+		MinimumTransferTime: 120,
+	}
+
+	r, err := NewRouter(f.GTFS, testDate, Options{Footpaths: []*gtfs.Transfer{walk}})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	its := r.Plan(f.stops["A"], f.stops["E"], mustParseTime(t, "07:55:00"))
+	if len(its) != 1 {
+		t.Fatalf("Router.Plan() returned %d itineraries, want 1", len(its))
+	}
+
+	it := its[0]
+	if it.Arrival != mustParseTime(t, "08:22:00") || it.Transfers != 0 || len(it.Legs) != 2 || !it.Legs[1].IsWalking() {
+		t.Errorf("Router.Plan() = %+v, want ride to C then walk to E arriving at 08:22", it)
+	}
+
+	// Explicit transfers take precedence over footpaths
+	f.Transfers = []*gtfs.Transfer{{From: f.stops["C"], To: f.stops["E"], Type: gtfs.TransferTypeNone}}
+	r, err = NewRouter(f.GTFS, testDate, Options{Footpaths: []*gtfs.Transfer{walk}})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	if got := r.Plan(f.stops["A"], f.stops["E"], mustParseTime(t, "07:55:00")); len(got) != 0 {
+		t.Errorf("Router.Plan() returned %d itineraries, want 0", len(got))
+	}
+	// Transfers restricted to specific routes don't affect footpaths
+	f.Transfers[0].FromRoute = f.Routes[0]
+	r, err = NewRouter(f.GTFS, testDate, Options{Footpaths: []*gtfs.Transfer{walk}})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	if got := r.Plan(f.stops["A"], f.stops["E"], mustParseTime(t, "07:55:00")); len(got) != 1 {
+		t.Errorf("Router.Plan() returned %d itineraries, want 1", len(got))
+	}
+}
+
+func TestRouter_Plan_serviceDays(t *testing.T) {
+	f := newTestFeed()
+
+	// A late-night trip from the previous service day
+	late := newTestTrip("late", f.Routes[0], f.Services[0], []*gtfs.Stop{f.stops["C"], f.stops["E"]}, []string{"24:30:00", "24:40:00"})
+	f.Trips = append(f.Trips, late)
+
+	r, err := NewRouter(f.GTFS, testDate, Options{})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	its := r.Plan(f.stops["C"], f.stops["E"], mustParseTime(t, "00:00:00"))
+	if len(its) != 1 || its[0].Legs[0].Trip != late || its[0].Arrival != mustParseTime(t, "00:40:00") {
+		t.Errorf("Router.Plan() = %+v, want previous day's trip arriving at 00:40", its)
+	}
+
+	// No service on a date outside of the calendar
+	r, err = NewRouter(f.GTFS, time.Date(2020, time.July, 3, 0, 0, 0, 0, time.UTC), Options{})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	if got := r.Plan(f.stops["A"], f.stops["D"], mustParseTime(t, "07:55:00")); len(got) != 0 {
+		t.Errorf("Router.Plan() returned %d itineraries, want 0", len(got))
+	}
+}
+
+func TestRouter_Plan_pickupDropoff(t *testing.T) {
+	f := newTestFeed()
+	f.trips["1a"].Stops[1].DropoffType = gtfs.DropoffTypeNone
+
+	r, err := NewRouter(f.GTFS, testDate, Options{})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	its := r.Plan(f.stops["A"], f.stops["D"], mustParseTime(t, "07:55:00"))
+	if len(its) != 1 || its[0].Legs[0].Trip != f.trips["3a"] {
+		t.Errorf("Router.Plan() = %+v, want only direct trip 3a", its)
+	}
+}
+
+func TestRouter_Plan_frequencies(t *testing.T) {
+	f := newTestFeed()
+
+	freq := newTestTrip("freq", f.Routes[1], f.Services[0], []*gtfs.Stop{f.stops["C"], f.stops["E"]}, []string{"00:00:00", "00:05:00"})
+	freq.AbsoluteTimes = false
+	freq.Frequencies = []gtfs.Frequency{{StartTime: "08:00:00", EndTime: "09:00:00", HeadwaySeconds: 900}}
+	f.Trips = append(f.Trips, freq)
+
+	r, err := NewRouter(f.GTFS, testDate, Options{})
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+
+	its := r.Plan(f.stops["C"], f.stops["E"], mustParseTime(t, "08:20:00"))
+	if len(its) != 1 || its[0].Departure != mustParseTime(t, "08:30:00") || its[0].Arrival != mustParseTime(t, "08:35:00") {
+		t.Errorf("Router.Plan() = %+v, want departure at 08:30 arriving at 08:35", its)
+	}
+}
@@ -0,0 +1,168 @@
+package routing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+// day is the length of a service day, used to include trips from the previous
+// service day that are still operating after midnight.
+const day = 24 * time.Hour
+
+// A tripRun is a single run of a trip on the queried service day, containing
+// only the stops at which the trip has scheduled times.
+//
+// Times are relative to the start of the queried service day, so runs of trips
+// from the previous service day have times shifted back by 24 hours.
+type tripRun struct {
+	trip       *gtfs.Trip
+	stops      []int
+	stopTimes  []*gtfs.StopTime
+	arrivals   []time.Duration
+	departures []time.Duration
+	canBoard   []bool
+	canAlight  []bool
+}
+
+// A timetable contains all trip runs operating on a single service day.
+type timetable struct {
+	stops     []*gtfs.Stop
+	stopIndex map[*gtfs.Stop]int
+	runs      []*tripRun
+}
+
+func newTimetable(g *gtfs.GTFS, date time.Time) (*timetable, error) {
+	tt := &timetable{
+		stops:     g.Stops,
+		stopIndex: make(map[*gtfs.Stop]int, len(g.Stops)),
+	}
+
+	for i, s := range g.Stops {
+		tt.stopIndex[s] = i
+	}
+
+	prevDate := date.AddDate(0, 0, -1)
+	for _, t := range g.Trips {
+		if t.Service == nil {
+			continue
+		}
+
+		var shifts []time.Duration
+		if t.Service.IsActiveOn(date) {
+			shifts = append(shifts, 0)
+		}
+
+		if t.Service.IsActiveOn(prevDate) {
+			shifts = append(shifts, -day)
+		}
+
+		if len(shifts) == 0 {
+			continue
+		}
+
+		offsets, err := t.InstanceOffsets()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, shift := range shifts {
+			for _, offset := range offsets {
+				run, err := tt.newTripRun(t, shift+offset)
+				if err != nil {
+					return nil, err
+				}
+
+				// Skip runs that don't serve at least two stops or that
+				// finished before the queried service day began
+				if run == nil || run.arrivals[len(run.arrivals)-1] < 0 {
+					continue
+				}
+
+				tt.runs = append(tt.runs, run)
+			}
+		}
+	}
+
+	return tt, nil
+}
+
+func (tt *timetable) newTripRun(t *gtfs.Trip, offset time.Duration) (*tripRun, error) {
+	run := &tripRun{
+		trip: t,
+	}
+
+	for _, st := range t.Stops {
+		idx, ok := tt.stopIndex[st.Stop]
+		if st.Stop == nil || !ok {
+			continue
+		}
+
+		arrival, departure, ok, err := stopTimeOffsets(st)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stop time for trip %s: %v", t.ID, err)
+		}
+
+		if !ok {
+			continue
+		}
+
+		run.stops = append(run.stops, idx)
+		run.stopTimes = append(run.stopTimes, st)
+		run.arrivals = append(run.arrivals, arrival+offset)
+		run.departures = append(run.departures, departure+offset)
+		run.canBoard = append(run.canBoard, st.PickupType != gtfs.PickupTypeNone)
+		run.canAlight = append(run.canAlight, st.DropoffType != gtfs.DropoffTypeNone)
+	}
+
+	if len(run.stops) < 2 {
+		return nil, nil
+	}
+
+	return run, nil
+}
+
+// stopTimeOffsets returns the arrival and departure offsets of st, along with
+// whether st has a scheduled time at all.
+//
+// If only one of the arrival and departure times is set, it is used for both.
+func stopTimeOffsets(st *gtfs.StopTime) (time.Duration, time.Duration, bool, error) {
+	arrStr, depStr := st.ArrivalTime, st.DepartureTime
+	if arrStr == "" {
+		arrStr = depStr
+	}
+
+	if depStr == "" {
+		depStr = arrStr
+	}
+
+	if arrStr == "" {
+		return 0, 0, false, nil
+	}
+
+	arrival, err := gtfs.ParseTime(arrStr)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	departure, err := gtfs.ParseTime(depStr)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	return arrival, departure, true, nil
+}
+
+// stopsAt returns the indexes of s and, if s is a station, all of the stops
+// within it.
+func (tt *timetable) stopsAt(s *gtfs.Stop) []int {
+	var res []int
+	for i, candidate := range tt.stops {
+		if candidate == s || (candidate.ParentStation == s && s.LocationType == gtfs.LocationTypeStation) {
+			res = append(res, i)
+		}
+	}
+
+	return res
+}
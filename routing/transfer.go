@@ -0,0 +1,157 @@
+package routing
+
+import (
+	"math"
+	"time"
+
+	"github.com/dpearson/gtfs"
+)
+
+// DefaultWalkingSpeed is the walking speed, in meters per second, used when
+// none is specified.
+const DefaultWalkingSpeed = gtfs.DefaultWalkingSpeed
+
+// A footpath is a walking connection from one stop to another.
+type footpath struct {
+	from     int
+	to       int
+	duration time.Duration
+}
+
+// transferRules holds the transfer rules between stops derived from a feed's
+// transfers and any additional footpaths.
+type transferRules struct {
+	footpaths   [][]footpath
+	footpathsTo [][]footpath
+	changeTimes []time.Duration
+	noTransfer  []bool
+}
+
+// newTransferRules builds transfer rules for the stops in tt from the feed's
+// transfers and the footpaths in opts.
+//
+// Transfers in the feed take precedence over footpaths in opts, which in turn
+// take precedence over footpaths between nearby stops.
+func newTransferRules(tt *timetable, g *gtfs.GTFS, opts Options) *transferRules {
+	tr := &transferRules{
+		footpaths:   make([][]footpath, len(tt.stops)),
+		footpathsTo: make([][]footpath, len(tt.stops)),
+		changeTimes: make([]time.Duration, len(tt.stops)),
+		noTransfer:  make([]bool, len(tt.stops)),
+	}
+
+	for i := range tr.changeTimes {
+		tr.changeTimes[i] = opts.MinimumChangeTime
+	}
+
+	transfers := append(append([]*gtfs.Transfer{}, g.Transfers...), opts.Footpaths...)
+	if opts.MaxWalkDistance > 0 {
+		transfers = append(transfers, g.GenerateFootpaths(gtfs.FootpathOptions{
+			MaxDistance:  opts.MaxWalkDistance,
+			WalkingSpeed: opts.WalkingSpeed,
+		})...)
+	}
+
+	seen := map[[2]*gtfs.Stop]bool{}
+	for _, t := range transfers {
+		// Transfers restricted to specific routes or trips, including in-seat
+		// transfers, don't describe footpaths
+		if t.From == nil || t.To == nil || !t.AppliesToAllTrips() {
+			continue
+		}
+
+		key := [2]*gtfs.Stop{t.From, t.To}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		from, ok := tt.stopIndex[t.From]
+		if !ok {
+			continue
+		}
+
+		to, ok := tt.stopIndex[t.To]
+		if !ok {
+			continue
+		}
+
+		if from == to {
+			switch t.Type {
+			case gtfs.TransferTypeMinimumTime:
+				tr.changeTimes[from] = time.Duration(t.MinimumTransferTime) * time.Second
+			case gtfs.TransferTypeNone:
+				tr.noTransfer[from] = true
+			}
+
+			continue
+		}
+
+		var duration time.Duration
+		switch t.Type {
+		case gtfs.TransferTypeNone:
+			continue
+		case gtfs.TransferTypeMinimumTime:
+			duration = time.Duration(t.MinimumTransferTime) * time.Second
+		default:
+			duration = footpathTime(t.From, t.To, opts.WalkingSpeed)
+		}
+
+		tr.add(footpath{
+			from:     from,
+			to:       to,
+			duration: duration,
+		})
+	}
+
+	return tr
+}
+
+func (tr *transferRules) add(fp footpath) {
+	tr.footpaths[fp.from] = append(tr.footpaths[fp.from], fp)
+	tr.footpathsTo[fp.to] = append(tr.footpathsTo[fp.to], fp)
+}
+
+// walkingTime returns the time needed to walk distance meters at walkingSpeed
+// meters per second.
+func walkingTime(distance, walkingSpeed float64) time.Duration {
+	return time.Duration(distance / walkingSpeed * float64(time.Second))
+}
+
+// footpathTime returns the time needed to walk from one stop to another at
+// walkingSpeed meters per second, using the same detour factor and rounding as
+// gtfs.GenerateFootpaths so that explicit and generated footpaths agree.
+func footpathTime(from, to *gtfs.Stop, walkingSpeed float64) time.Duration {
+	distance := from.DistanceTo(to) * gtfs.DefaultDetourFactor
+	return time.Duration(math.Ceil(distance/walkingSpeed)) * time.Second
+}
+
+// readyTime returns the earliest time at which a rider who reached a stop as
+// described by l can board a vehicle there, along with whether boarding is
+// possible at all.
+func (tr *transferRules) readyTime(l *label) (time.Duration, bool) {
+	if l.kind != labelTransit {
+		return l.time, true
+	}
+
+	if tr.noTransfer[l.stop] {
+		return 0, false
+	}
+
+	return l.time + tr.changeTimes[l.stop], true
+}
+
+// latestArrival returns the latest time at which a rider must arrive at a stop
+// in order to continue their journey as described by l, which is a label from
+// a reverse search, along with whether arriving by vehicle is possible at all.
+func (tr *transferRules) latestArrival(l *label) (time.Duration, bool) {
+	if l.kind != labelTransit {
+		return l.time, true
+	}
+
+	if tr.noTransfer[l.stop] {
+		return 0, false
+	}
+
+	return l.time - tr.changeTimes[l.stop], true
+}
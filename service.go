@@ -3,6 +3,7 @@ package gtfs
 import (
 	"fmt"
 	"io"
+	"time"
 )
 
 // A Service is a schedule of service over one or more routes.
@@ -146,6 +147,47 @@ func (g *GTFS) processServiceDates(r io.Reader) error {
 	return nil
 }
 
+// IsActiveOn reports whether s operates on the service day beginning on date.
+//
+// Dates added or removed in calendar_dates.txt take precedence over the
+// weekly schedule in calendar.txt.
+func (s *Service) IsActiveOn(date time.Time) bool {
+	d := date.Format(dateFormat)
+
+	for _, except := range s.ExceptDates {
+		if except == d {
+			return false
+		}
+	}
+
+	for _, additional := range s.AdditionalDates {
+		if additional == d {
+			return true
+		}
+	}
+
+	if s.StartDate == "" || d < s.StartDate || d > s.EndDate {
+		return false
+	}
+
+	switch date.Weekday() {
+	case time.Monday:
+		return s.Monday
+	case time.Tuesday:
+		return s.Tuesday
+	case time.Wednesday:
+		return s.Wednesday
+	case time.Thursday:
+		return s.Thursday
+	case time.Friday:
+		return s.Friday
+	case time.Saturday:
+		return s.Saturday
+	default:
+		return s.Sunday
+	}
+}
+
 func (g *GTFS) serviceByID(id string) *Service {
 	return g.servicesByID[id]
 }
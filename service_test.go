@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 const testServicesCSVValid = `service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date
@@ -324,3 +325,84 @@ func TestGTFS_serviceByID(t *testing.T) {
 		})
 	}
 }
+
+func TestService_IsActiveOn(t *testing.T) {
+	weekdays := &Service{
+		ID:              "weekdays",
+		Monday:          true,
+		Tuesday:         true,
+		Wednesday:       true,
+		Thursday:        true,
+		Friday:          true,
+		StartDate:       "20190101",
+		EndDate:         "20191231",
+		AdditionalDates: []string{"20190720"},
+		ExceptDates:     []string{"20190704"},
+	}
+	datesOnly := &Service{
+		ID:              "dates",
+		AdditionalDates: []string{"20190721"},
+	}
+	tests := []struct {
+		name    string
+		service *Service
+		date    time.Time
+		want    bool
+	}{
+		{
+			name:    "Weekday",
+			service: weekdays,
+			date:    time.Date(2019, time.July, 3, 0, 0, 0, 0, time.UTC),
+			want:    true,
+		},
+		{
+			name:    "Weekend",
+			service: weekdays,
+			date:    time.Date(2019, time.July, 6, 0, 0, 0, 0, time.UTC),
+			want:    false,
+		},
+		{
+			name:    "Removed Date",
+			service: weekdays,
+			date:    time.Date(2019, time.July, 4, 0, 0, 0, 0, time.UTC),
+			want:    false,
+		},
+		{
+			name:    "Added Date",
+			service: weekdays,
+			date:    time.Date(2019, time.July, 20, 0, 0, 0, 0, time.UTC),
+			want:    true,
+		},
+		{
+			name:    "Before Start",
+			service: weekdays,
+			date:    time.Date(2018, time.December, 31, 0, 0, 0, 0, time.UTC),
+			want:    false,
+		},
+		{
+			name:    "After End",
+			service: weekdays,
+			date:    time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+			want:    false,
+		},
+		{
+			name:    "Dates Only (active)",
+			service: datesOnly,
+			date:    time.Date(2019, time.July, 21, 0, 0, 0, 0, time.UTC),
+			want:    true,
+		},
+		{
+			name:    "Dates Only (inactive)",
+			service: datesOnly,
+			date:    time.Date(2019, time.July, 22, 0, 0, 0, 0, time.UTC),
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.service.IsActiveOn(tt.date); got != tt.want {
+				t.Errorf("Service.IsActiveOn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
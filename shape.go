@@ -94,3 +94,14 @@ func (g *GTFS) processShapes(r io.Reader) error {
 func (g *GTFS) shapeByID(id string) *Shape {
 	return g.shapesByID[id]
 }
+
+// Length returns the length, in meters, of the path described by s.
+func (s *Shape) Length() float64 {
+	length := 0.0
+	for i := 1; i < len(s.Points); i++ {
+		prev, pt := s.Points[i-1], s.Points[i]
+		length += haversine(prev.Latitude, prev.Longitude, pt.Latitude, pt.Longitude)
+	}
+
+	return length
+}
@@ -0,0 +1,190 @@
+package gtfs
+
+import "math"
+
+// DefaultMaxStopShapeDistance is the default distance, in meters, beyond which
+// a stop is considered to be far from its trip's shape.
+const DefaultMaxStopShapeDistance = 100
+
+// ShapeDistanceOptions specifies options used when computing distances along
+// shapes.
+type ShapeDistanceOptions struct {
+	// Unit is the unit in which distances are stored.
+	Unit DistanceUnit
+
+	// MaxStopDistance is the distance, in meters, beyond which a stop is
+	// considered to be far from its trip's shape. If zero,
+	// DefaultMaxStopShapeDistance is used.
+	MaxStopDistance float64
+}
+
+// A ShapeDistanceIssue is a stop that is far from the shape of a trip serving
+// it.
+type ShapeDistanceIssue struct {
+	Trip     *Trip
+	StopTime *StopTime
+
+	// Distance is the distance, in meters, from the stop to the point on the
+	// shape it was projected onto.
+	Distance float64
+}
+
+// ComputeDistances sets the distance of each point in s to the cumulative
+// distance along s, measured in unit.
+func (s *Shape) ComputeDistances(unit DistanceUnit) {
+	dist := 0.0
+	for i, pt := range s.Points {
+		if i > 0 {
+			prev := s.Points[i-1]
+			dist += haversine(prev.Latitude, prev.Longitude, pt.Latitude, pt.Longitude)
+		}
+
+		pt.Distance = unit.FromMeters(dist)
+	}
+}
+
+// ComputeShapeDistances fills in the distances of all shape points and stop
+// times in g from their geometry, overwriting any existing distances. It
+// returns every stop time whose stop is far from its trip's shape.
+//
+// Shape point distances are cumulative distances along each shape. Each stop
+// time of a trip with a shape is projected onto the shape, in order, such that
+// no stop is projected to an earlier point than the stop before it. Stops are
+// projected onto the first part of the remaining shape that passes within
+// opts.MaxStopDistance of them, so that stops on looping shapes are projected
+// correctly. Stop times of trips without shapes aren't modified.
+func (g *GTFS) ComputeShapeDistances(opts ShapeDistanceOptions) []*ShapeDistanceIssue {
+	if opts.MaxStopDistance == 0 {
+		opts.MaxStopDistance = DefaultMaxStopShapeDistance
+	}
+
+	for _, s := range g.Shapes {
+		s.ComputeDistances(opts.Unit)
+	}
+
+	var res []*ShapeDistanceIssue
+	for _, t := range g.Trips {
+		res = append(res, t.computeShapeDistances(opts)...)
+	}
+
+	return res
+}
+
+// computeShapeDistances sets the distance along t's shape of each of its stop
+// times, returning every stop time whose stop is far from the shape. opts must
+// already have defaults applied.
+func (t *Trip) computeShapeDistances(opts ShapeDistanceOptions) []*ShapeDistanceIssue {
+	if t.Shape == nil || len(t.Shape.Points) == 0 {
+		return nil
+	}
+
+	var res []*ShapeDistanceIssue
+	p := newShapeProjector(t.Shape)
+	for _, st := range t.Stops {
+		if st.Stop == nil {
+			continue
+		}
+
+		along, dist := p.project(st.Stop.Latitude, st.Stop.Longitude, opts.MaxStopDistance)
+		st.ShapeDistanceTraveled = opts.Unit.FromMeters(along)
+
+		if dist > opts.MaxStopDistance {
+			res = append(res, &ShapeDistanceIssue{
+				Trip:     t,
+				StopTime: st,
+				Distance: dist,
+			})
+		}
+	}
+
+	return res
+}
+
+// A shapeProjector projects points onto a shape in order, never moving
+// backwards along the shape.
+type shapeProjector struct {
+	points []*ShapePoint
+
+	// cumulative contains the distance, in meters, along the shape to each
+	// point.
+	cumulative []float64
+
+	// segment and fraction specify the position of the last projected point:
+	// fraction is the proportion of the way from points[segment] to
+	// points[segment+1].
+	segment  int
+	fraction float64
+}
+
+func newShapeProjector(s *Shape) *shapeProjector {
+	cumulative := make([]float64, len(s.Points))
+	for i := 1; i < len(s.Points); i++ {
+		prev, pt := s.Points[i-1], s.Points[i]
+		cumulative[i] = cumulative[i-1] + haversine(prev.Latitude, prev.Longitude, pt.Latitude, pt.Longitude)
+	}
+
+	return &shapeProjector{
+		points:     s.Points,
+		cumulative: cumulative,
+	}
+}
+
+// project projects the point at lat and lon onto the remainder of the shape,
+// returning the distance along the shape to the projected point and the
+// distance from the point to the shape, both in meters.
+//
+// The point is projected onto the first segment within maxDistance of it,
+// moving on to later segments while they are closer. If no segment is within
+// maxDistance, the closest segment is used.
+func (p *shapeProjector) project(lat, lon, maxDistance float64) (float64, float64) {
+	if len(p.points) == 1 {
+		pt := p.points[0]
+		return 0, haversine(lat, lon, pt.Latitude, pt.Longitude)
+	}
+
+	best := -1
+	var bestFraction, bestDist float64
+	for i := p.segment; i < len(p.points)-1; i++ {
+		minFraction := 0.0
+		if i == p.segment {
+			minFraction = p.fraction
+		}
+
+		fraction, dist := projectOntoSegment(p.points[i], p.points[i+1], lat, lon, minFraction)
+
+		if best >= 0 && bestDist <= maxDistance && dist >= bestDist {
+			// Stop at the first local minimum within maxDistance
+			break
+		}
+
+		if best < 0 || dist < bestDist {
+			best, bestFraction, bestDist = i, fraction, dist
+		}
+	}
+
+	p.segment, p.fraction = best, bestFraction
+
+	segLen := p.cumulative[best+1] - p.cumulative[best]
+
+	return p.cumulative[best] + bestFraction*segLen, bestDist
+}
+
+// projectOntoSegment projects the point at lat and lon onto the segment from a
+// to b, returning the proportion of the way along the segment of the projected
+// point, which is at least minFraction, and the distance, in meters, to it.
+func projectOntoSegment(a, b *ShapePoint, lat, lon, minFraction float64) (float64, float64) {
+	// Use an equirectangular projection centered on the start of the segment
+	scale := earthRadius * math.Pi / 180
+	cosLat := math.Cos(a.Latitude * math.Pi / 180)
+	bx := (b.Longitude - a.Longitude) * cosLat * scale
+	by := (b.Latitude - a.Latitude) * scale
+	px := (lon - a.Longitude) * cosLat * scale
+	py := (lat - a.Latitude) * scale
+
+	fraction := minFraction
+	if lenSq := bx*bx + by*by; lenSq > 0 {
+		fraction = math.Max(minFraction, math.Min(1, (px*bx+py*by)/lenSq))
+	}
+
+	return fraction, math.Hypot(px-fraction*bx, py-fraction*by)
+}
@@ -0,0 +1,94 @@
+package gtfs
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShape_ComputeDistances(t *testing.T) {
+	s := &Shape{
+		ID: "shape",
+		Points: []*ShapePoint{
+			{Latitude: 40.0, Longitude: -75.0},
+			{Latitude: 40.0, Longitude: -74.99},
+			{Latitude: 40.01, Longitude: -74.99},
+		},
+	}
+
+	s.ComputeDistances(DistanceUnitKilometers)
+
+	first := haversine(40.0, -75.0, 40.0, -74.99) / 1000
+	want := []float64{0, first, first + haversine(40.0, -74.99, 40.01, -74.99)/1000}
+	for i, pt := range s.Points {
+		if math.Abs(pt.Distance-want[i]) > 1e-9 {
+			t.Errorf("Shape.ComputeDistances() point %d = %v, want %v", i, pt.Distance, want[i])
+		}
+	}
+}
+
+func TestGTFS_ComputeShapeDistances(t *testing.T) {
+	// A loop that runs east and then returns west slightly further north
+	shape := &Shape{
+		ID: "loop",
+		Points: []*ShapePoint{
+			{Latitude: 40.0, Longitude: -75.0},
+			{Latitude: 40.0, Longitude: -74.98},
+			{Latitude: 40.0005, Longitude: -74.98},
+			{Latitude: 40.0005, Longitude: -75.0},
+		},
+	}
+
+	start := &Stop{ID: "start", Latitude: 40.0, Longitude: -75.0}
+	middle := &Stop{ID: "middle", Latitude: 40.0, Longitude: -74.99}
+	far := &Stop{ID: "far", Latitude: 40.01, Longitude: -74.98}
+	end := &Stop{ID: "end", Latitude: 40.0005, Longitude: -75.0}
+
+	trip := newBlockTestTrip("trip", "", nil, []*Stop{start, middle, far, end}, []string{"08:00:00", "08:05:00", "08:10:00", "08:15:00"})
+	trip.Shape = shape
+	unshaped := newBlockTestTrip("unshaped", "", nil, []*Stop{start, end}, []string{"08:00:00", "08:15:00"})
+	unshaped.Stops[1].ShapeDistanceTraveled = 5
+
+	g := &GTFS{
+		Shapes: []*Shape{shape},
+		Trips:  []*Trip{trip, unshaped},
+	}
+
+	issues := g.ComputeShapeDistances(ShapeDistanceOptions{})
+
+	east := haversine(40.0, -75.0, 40.0, -74.98)
+	north := haversine(40.0, -74.98, 40.0005, -74.98)
+	west := haversine(40.0005, -74.98, 40.0005, -75.0)
+
+	wantPoints := []float64{0, east, east + north, east + north + west}
+	for i, pt := range shape.Points {
+		if math.Abs(pt.Distance-wantPoints[i]) > 1e-6 {
+			t.Errorf("GTFS.ComputeShapeDistances() point %d = %v, want %v", i, pt.Distance, wantPoints[i])
+		}
+	}
+
+	// The final stop is close to the start of the shape, but must be projected
+	// onto its end
+	wantStops := []float64{0, east / 2, east + north, east + north + west}
+	for i, st := range trip.Stops {
+		if math.Abs(st.ShapeDistanceTraveled-wantStops[i]) > 1 {
+			t.Errorf("GTFS.ComputeShapeDistances() stop %s = %v, want %v", st.Stop.ID, st.ShapeDistanceTraveled, wantStops[i])
+		}
+	}
+
+	if got := unshaped.Stops[1].ShapeDistanceTraveled; got != 5 {
+		t.Errorf("GTFS.ComputeShapeDistances() modified trip without shape: %v", got)
+	}
+
+	if len(issues) != 1 || issues[0].StopTime != trip.Stops[2] || issues[0].Trip != trip {
+		t.Fatalf("GTFS.ComputeShapeDistances() issues = %v, want issue for stop far", issues)
+	}
+
+	if want := haversine(40.01, -74.98, 40.0005, -74.98); math.Abs(issues[0].Distance-want) > 1 {
+		t.Errorf("GTFS.ComputeShapeDistances() issue distance = %v, want %v", issues[0].Distance, want)
+	}
+
+	g.ComputeShapeDistances(ShapeDistanceOptions{Unit: DistanceUnitKilometers, MaxStopDistance: 2000})
+	if got, want := trip.Stops[3].ShapeDistanceTraveled, wantStops[3]/1000; math.Abs(got-want) > 1e-3 {
+		t.Errorf("GTFS.ComputeShapeDistances() in kilometers = %v, want %v", got, want)
+	}
+}
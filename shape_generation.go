@@ -0,0 +1,161 @@
+package gtfs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultShapeIDPrefix is the default prefix of the IDs of generated shapes.
+const DefaultShapeIDPrefix = "generated_"
+
+// A ShapeGenerator returns the points of a shape passing through stops, in
+// order. Only the latitudes and longitudes of the points are used.
+//
+// Generators may, for example, match stops to a road network.
+type ShapeGenerator func(stops []*Stop) ([]*ShapePoint, error)
+
+// StraightLineShape is a ShapeGenerator that connects stops with straight
+// lines.
+func StraightLineShape(stops []*Stop) ([]*ShapePoint, error) {
+	res := make([]*ShapePoint, 0, len(stops))
+	for _, s := range stops {
+		res = append(res, &ShapePoint{
+			Latitude:  s.Latitude,
+			Longitude: s.Longitude,
+		})
+	}
+
+	return res, nil
+}
+
+// ShapeGenerationOptions specifies options used when generating shapes.
+type ShapeGenerationOptions struct {
+	// Generator generates the points of each shape. If nil,
+	// StraightLineShape is used.
+	Generator ShapeGenerator
+
+	// IDPrefix is the prefix of the IDs of generated shapes, which are
+	// followed by a number. If empty, DefaultShapeIDPrefix is used.
+	IDPrefix string
+
+	// Unit is the unit in which distances along generated shapes are stored.
+	Unit DistanceUnit
+}
+
+// GenerateShapes creates shapes for all trips in g without shapes, returning
+// the shapes that were added to g.
+//
+// One shape is generated for each distinct sequence of stops. If a generated
+// shape has the same points as an existing shape or another generated shape,
+// that shape is used instead. Distances along generated shapes and the stop
+// times of trips assigned to them are filled in. Stop times of trips assigned
+// to existing shapes are measured in the same unit as the distances of the
+// shape's points, when it has any, rather than in opts.Unit. Trips with fewer
+// than two stops, or with stop times that don't refer to stops, are skipped.
+func (g *GTFS) GenerateShapes(opts ShapeGenerationOptions) ([]*Shape, error) {
+	if opts.Generator == nil {
+		opts.Generator = StraightLineShape
+	}
+
+	if opts.IDPrefix == "" {
+		opts.IDPrefix = DefaultShapeIDPrefix
+	}
+
+	if g.shapesByID == nil {
+		g.shapesByID = map[string]*Shape{}
+	}
+
+	shapesByPoints := map[string]*Shape{}
+	for _, s := range g.Shapes {
+		key := shapePointsKey(s.Points)
+		if _, ok := shapesByPoints[key]; !ok {
+			shapesByPoints[key] = s
+		}
+	}
+
+	var res []*Shape
+	generated := map[*Shape]bool{}
+	shapesByStops := map[string]*Shape{}
+	distanceOpts := ShapeDistanceOptions{
+		Unit:            opts.Unit,
+		MaxStopDistance: DefaultMaxStopShapeDistance,
+	}
+	n := 1
+
+	for _, t := range g.Trips {
+		if t.Shape != nil || len(t.Stops) < 2 {
+			continue
+		}
+
+		stops := make([]*Stop, 0, len(t.Stops))
+		ids := make([]string, 0, len(t.Stops))
+		for _, st := range t.Stops {
+			if st.Stop == nil {
+				break
+			}
+
+			stops = append(stops, st.Stop)
+			ids = append(ids, st.Stop.ID)
+		}
+
+		if len(stops) != len(t.Stops) {
+			continue
+		}
+
+		stopsKey := strings.Join(ids, "|")
+		shape, ok := shapesByStops[stopsKey]
+		if !ok {
+			points, err := opts.Generator(stops)
+			if err != nil {
+				return res, fmt.Errorf("error generating shape for trip %s: %v", t.ID, err)
+			}
+
+			pointsKey := shapePointsKey(points)
+			shape, ok = shapesByPoints[pointsKey]
+			if !ok {
+				for g.shapesByID[fmt.Sprintf("%s%d", opts.IDPrefix, n)] != nil {
+					n++
+				}
+
+				for i, pt := range points {
+					pt.Sequence = uint64(i)
+				}
+
+				shape = &Shape{
+					ID:     fmt.Sprintf("%s%d", opts.IDPrefix, n),
+					Points: points,
+				}
+				shape.ComputeDistances(opts.Unit)
+
+				g.Shapes = append(g.Shapes, shape)
+				g.shapesByID[shape.ID] = shape
+				shapesByPoints[pointsKey] = shape
+				generated[shape] = true
+				res = append(res, shape)
+			}
+
+			shapesByStops[stopsKey] = shape
+		}
+
+		t.Shape = shape
+		t.computeShapeDistances(distanceOpts)
+
+		// Existing shapes may measure distances in another unit
+		if !generated[shape] {
+			t.rescaleShapeDistances()
+		}
+	}
+
+	return res, nil
+}
+
+// shapePointsKey returns a string identifying the latitudes and longitudes of
+// points.
+func shapePointsKey(points []*ShapePoint) string {
+	var b strings.Builder
+	for _, pt := range points {
+		fmt.Fprintf(&b, "%v,%v;", pt.Latitude, pt.Longitude)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,150 @@
+package gtfs
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGTFS_GenerateShapes(t *testing.T) {
+	a := &Stop{ID: "a", Latitude: 40.0, Longitude: -75.0}
+	b := &Stop{ID: "b", Latitude: 40.0, Longitude: -74.99}
+	c := &Stop{ID: "c", Latitude: 40.01, Longitude: -74.99}
+	c2 := &Stop{ID: "c2", Latitude: 40.01, Longitude: -74.99}
+
+	existing := &Shape{ID: "generated_1", Points: []*ShapePoint{{Latitude: 41.0, Longitude: -75.0}, {Latitude: 41.0, Longitude: -74.0}}}
+
+	t1 := newBlockTestTrip("t1", "", nil, []*Stop{a, b, c}, []string{"08:00:00", "08:05:00", "08:10:00"})
+	t2 := newBlockTestTrip("t2", "", nil, []*Stop{a, b, c}, []string{"09:00:00", "09:05:00", "09:10:00"})
+	t3 := newBlockTestTrip("t3", "", nil, []*Stop{c, b, a}, []string{"10:00:00", "10:05:00", "10:10:00"})
+	t4 := newBlockTestTrip("t4", "", nil, []*Stop{a, b, c2}, []string{"11:00:00", "11:05:00", "11:10:00"})
+	t5 := newBlockTestTrip("t5", "", nil, []*Stop{a, b}, []string{"12:00:00", "12:05:00"})
+	t5.Shape = existing
+	t6 := newBlockTestTrip("t6", "", nil, []*Stop{a}, []string{"13:00:00"})
+
+	g := &GTFS{
+		Shapes: []*Shape{existing},
+		Trips:  []*Trip{t1, t2, t3, t4, t5, t6},
+	}
+	g.index()
+
+	got, err := g.GenerateShapes(ShapeGenerationOptions{})
+	if err != nil {
+		t.Fatalf("GTFS.GenerateShapes() error = %v", err)
+	}
+
+	ab := haversine(40.0, -75.0, 40.0, -74.99)
+	bc := haversine(40.0, -74.99, 40.01, -74.99)
+	want := []*Shape{
+		{
+			ID: "generated_2",
+			Points: []*ShapePoint{
+				{Latitude: 40.0, Longitude: -75.0, Sequence: 0, Distance: 0},
+				{Latitude: 40.0, Longitude: -74.99, Sequence: 1, Distance: ab},
+				{Latitude: 40.01, Longitude: -74.99, Sequence: 2, Distance: ab + bc},
+			},
+		},
+		{
+			ID: "generated_3",
+			Points: []*ShapePoint{
+				{Latitude: 40.01, Longitude: -74.99, Sequence: 0, Distance: 0},
+				{Latitude: 40.0, Longitude: -74.99, Sequence: 1, Distance: bc},
+				{Latitude: 40.0, Longitude: -75.0, Sequence: 2, Distance: ab + bc},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.GenerateShapes() = %v, want %v", got, want)
+	}
+
+	if len(g.Shapes) != 3 || g.shapeByID("generated_2") != got[0] {
+		t.Errorf("GTFS.GenerateShapes() didn't add shapes to feed")
+	}
+
+	wantShapes := map[*Trip]*Shape{
+		t1: got[0],
+		t2: got[0],
+		t3: got[1],
+		t4: got[0],
+		t5: existing,
+		t6: nil,
+	}
+	for trip, want := range wantShapes {
+		if trip.Shape != want {
+			t.Errorf("GTFS.GenerateShapes() trip %s shape = %v, want %v", trip.ID, trip.Shape, want)
+		}
+	}
+
+	wantDistances := []float64{0, ab, ab + bc}
+	for i, st := range t2.Stops {
+		if math.Abs(st.ShapeDistanceTraveled-wantDistances[i]) > 1e-6 {
+			t.Errorf("GTFS.GenerateShapes() stop %d distance = %v, want %v", i, st.ShapeDistanceTraveled, wantDistances[i])
+		}
+	}
+}
+
+func TestGTFS_GenerateShapes_existingUnit(t *testing.T) {
+	a := &Stop{ID: "a", Latitude: 40.0, Longitude: -75.0}
+	b := &Stop{ID: "b", Latitude: 40.0, Longitude: -74.99}
+
+	// The existing shape measures distances in miles
+	existing := &Shape{ID: "existing", Points: []*ShapePoint{{Latitude: 40.0, Longitude: -75.0}, {Latitude: 40.0, Longitude: -74.99}}}
+	existing.ComputeDistances(DistanceUnitMiles)
+
+	trip := newBlockTestTrip("trip", "", nil, []*Stop{a, b}, []string{"08:00:00", "08:05:00"})
+	g := &GTFS{
+		Shapes: []*Shape{existing},
+		Trips:  []*Trip{trip},
+	}
+	g.index()
+
+	got, err := g.GenerateShapes(ShapeGenerationOptions{})
+	if err != nil || len(got) != 0 || trip.Shape != existing {
+		t.Fatalf("GTFS.GenerateShapes() = %v, %v, want trip assigned to existing shape", got, err)
+	}
+
+	if d, want := trip.Stops[1].ShapeDistanceTraveled, existing.Points[1].Distance; math.Abs(d-want) > 1e-9 {
+		t.Errorf("GTFS.GenerateShapes() final stop distance = %v, want %v miles", d, want)
+	}
+}
+
+func TestGTFS_GenerateShapes_generator(t *testing.T) {
+	a := &Stop{ID: "a", Latitude: 40.0, Longitude: -75.0}
+	b := &Stop{ID: "b", Latitude: 40.0, Longitude: -74.99}
+
+	trip := newBlockTestTrip("trip", "", nil, []*Stop{a, b}, []string{"08:00:00", "08:05:00"})
+	g := &GTFS{
+		Trips: []*Trip{trip},
+	}
+
+	generator := func(stops []*Stop) ([]*ShapePoint, error) {
+		return []*ShapePoint{
+			{Latitude: 40.0, Longitude: -75.0},
+			{Latitude: 40.001, Longitude: -74.995},
+			{Latitude: 40.0, Longitude: -74.99},
+		}, nil
+	}
+
+	got, err := g.GenerateShapes(ShapeGenerationOptions{Generator: generator, IDPrefix: "road_", Unit: DistanceUnitKilometers})
+	if err != nil {
+		t.Fatalf("GTFS.GenerateShapes() error = %v", err)
+	}
+
+	if len(got) != 1 || got[0].ID != "road_1" || len(got[0].Points) != 3 {
+		t.Fatalf("GTFS.GenerateShapes() = %v, want single shape with three points", got)
+	}
+
+	if d := trip.Stops[1].ShapeDistanceTraveled; math.Abs(d-got[0].Points[2].Distance) > 1e-9 || d < 0.85 || d > 1 {
+		t.Errorf("GTFS.GenerateShapes() final stop distance = %v, want %v", d, got[0].Points[2].Distance)
+	}
+
+	failing := func(stops []*Stop) ([]*ShapePoint, error) {
+		return nil, errors.New("no route")
+	}
+
+	trip.Shape = nil
+	if _, err := g.GenerateShapes(ShapeGenerationOptions{Generator: failing}); err == nil {
+		t.Errorf("GTFS.GenerateShapes() with failing generator error = nil, want error")
+	}
+}
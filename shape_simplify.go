@@ -0,0 +1,550 @@
+package gtfs
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"strings"
+)
+
+// SimplificationAlgorithm specifies the algorithm used to simplify shapes.
+type SimplificationAlgorithm int
+
+const (
+	// SimplificationDouglasPeucker indicates that shapes are simplified using
+	// the Douglas-Peucker algorithm, which removes points that are within
+	// the tolerance of the simplified shape.
+	SimplificationDouglasPeucker SimplificationAlgorithm = iota
+
+	// SimplificationVisvalingam indicates that shapes are simplified using
+	// the Visvalingam-Whyatt algorithm, which removes points whose effective
+	// area is less than the square of the tolerance.
+	SimplificationVisvalingam
+)
+
+// SimplificationOptions specifies options used when simplifying shapes.
+type SimplificationOptions struct {
+	// Tolerance is the distance, in meters, by which a simplified shape may
+	// deviate from the original. With SimplificationVisvalingam, it instead
+	// sets the minimum effective area of the points that are kept, which is
+	// Tolerance² square meters.
+	Tolerance float64
+
+	Algorithm SimplificationAlgorithm
+}
+
+// Simplify removes points from s that aren't needed to represent it within
+// opts.Tolerance. The first and last points are always kept, as are the points
+// nearest to each of stops, which must be in the order in which they are
+// served.
+//
+// The distances and sequence numbers of the remaining points are unchanged, so
+// distances along s remain consistent with stop times.
+func (s *Shape) Simplify(opts SimplificationOptions, stops []*Stop) {
+	keep := map[int]bool{}
+	s.nearestPoints(stops, keep)
+	s.simplify(opts, keep)
+}
+
+// SimplifyShapes simplifies every shape in g, keeping the points nearest to
+// each stop served by trips using the shape. It returns the number of points
+// that were removed.
+func (g *GTFS) SimplifyShapes(opts SimplificationOptions) int {
+	keep := map[*Shape]map[int]bool{}
+	seen := map[*Shape]map[string]bool{}
+	for _, t := range g.Trips {
+		if t.Shape == nil {
+			continue
+		}
+
+		if keep[t.Shape] == nil {
+			keep[t.Shape] = map[int]bool{}
+			seen[t.Shape] = map[string]bool{}
+		}
+
+		var stops []*Stop
+		ids := make([]string, 0, len(t.Stops))
+		for _, st := range t.Stops {
+			if st.Stop != nil {
+				stops = append(stops, st.Stop)
+				ids = append(ids, st.Stop.ID)
+			}
+		}
+
+		// Trips serving the same stops keep the same points
+		key := strings.Join(ids, "|")
+		if seen[t.Shape][key] {
+			continue
+		}
+		seen[t.Shape][key] = true
+
+		t.Shape.nearestPoints(stops, keep[t.Shape])
+	}
+
+	removed := 0
+	for _, s := range g.Shapes {
+		n := len(s.Points)
+		s.simplify(opts, keep[s])
+		removed += n - len(s.Points)
+	}
+
+	return removed
+}
+
+// nearestPoints adds the indices of the points of s nearest to each of stops,
+// projected onto s in order, to keep.
+func (s *Shape) nearestPoints(stops []*Stop, keep map[int]bool) {
+	if len(s.Points) < 2 {
+		return
+	}
+
+	p := newShapeProjector(s)
+	for _, stop := range stops {
+		p.project(stop.Latitude, stop.Longitude, DefaultMaxStopShapeDistance)
+
+		if p.fraction < 0.5 {
+			keep[p.segment] = true
+		} else {
+			keep[p.segment+1] = true
+		}
+	}
+}
+
+// simplify removes points from s using opts, never removing the first or last
+// point or points whose indices are in keep.
+func (s *Shape) simplify(opts SimplificationOptions, keep map[int]bool) {
+	if len(s.Points) < 3 {
+		return
+	}
+
+	kept := make([]bool, len(s.Points))
+	kept[0] = true
+	kept[len(kept)-1] = true
+	for i := range keep {
+		if i >= 0 && i < len(kept) {
+			kept[i] = true
+		}
+	}
+
+	switch opts.Algorithm {
+	case SimplificationVisvalingam:
+		visvalingam(s.Points, kept, opts.Tolerance*opts.Tolerance)
+	default:
+		// Simplify each section between points that must be kept separately
+		start := 0
+		for i := 1; i < len(kept); i++ {
+			if kept[i] {
+				douglasPeucker(s.Points, kept, start, i, opts.Tolerance)
+				start = i
+			}
+		}
+	}
+
+	points := make([]*ShapePoint, 0, len(s.Points))
+	for i, pt := range s.Points {
+		if kept[i] {
+			points = append(points, pt)
+		}
+	}
+
+	s.Points = points
+}
+
+// douglasPeucker marks the points between first and last that are needed to
+// represent points within tolerance as kept.
+func douglasPeucker(points []*ShapePoint, kept []bool, first, last int, tolerance float64) {
+	type section struct {
+		first, last int
+	}
+
+	stack := []section{{first, last}}
+	for len(stack) > 0 {
+		sec := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		farthest := -1
+		maxDist := tolerance
+		for i := sec.first + 1; i < sec.last; i++ {
+			_, dist := projectOntoSegment(points[sec.first], points[sec.last], points[i].Latitude, points[i].Longitude, 0)
+			if dist > maxDist {
+				farthest, maxDist = i, dist
+			}
+		}
+
+		if farthest < 0 {
+			continue
+		}
+
+		kept[farthest] = true
+		stack = append(stack, section{sec.first, farthest}, section{farthest, sec.last})
+	}
+}
+
+// visvalingam marks the points that remain after removing points with an
+// effective area, in square meters, less than minArea as kept. Points that are
+// already kept are never removed.
+func visvalingam(points []*ShapePoint, kept []bool, minArea float64) {
+	prev := make([]int, len(points))
+	next := make([]int, len(points))
+	for i := range points {
+		prev[i] = i - 1
+		next[i] = i + 1
+	}
+
+	h := &areaHeap{}
+	items := make([]*areaItem, len(points))
+	for i := 1; i < len(points)-1; i++ {
+		if kept[i] {
+			continue
+		}
+
+		items[i] = &areaItem{
+			point: i,
+			area:  triangleArea(points[i-1], points[i], points[i+1]),
+		}
+		heap.Push(h, items[i])
+	}
+
+	for i := range points {
+		kept[i] = true
+	}
+
+	for h.Len() > 0 {
+		item := heap.Pop(h).(*areaItem)
+		if item.area >= minArea {
+			break
+		}
+
+		i := item.point
+		kept[i] = false
+		p, n := prev[i], next[i]
+		next[p] = n
+		prev[n] = p
+
+		// The effective area of a point never decreases when its neighbors
+		// are removed
+		for _, j := range []int{p, n} {
+			if items[j] == nil || items[j].index < 0 {
+				continue
+			}
+
+			items[j].area = math.Max(item.area, triangleArea(points[prev[j]], points[j], points[next[j]]))
+			heap.Fix(h, items[j].index)
+		}
+	}
+}
+
+// triangleArea returns the area, in square meters, of the triangle formed by a,
+// b and c.
+func triangleArea(a, b, c *ShapePoint) float64 {
+	scale := earthRadius * math.Pi / 180
+	cosLat := math.Cos(a.Latitude * math.Pi / 180)
+	bx := (b.Longitude - a.Longitude) * cosLat * scale
+	by := (b.Latitude - a.Latitude) * scale
+	cx := (c.Longitude - a.Longitude) * cosLat * scale
+	cy := (c.Latitude - a.Latitude) * scale
+
+	return math.Abs(bx*cy-cx*by) / 2
+}
+
+type areaItem struct {
+	point int
+	area  float64
+	index int
+}
+
+// An areaHeap is a min-heap of points ordered by effective area.
+type areaHeap []*areaItem
+
+func (h areaHeap) Len() int { return len(h) }
+
+func (h areaHeap) Less(i, j int) bool {
+	if h[i].area == h[j].area {
+		return h[i].point < h[j].point
+	}
+
+	return h[i].area < h[j].area
+}
+
+func (h areaHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *areaHeap) Push(x interface{}) {
+	item := x.(*areaItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *areaHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	item.index = -1
+	*h = old[:len(old)-1]
+
+	return item
+}
+
+// ShapeDeduplicationOptions specifies options used when deduplicating shapes.
+type ShapeDeduplicationOptions struct {
+	// Tolerance is the distance, in meters, within which two shapes must
+	// follow each other to be considered duplicates. If zero, only shapes with
+	// exactly the same points are considered duplicates.
+	Tolerance float64
+}
+
+// DeduplicateShapes removes shapes from g that duplicate an earlier shape,
+// updating trips to use the earlier shape instead. It returns the number of
+// shapes that were removed.
+//
+// Two shapes are duplicates if every point of each is within opts.Tolerance of
+// the other, in order, so that they run in the same direction. Each shape is
+// only compared with earlier shapes starting near the same point and covering
+// the same area. The stop times of trips that
+// are moved to a different shape have their distances along the shape
+// recomputed if they had any, using the same unit as the distances of the
+// shape's points.
+func (g *GTFS) DeduplicateShapes(opts ShapeDeduplicationOptions) int {
+	replacements := map[*Shape]*Shape{}
+	var shapes []*Shape
+
+	if opts.Tolerance == 0 {
+		shapesByPoints := map[string]*Shape{}
+		for _, s := range g.Shapes {
+			key := shapePointsKey(s.Points)
+			if existing, ok := shapesByPoints[key]; ok {
+				replacements[s] = existing
+				continue
+			}
+
+			shapesByPoints[key] = s
+			shapes = append(shapes, s)
+		}
+	} else {
+		grid := newShapeGrid(g.Shapes, opts.Tolerance)
+		for _, s := range g.Shapes {
+			for _, existing := range grid.candidates(s) {
+				if shapesWithin(existing, s, opts.Tolerance) {
+					replacements[s] = existing
+					break
+				}
+			}
+
+			if replacements[s] == nil {
+				grid.add(s)
+				shapes = append(shapes, s)
+			}
+		}
+	}
+
+	if len(replacements) == 0 {
+		return 0
+	}
+
+	for s := range replacements {
+		if g.shapesByID[s.ID] == s {
+			delete(g.shapesByID, s.ID)
+		}
+	}
+	g.Shapes = shapes
+
+	for _, t := range g.Trips {
+		replacement, ok := replacements[t.Shape]
+		if !ok {
+			continue
+		}
+
+		t.Shape = replacement
+		t.rescaleShapeDistances()
+	}
+
+	return len(replacements)
+}
+
+// rescaleShapeDistances recomputes the distances of t's stop times along its
+// shape, in the same unit as the distances of the shape's points. Stop times
+// are left unchanged if none of them had distances, or if the shape has none,
+// since there's no unit to use.
+func (t *Trip) rescaleShapeDistances() {
+	hasDistances := false
+	for _, st := range t.Stops {
+		if st.ShapeDistanceTraveled != 0 {
+			hasDistances = true
+			break
+		}
+	}
+
+	points := t.Shape.Points
+	length := t.Shape.Length()
+	if !hasDistances || len(points) == 0 || points[len(points)-1].Distance == 0 || length == 0 {
+		return
+	}
+
+	scale := points[len(points)-1].Distance / length
+
+	t.computeShapeDistances(ShapeDistanceOptions{MaxStopDistance: DefaultMaxStopShapeDistance})
+	for _, st := range t.Stops {
+		st.ShapeDistanceTraveled *= scale
+	}
+}
+
+// shapesWithin reports whether a and b start and end within tolerance of each
+// other, and every point of each is within tolerance of the other.
+func shapesWithin(a, b *Shape, tolerance float64) bool {
+	if len(a.Points) == 0 || len(b.Points) == 0 {
+		return len(a.Points) == len(b.Points)
+	}
+
+	aFirst, aLast := a.Points[0], a.Points[len(a.Points)-1]
+	bFirst, bLast := b.Points[0], b.Points[len(b.Points)-1]
+	if haversine(aFirst.Latitude, aFirst.Longitude, bFirst.Latitude, bFirst.Longitude) > tolerance ||
+		haversine(aLast.Latitude, aLast.Longitude, bLast.Latitude, bLast.Longitude) > tolerance {
+		return false
+	}
+
+	return pointsWithin(a.Points, b.Points, tolerance) && pointsWithin(b.Points, a.Points, tolerance)
+}
+
+// pointsWithin reports whether every point in points is within tolerance of the
+// line through line, in order.
+//
+// Both polylines are walked together: each point is matched to the first
+// segment of line within tolerance of it, starting from the segment matched to
+// the previous point, so line is only walked once.
+func pointsWithin(points, line []*ShapePoint, tolerance float64) bool {
+	if len(line) == 1 {
+		for _, pt := range points {
+			if haversine(pt.Latitude, pt.Longitude, line[0].Latitude, line[0].Longitude) > tolerance {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	segment := 1
+	for _, pt := range points {
+		for ; segment < len(line); segment++ {
+			if _, dist := projectOntoSegment(line[segment-1], line[segment], pt.Latitude, pt.Longitude, 0); dist <= tolerance {
+				break
+			}
+		}
+
+		if segment == len(line) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// A shapeGrid indexes shapes by the grid cell containing their first point,
+// with cells at least as large as a tolerance, so that shapes starting within
+// the tolerance of a point are in the same or an adjacent cell.
+type shapeGrid struct {
+	cellLat, cellLon float64
+	cells            map[[2]int][]*Shape
+	empty            []*Shape
+
+	// bounds and order hold the bounding box of each shape in the grid and
+	// the order in which it was added
+	bounds map[*Shape]BoundingBox
+	order  map[*Shape]int
+}
+
+func newShapeGrid(shapes []*Shape, tolerance float64) *shapeGrid {
+	// A degree of longitude is shortest at the latitude farthest from the
+	// equator, so cells are sized for it
+	maxLat := 0.0
+	for _, s := range shapes {
+		for _, pt := range s.Points {
+			maxLat = math.Max(maxLat, math.Abs(pt.Latitude))
+		}
+	}
+
+	cellLat := tolerance / (earthRadius * math.Pi / 180)
+	cellLon := 360.0
+	if cosLat := math.Cos(maxLat * math.Pi / 180); cellLat < cosLat*360 {
+		cellLon = cellLat / cosLat
+	}
+
+	return &shapeGrid{
+		cellLat: cellLat,
+		cellLon: cellLon,
+		cells:   map[[2]int][]*Shape{},
+		bounds:  map[*Shape]BoundingBox{},
+		order:   map[*Shape]int{},
+	}
+}
+
+func (sg *shapeGrid) cell(pt *ShapePoint) [2]int {
+	return [2]int{int(math.Floor(pt.Latitude / sg.cellLat)), int(math.Floor(pt.Longitude / sg.cellLon))}
+}
+
+// add adds s to the grid.
+func (sg *shapeGrid) add(s *Shape) {
+	sg.order[s] = len(sg.order)
+
+	if len(s.Points) == 0 {
+		sg.empty = append(sg.empty, s)
+		return
+	}
+
+	c := sg.cell(s.Points[0])
+	sg.cells[c] = append(sg.cells[c], s)
+	sg.bounds[s] = shapeBounds(s)
+}
+
+// candidates returns the shapes in the grid that may be within the tolerance
+// of s, in the order in which they were added: those starting in the same or an
+// adjacent cell whose bounding boxes are within the tolerance of that of s.
+func (sg *shapeGrid) candidates(s *Shape) []*Shape {
+	if len(s.Points) == 0 {
+		return sg.empty
+	}
+
+	b := shapeBounds(s)
+	c := sg.cell(s.Points[0])
+
+	var res []*Shape
+	for i := c[0] - 1; i <= c[0]+1; i++ {
+		for j := c[1] - 1; j <= c[1]+1; j++ {
+			for _, other := range sg.cells[[2]int{i, j}] {
+				ob := sg.bounds[other]
+				if math.Abs(ob.MinLatitude-b.MinLatitude) <= sg.cellLat && math.Abs(ob.MaxLatitude-b.MaxLatitude) <= sg.cellLat &&
+					math.Abs(ob.MinLongitude-b.MinLongitude) <= sg.cellLon && math.Abs(ob.MaxLongitude-b.MaxLongitude) <= sg.cellLon {
+					res = append(res, other)
+				}
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return sg.order[res[i]] < sg.order[res[j]]
+	})
+
+	return res
+}
+
+// shapeBounds returns the smallest bounding box containing the points of s,
+// which must have at least one point.
+func shapeBounds(s *Shape) BoundingBox {
+	first := s.Points[0]
+	b := BoundingBox{
+		MinLatitude:  first.Latitude,
+		MinLongitude: first.Longitude,
+		MaxLatitude:  first.Latitude,
+		MaxLongitude: first.Longitude,
+	}
+
+	for _, pt := range s.Points[1:] {
+		b.MinLatitude = math.Min(b.MinLatitude, pt.Latitude)
+		b.MinLongitude = math.Min(b.MinLongitude, pt.Longitude)
+		b.MaxLatitude = math.Max(b.MaxLatitude, pt.Latitude)
+		b.MaxLongitude = math.Max(b.MaxLongitude, pt.Longitude)
+	}
+
+	return b
+}
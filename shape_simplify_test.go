@@ -0,0 +1,289 @@
+package gtfs
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// newSimplifyTestShape creates a shape that runs east and then north, with
+// small deviations from a straight line along each leg.
+func newSimplifyTestShape() *Shape {
+	s := &Shape{ID: "shape"}
+	for i := 0; i <= 10; i++ {
+		noise := 0.000001 * float64(i%2)
+		s.Points = append(s.Points, &ShapePoint{Latitude: 40.0 + noise, Longitude: -75.0 + 0.001*float64(i), Sequence: uint64(i)})
+	}
+
+	for i := 1; i <= 10; i++ {
+		noise := 0.000001 * float64(i%2)
+		s.Points = append(s.Points, &ShapePoint{Latitude: 40.0 + 0.001*float64(i), Longitude: -74.99 + noise, Sequence: uint64(10 + i)})
+	}
+
+	return s
+}
+
+func shapeSequences(s *Shape) []uint64 {
+	var res []uint64
+	for _, pt := range s.Points {
+		res = append(res, pt.Sequence)
+	}
+
+	return res
+}
+
+func TestShape_Simplify(t *testing.T) {
+	stop := &Stop{ID: "stop", Latitude: 40.0001, Longitude: -74.9969}
+
+	tests := []struct {
+		name  string
+		opts  SimplificationOptions
+		stops []*Stop
+		want  []uint64
+	}{
+		{
+			name: "Douglas-Peucker",
+			opts: SimplificationOptions{Tolerance: 5},
+			want: []uint64{0, 10, 20},
+		},
+		{
+			name: "Visvalingam",
+			opts: SimplificationOptions{Tolerance: 10, Algorithm: SimplificationVisvalingam},
+			want: []uint64{0, 10, 20},
+		},
+		{
+			name:  "Douglas-Peucker With Stop",
+			opts:  SimplificationOptions{Tolerance: 5},
+			stops: []*Stop{stop},
+			want:  []uint64{0, 3, 10, 20},
+		},
+		{
+			name:  "Visvalingam With Stop",
+			opts:  SimplificationOptions{Tolerance: 10, Algorithm: SimplificationVisvalingam},
+			stops: []*Stop{stop},
+			want:  []uint64{0, 3, 10, 20},
+		},
+		{
+			name: "Small Tolerance",
+			opts: SimplificationOptions{Tolerance: 0.01},
+			want: shapeSequences(newSimplifyTestShape()),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSimplifyTestShape()
+			s.Simplify(tt.opts, tt.stops)
+			if got := shapeSequences(s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Shape.Simplify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGTFS_SimplifyShapes(t *testing.T) {
+	s := newSimplifyTestShape()
+	unused := newSimplifyTestShape()
+	unused.ID = "unused"
+
+	a := &Stop{ID: "a", Latitude: 40.0, Longitude: -75.0}
+	b := &Stop{ID: "b", Latitude: 40.0001, Longitude: -74.9969}
+	c := &Stop{ID: "c", Latitude: 40.005, Longitude: -74.9901}
+
+	trip := newBlockTestTrip("trip", "", nil, []*Stop{a, b, c}, []string{"08:00:00", "08:05:00", "08:10:00"})
+	trip.Shape = s
+
+	g := &GTFS{
+		Shapes: []*Shape{s, unused},
+		Trips:  []*Trip{trip},
+	}
+
+	if got := g.SimplifyShapes(SimplificationOptions{Tolerance: 5}); got != 34 {
+		t.Errorf("GTFS.SimplifyShapes() = %v, want %v", got, 34)
+	}
+
+	if got, want := shapeSequences(s), []uint64{0, 3, 10, 15, 20}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.SimplifyShapes() shape = %v, want %v", got, want)
+	}
+
+	if got, want := shapeSequences(unused), []uint64{0, 10, 20}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.SimplifyShapes() unused shape = %v, want %v", got, want)
+	}
+}
+
+func TestGTFS_DeduplicateShapes(t *testing.T) {
+	newShapes := func() (*GTFS, []*Shape, *Trip) {
+		original := &Shape{ID: "original", Points: []*ShapePoint{
+			{Latitude: 40.0, Longitude: -75.0},
+			{Latitude: 40.0, Longitude: -74.99},
+			{Latitude: 40.01, Longitude: -74.99},
+		}}
+		original.ComputeDistances(DistanceUnitKilometers)
+
+		copied := &Shape{ID: "copied", Points: []*ShapePoint{
+			{Latitude: 40.0, Longitude: -75.0},
+			{Latitude: 40.0, Longitude: -74.99},
+			{Latitude: 40.01, Longitude: -74.99},
+		}}
+
+		// Roughly 1m north of the original, with an extra point
+		nearby := &Shape{ID: "nearby", Points: []*ShapePoint{
+			{Latitude: 40.00001, Longitude: -75.0},
+			{Latitude: 40.00001, Longitude: -74.995},
+			{Latitude: 40.00001, Longitude: -74.99},
+			{Latitude: 40.01, Longitude: -74.98999},
+		}}
+		nearby.ComputeDistances(DistanceUnitMeters)
+
+		reversed := &Shape{ID: "reversed", Points: []*ShapePoint{
+			{Latitude: 40.01, Longitude: -74.99},
+			{Latitude: 40.0, Longitude: -74.99},
+			{Latitude: 40.0, Longitude: -75.0},
+		}}
+
+		a := &Stop{ID: "a", Latitude: 40.0, Longitude: -75.0}
+		b := &Stop{ID: "b", Latitude: 40.01, Longitude: -74.99}
+		trip := newBlockTestTrip("trip", "", nil, []*Stop{a, b}, []string{"08:00:00", "08:10:00"})
+		trip.Shape = nearby
+		trip.computeShapeDistances(ShapeDistanceOptions{MaxStopDistance: DefaultMaxStopShapeDistance})
+
+		g := &GTFS{
+			Shapes: []*Shape{original, copied, nearby, reversed},
+			Trips:  []*Trip{trip},
+		}
+		g.index()
+
+		return g, g.Shapes, trip
+	}
+
+	t.Run("Exact", func(t *testing.T) {
+		g, shapes, trip := newShapes()
+
+		if got := g.DeduplicateShapes(ShapeDeduplicationOptions{}); got != 1 {
+			t.Errorf("GTFS.DeduplicateShapes() = %v, want %v", got, 1)
+		}
+
+		if want := []*Shape{shapes[0], shapes[2], shapes[3]}; !reflect.DeepEqual(g.Shapes, want) {
+			t.Errorf("GTFS.DeduplicateShapes() shapes = %v, want %v", g.Shapes, want)
+		}
+
+		if g.shapeByID("copied") != nil {
+			t.Errorf("GTFS.DeduplicateShapes() didn't remove shape from index")
+		}
+
+		if trip.Shape != shapes[2] {
+			t.Errorf("GTFS.DeduplicateShapes() changed shape of trip with unique shape")
+		}
+	})
+
+	t.Run("Tolerance", func(t *testing.T) {
+		g, shapes, trip := newShapes()
+
+		if got := g.DeduplicateShapes(ShapeDeduplicationOptions{Tolerance: 5}); got != 2 {
+			t.Errorf("GTFS.DeduplicateShapes() = %v, want %v", got, 2)
+		}
+
+		if want := []*Shape{shapes[0], shapes[3]}; !reflect.DeepEqual(g.Shapes, want) {
+			t.Errorf("GTFS.DeduplicateShapes() shapes = %v, want %v", g.Shapes, want)
+		}
+
+		if trip.Shape != shapes[0] {
+			t.Errorf("GTFS.DeduplicateShapes() trip shape = %v, want %v", trip.Shape, shapes[0])
+		}
+
+		// Distances must now be in kilometers, matching the original shape
+		last := shapes[0].Points[2].Distance
+		if got := trip.Stops[1].ShapeDistanceTraveled; math.Abs(got-last) > 1e-6 {
+			t.Errorf("GTFS.DeduplicateShapes() stop distance = %v, want %v", got, last)
+		}
+	})
+
+	t.Run("No Distances", func(t *testing.T) {
+		g, shapes, trip := newShapes()
+		for _, p := range shapes[0].Points {
+			p.Distance = 0
+		}
+
+		before := trip.Stops[1].ShapeDistanceTraveled
+		if got := g.DeduplicateShapes(ShapeDeduplicationOptions{Tolerance: 5}); got != 2 {
+			t.Errorf("GTFS.DeduplicateShapes() = %v, want %v", got, 2)
+		}
+
+		if trip.Shape != shapes[0] {
+			t.Errorf("GTFS.DeduplicateShapes() trip shape = %v, want %v", trip.Shape, shapes[0])
+		}
+
+		if got := trip.Stops[1].ShapeDistanceTraveled; got != before || got == 0 {
+			t.Errorf("GTFS.DeduplicateShapes() stop distance = %v, want %v", got, before)
+		}
+	})
+}
+
+func Test_pointsWithin(t *testing.T) {
+	line := []*ShapePoint{
+		{Latitude: 40.0, Longitude: -75.0},
+		{Latitude: 40.0, Longitude: -74.99},
+		{Latitude: 40.01, Longitude: -74.99},
+	}
+
+	tests := []struct {
+		name   string
+		points []*ShapePoint
+		want   bool
+	}{
+		{
+			name: "In Order",
+			points: []*ShapePoint{
+				{Latitude: 40.0, Longitude: -75.0},
+				{Latitude: 40.00001, Longitude: -74.995},
+				{Latitude: 40.005, Longitude: -74.99},
+				{Latitude: 40.01, Longitude: -74.99},
+			},
+			want: true,
+		},
+		{
+			name: "Out of Order",
+			points: []*ShapePoint{
+				{Latitude: 40.0, Longitude: -75.0},
+				{Latitude: 40.005, Longitude: -74.99},
+				{Latitude: 40.00001, Longitude: -74.995},
+				{Latitude: 40.01, Longitude: -74.99},
+			},
+			want: false,
+		},
+		{
+			name: "Too Far",
+			points: []*ShapePoint{
+				{Latitude: 40.0, Longitude: -75.0},
+				{Latitude: 40.001, Longitude: -74.995},
+				{Latitude: 40.01, Longitude: -74.99},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pointsWithin(tt.points, line, 5); got != tt.want {
+				t.Errorf("pointsWithin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGTFS_DeduplicateShapes_distant(t *testing.T) {
+	newShape := func(id string, lat float64) *Shape {
+		return &Shape{ID: id, Points: []*ShapePoint{{Latitude: lat, Longitude: -75.0}, {Latitude: lat, Longitude: -74.99}}}
+	}
+
+	g := &GTFS{
+		Shapes: []*Shape{newShape("a", 40.0), newShape("b", 41.0), newShape("c", 40.00001), newShape("d", 41.00001)},
+	}
+	g.index()
+
+	if got := g.DeduplicateShapes(ShapeDeduplicationOptions{Tolerance: 5}); got != 2 {
+		t.Errorf("GTFS.DeduplicateShapes() = %v, want %v", got, 2)
+	}
+
+	if len(g.Shapes) != 2 || g.Shapes[0].ID != "a" || g.Shapes[1].ID != "b" {
+		t.Errorf("GTFS.DeduplicateShapes() shapes = %v, want a and b", g.Shapes)
+	}
+}
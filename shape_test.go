@@ -2,6 +2,7 @@ package gtfs
 
 import (
 	"io"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
@@ -226,3 +227,41 @@ func TestGTFS_shapeByID(t *testing.T) {
 		})
 	}
 }
+
+func TestShape_Length(t *testing.T) {
+	tests := []struct {
+		name   string
+		points []*ShapePoint
+		want   float64
+	}{
+		{
+			name:   "Empty",
+			points: nil,
+			want:   0,
+		},
+		{
+			name: "Single Point",
+			points: []*ShapePoint{
+				{Latitude: 0, Longitude: 0},
+			},
+			want: 0,
+		},
+		{
+			name: "Multiple Points",
+			points: []*ShapePoint{
+				{Latitude: 0, Longitude: 0},
+				{Latitude: 0, Longitude: 0.01},
+				{Latitude: 0.01, Longitude: 0.01},
+			},
+			want: 2224,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Shape{Points: tt.points}
+			if got := s.Length(); math.Abs(got-tt.want) > 1 {
+				t.Errorf("Shape.Length() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
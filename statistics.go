@@ -0,0 +1,320 @@
+package gtfs
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// StatisticsOptions specifies options used when computing service statistics.
+type StatisticsOptions struct {
+	// ShapeDistanceUnit is the unit of the shape_dist_traveled values in the
+	// feed, which the GTFS specification leaves up to the producer.
+	ShapeDistanceUnit DistanceUnit
+}
+
+// ServiceStatistics summarizes the service operated by a set of trips on a
+// single day.
+type ServiceStatistics struct {
+	// Trips is the number of trips operated. Each run of a frequency-based
+	// trip is counted separately.
+	Trips int
+
+	// RevenueTime is the total time between the first departure and last
+	// arrival of each trip.
+	RevenueTime time.Duration
+
+	// RevenueDistance is the total distance, in meters, traveled by trips.
+	RevenueDistance float64
+
+	// FirstDeparture and LastArrival are the times of the first departure
+	// and last arrival of any trip, as the amount of time elapsed since the
+	// start of the service day.
+	FirstDeparture time.Duration
+	LastArrival    time.Duration
+
+	// PeakVehicles is the largest number of vehicles in service at once.
+	// Consecutive trips in the same block are assumed to be operated by the
+	// same vehicle, which remains in service between them.
+	PeakVehicles int
+
+	runs []*serviceRun
+}
+
+// Span returns the span of service, from the first departure to the last
+// arrival.
+func (s *ServiceStatistics) Span() time.Duration {
+	return s.LastArrival - s.FirstDeparture
+}
+
+// RevenueHours returns RevenueTime in hours.
+func (s *ServiceStatistics) RevenueHours() float64 {
+	return s.RevenueTime.Hours()
+}
+
+// RevenueKilometers returns RevenueDistance in kilometers.
+func (s *ServiceStatistics) RevenueKilometers() float64 {
+	return s.RevenueDistance / 1000
+}
+
+// DailyStatistics summarizes the service operated on a single day, both in
+// total and broken down by route, agency and route type.
+type DailyStatistics struct {
+	Date        time.Time
+	Total       *ServiceStatistics
+	ByRoute     map[*Route]*ServiceStatistics
+	ByAgency    map[*Agency]*ServiceStatistics
+	ByRouteType map[RouteType]*ServiceStatistics
+}
+
+// A serviceRun is a single run of a trip on a service day.
+type serviceRun struct {
+	trip       *Trip
+	start, end time.Duration
+	distance   float64
+}
+
+// Statistics computes service statistics for each day from start to end,
+// inclusive.
+//
+// Trip distances are taken from the shape_dist_traveled values of the first and
+// last stops when present, and otherwise from the length of the trip's shape.
+// Trips with neither are measured as straight lines between consecutive stops.
+func (g *GTFS) Statistics(start, end time.Time, opts StatisticsOptions) ([]*DailyStatistics, error) {
+	if end.Before(start) {
+		return nil, errors.New("statistics end date is before start date")
+	}
+
+	var res []*DailyStatistics
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		day, err := g.dailyStatistics(date, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, day)
+	}
+
+	return res, nil
+}
+
+func (g *GTFS) dailyStatistics(date time.Time, opts StatisticsOptions) (*DailyStatistics, error) {
+	day := &DailyStatistics{
+		Date:        date,
+		Total:       &ServiceStatistics{},
+		ByRoute:     map[*Route]*ServiceStatistics{},
+		ByAgency:    map[*Agency]*ServiceStatistics{},
+		ByRouteType: map[RouteType]*ServiceStatistics{},
+	}
+
+	for _, t := range g.Trips {
+		if t.Service == nil || !t.Service.IsActiveOn(date) {
+			continue
+		}
+
+		bt, err := newBlockTrip(t)
+		if err != nil {
+			return nil, err
+		}
+
+		if bt == nil {
+			continue
+		}
+
+		offsets, err := t.InstanceOffsets()
+		if err != nil {
+			return nil, err
+		}
+
+		distance := tripDistance(bt, opts.ShapeDistanceUnit)
+
+		// bt is placed at the first run of frequency-based trips
+		for _, offset := range offsets {
+			run := &serviceRun{
+				trip:     t,
+				start:    bt.start + offset - offsets[0],
+				end:      bt.end + offset - offsets[0],
+				distance: distance,
+			}
+
+			day.Total.add(run)
+
+			if t.Route == nil {
+				continue
+			}
+
+			day.route(t.Route).add(run)
+			day.agency(t.Route.Agency).add(run)
+			day.routeType(t.Route.Type).add(run)
+		}
+	}
+
+	day.Total.finish()
+	for _, s := range day.ByRoute {
+		s.finish()
+	}
+	for _, s := range day.ByAgency {
+		s.finish()
+	}
+	for _, s := range day.ByRouteType {
+		s.finish()
+	}
+
+	return day, nil
+}
+
+func (d *DailyStatistics) route(r *Route) *ServiceStatistics {
+	s, ok := d.ByRoute[r]
+	if !ok {
+		s = &ServiceStatistics{}
+		d.ByRoute[r] = s
+	}
+
+	return s
+}
+
+func (d *DailyStatistics) agency(a *Agency) *ServiceStatistics {
+	s, ok := d.ByAgency[a]
+	if !ok {
+		s = &ServiceStatistics{}
+		d.ByAgency[a] = s
+	}
+
+	return s
+}
+
+func (d *DailyStatistics) routeType(t RouteType) *ServiceStatistics {
+	s, ok := d.ByRouteType[t]
+	if !ok {
+		s = &ServiceStatistics{}
+		d.ByRouteType[t] = s
+	}
+
+	return s
+}
+
+func (s *ServiceStatistics) add(run *serviceRun) {
+	if s.Trips == 0 || run.start < s.FirstDeparture {
+		s.FirstDeparture = run.start
+	}
+
+	if s.Trips == 0 || run.end > s.LastArrival {
+		s.LastArrival = run.end
+	}
+
+	s.Trips++
+	s.RevenueTime += run.end - run.start
+	s.RevenueDistance += run.distance
+	s.runs = append(s.runs, run)
+}
+
+// finish computes the peak number of vehicles in service.
+func (s *ServiceStatistics) finish() {
+	type event struct {
+		time  time.Duration
+		delta int
+	}
+
+	// Each vehicle is in service from the start of its first run to the end
+	// of its last run, where runs without a block have their own vehicle
+	var events []event
+	blocks := map[string][2]time.Duration{}
+	for _, run := range s.runs {
+		if run.trip.BlockID == "" || !run.trip.AbsoluteTimes {
+			events = append(events, event{run.start, 1}, event{run.end, -1})
+			continue
+		}
+
+		span, ok := blocks[run.trip.BlockID]
+		if !ok {
+			span = [2]time.Duration{run.start, run.end}
+		}
+
+		if run.start < span[0] {
+			span[0] = run.start
+		}
+
+		if run.end > span[1] {
+			span[1] = run.end
+		}
+
+		blocks[run.trip.BlockID] = span
+	}
+
+	for _, span := range blocks {
+		events = append(events, event{span[0], 1}, event{span[1], -1})
+	}
+
+	// Vehicles finishing a run at the same time as another starts are not
+	// both in service
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].time != events[j].time {
+			return events[i].time < events[j].time
+		}
+
+		return events[i].delta < events[j].delta
+	})
+
+	vehicles := 0
+	for _, e := range events {
+		vehicles += e.delta
+		if vehicles > s.PeakVehicles {
+			s.PeakVehicles = vehicles
+		}
+	}
+
+	s.runs = nil
+}
+
+// tripDistance returns the distance, in meters, traveled by a trip between its
+// first and last scheduled stops.
+func tripDistance(bt *blockTrip, unit DistanceUnit) float64 {
+	if bt.last.ShapeDistanceTraveled > 0 {
+		return unit.Meters(bt.last.ShapeDistanceTraveled - bt.first.ShapeDistanceTraveled)
+	}
+
+	if bt.trip.Shape != nil && len(bt.trip.Shape.Points) > 1 {
+		if bt.first.Stop == nil || bt.last.Stop == nil {
+			return bt.trip.Shape.Length()
+		}
+
+		// Project the stops onto the shape in order, so that only the part of
+		// the shape between the first and last scheduled stops is counted
+		p := newShapeProjector(bt.trip.Shape)
+		var start float64
+		projecting := false
+		for _, st := range bt.trip.Stops {
+			if st == bt.first {
+				projecting = true
+			}
+
+			if !projecting || st.Stop == nil {
+				continue
+			}
+
+			along, _ := p.project(st.Stop.Latitude, st.Stop.Longitude, DefaultMaxStopShapeDistance)
+			if st == bt.first {
+				start = along
+			}
+
+			if st == bt.last {
+				return along - start
+			}
+		}
+	}
+
+	distance := 0.0
+	var prev *Stop
+	for _, st := range bt.trip.Stops {
+		if st.Stop == nil {
+			continue
+		}
+
+		if prev != nil {
+			distance += prev.DistanceTo(st.Stop)
+		}
+		prev = st.Stop
+	}
+
+	return distance
+}
@@ -0,0 +1,164 @@
+package gtfs
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGTFS_Statistics(t *testing.T) {
+	service := &Service{
+		ID:        "weekday",
+		Monday:    true,
+		Tuesday:   true,
+		Wednesday: true,
+		Thursday:  true,
+		Friday:    true,
+		StartDate: "20190101",
+		EndDate:   "20191231",
+	}
+
+	agency1 := &Agency{ID: "agency-1"}
+	agency2 := &Agency{ID: "agency-2"}
+	bus := &Route{ID: "bus", Agency: agency1, Type: RouteTypeBus}
+	rail := &Route{ID: "rail", Agency: agency2, Type: RouteTypeRail}
+
+	a := &Stop{ID: "a", Latitude: 0, Longitude: 0}
+	b := &Stop{ID: "b", Latitude: 0.01, Longitude: 0}
+	shape := &Shape{
+		ID: "shape",
+		Points: []*ShapePoint{
+			{Latitude: 0, Longitude: 0},
+			{Latitude: 0.01, Longitude: 0},
+			{Latitude: 0.02, Longitude: 0},
+		},
+	}
+
+	// Two trips operated by the same vehicle, measured in kilometers
+	t1 := newBlockTestTrip("t1", "block-1", service, []*Stop{a, b}, []string{"08:00:00", "08:30:00"})
+	t1.Route = bus
+	t1.Stops[1].ShapeDistanceTraveled = 5
+	t2 := newBlockTestTrip("t2", "block-1", service, []*Stop{b, a}, []string{"08:40:00", "09:10:00"})
+	t2.Route = bus
+	t2.Stops[1].ShapeDistanceTraveled = 5
+
+	// A trip measured using its shape, which continues past its last stop
+	t3 := newBlockTestTrip("t3", "", service, []*Stop{a, b}, []string{"08:15:00", "08:45:00"})
+	t3.Route = bus
+	t3.Shape = shape
+
+	// A frequency-based trip running twice, measured between its stops
+	t4 := newBlockTestTrip("t4", "", service, []*Stop{a, b}, []string{"00:00:00", "00:20:00"})
+	t4.Route = rail
+	t4.AbsoluteTimes = false
+	t4.Frequencies = []Frequency{{StartTime: "10:00:00", EndTime: "11:00:00", HeadwaySeconds: 1800}}
+
+	g := &GTFS{
+		Trips: []*Trip{t1, t2, t3, t4},
+	}
+
+	start := time.Date(2019, time.July, 3, 0, 0, 0, 0, time.UTC)
+	if _, err := g.Statistics(start, start.AddDate(0, 0, -1), StatisticsOptions{}); err == nil {
+		t.Errorf("GTFS.Statistics() with end before start error = nil, want error")
+	}
+
+	days, err := g.Statistics(start, start.AddDate(0, 0, 3), StatisticsOptions{ShapeDistanceUnit: DistanceUnitKilometers})
+	if err != nil {
+		t.Fatalf("GTFS.Statistics() error = %v", err)
+	}
+
+	if len(days) != 4 {
+		t.Fatalf("GTFS.Statistics() returned %d days, want 4", len(days))
+	}
+
+	// July 6, 2019 was a Saturday
+	if saturday := days[3]; saturday.Total.Trips != 0 || len(saturday.ByRoute) != 0 {
+		t.Errorf("GTFS.Statistics() for Saturday = %+v, want no service", saturday.Total)
+	}
+
+	tests := []struct {
+		name         string
+		stats        *ServiceStatistics
+		wantTrips    int
+		wantTime     time.Duration
+		wantDistance float64
+		wantFirst    time.Duration
+		wantLast     time.Duration
+		wantPeak     int
+	}{
+		{
+			name:         "Total",
+			stats:        days[0].Total,
+			wantTrips:    5,
+			wantTime:     130 * time.Minute,
+			wantDistance: 13336,
+			wantFirst:    8 * time.Hour,
+			wantLast:     10*time.Hour + 50*time.Minute,
+			wantPeak:     2,
+		},
+		{
+			name:         "Bus Route",
+			stats:        days[1].ByRoute[bus],
+			wantTrips:    3,
+			wantTime:     90 * time.Minute,
+			wantDistance: 11112,
+			wantFirst:    8 * time.Hour,
+			wantLast:     9*time.Hour + 10*time.Minute,
+			wantPeak:     2,
+		},
+		{
+			name:         "Rail Agency",
+			stats:        days[0].ByAgency[agency2],
+			wantTrips:    2,
+			wantTime:     40 * time.Minute,
+			wantDistance: 2224,
+			wantFirst:    10 * time.Hour,
+			wantLast:     10*time.Hour + 50*time.Minute,
+			wantPeak:     1,
+		},
+		{
+			name:         "Bus Route Type",
+			stats:        days[0].ByRouteType[RouteTypeBus],
+			wantTrips:    3,
+			wantTime:     90 * time.Minute,
+			wantDistance: 11112,
+			wantFirst:    8 * time.Hour,
+			wantLast:     9*time.Hour + 10*time.Minute,
+			wantPeak:     2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.stats
+			if s == nil {
+				t.Fatalf("statistics missing")
+			}
+
+			if s.Trips != tt.wantTrips {
+				t.Errorf("Trips = %v, want %v", s.Trips, tt.wantTrips)
+			}
+			if s.RevenueTime != tt.wantTime {
+				t.Errorf("RevenueTime = %v, want %v", s.RevenueTime, tt.wantTime)
+			}
+			if math.Abs(s.RevenueDistance-tt.wantDistance) > 2 {
+				t.Errorf("RevenueDistance = %v, want %v", s.RevenueDistance, tt.wantDistance)
+			}
+			if s.FirstDeparture != tt.wantFirst || s.LastArrival != tt.wantLast {
+				t.Errorf("span = %v to %v, want %v to %v", s.FirstDeparture, s.LastArrival, tt.wantFirst, tt.wantLast)
+			}
+			if s.Span() != tt.wantLast-tt.wantFirst {
+				t.Errorf("Span() = %v, want %v", s.Span(), tt.wantLast-tt.wantFirst)
+			}
+			if s.PeakVehicles != tt.wantPeak {
+				t.Errorf("PeakVehicles = %v, want %v", s.PeakVehicles, tt.wantPeak)
+			}
+		})
+	}
+
+	if got := days[0].Total.RevenueHours(); math.Abs(got-130.0/60) > 1e-9 {
+		t.Errorf("ServiceStatistics.RevenueHours() = %v, want %v", got, 130.0/60)
+	}
+	if got := days[0].Total.RevenueKilometers(); math.Abs(got-13.336) > 0.002 {
+		t.Errorf("ServiceStatistics.RevenueKilometers() = %v, want %v", got, 13.336)
+	}
+}
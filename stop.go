@@ -21,7 +21,7 @@ type Stop struct {
 	LocationType       LocationType
 	ParentStation      *Stop
 	Timezone           string
-	WheelchairBoarding string // TODO: parse me
+	WheelchairBoarding WheelchairBoarding
 
 	// Extensions:
 	PlatformCode string
@@ -44,6 +44,29 @@ const (
 	LocationTypeStationEntrance
 )
 
+// WheelchairBoarding indicates whether passengers in wheelchairs may board
+// vehicles at a stop.
+type WheelchairBoarding int
+
+const (
+	// WheelchairBoardingUnknown means that no wheelchair boarding information
+	// is available.
+	//
+	// Stops and station entrances with this value inherit the value of their
+	// parent station. See EffectiveWheelchairBoarding.
+	WheelchairBoardingUnknown WheelchairBoarding = iota
+
+	// WheelchairBoardingYes means that at least some vehicles at this stop may
+	// be boarded by passengers in wheelchairs or, for station entrances, that
+	// the entrance is wheelchair accessible.
+	WheelchairBoardingYes
+
+	// WheelchairBoardingNo means that passengers in wheelchairs may not board
+	// vehicles at this stop or, for station entrances, that the entrance is
+	// not wheelchair accessible.
+	WheelchairBoardingNo
+)
+
 var stopFields = map[string]bool{
 	"stop_id":             true,
 	"stop_code":           false,
@@ -87,6 +110,11 @@ func (g *GTFS) processStops(r io.Reader) error {
 			return err
 		}
 
+		wheelchairBoarding, err := parseWheelchairBoarding(row["wheelchair_boarding"])
+		if err != nil {
+			return err
+		}
+
 		var vehicleType RouteType
 		if row["vehicle_type"] != "" {
 			vehicleType, err = parseRouteType(row["vehicle_type"])
@@ -106,7 +134,7 @@ func (g *GTFS) processStops(r io.Reader) error {
 			URL:                row["stop_url"],
 			LocationType:       locType,
 			Timezone:           row["stop_timezone"],
-			WheelchairBoarding: row["wheelchair_boarding"],
+			WheelchairBoarding: wheelchairBoarding,
 
 			PlatformCode: row["platform_code"],
 			VehicleType:  vehicleType,
@@ -123,7 +151,7 @@ func (g *GTFS) processStops(r io.Reader) error {
 			continue
 		}
 
-		if s.LocationType != LocationTypeStop {
+		if s.LocationType == LocationTypeStation {
 			return fmt.Errorf("invalid location type with parent station: %d", s.LocationType)
 		}
 
@@ -146,6 +174,36 @@ func (g *GTFS) stopByID(id string) *Stop {
 	return g.stopsByID[id]
 }
 
+// EffectiveWheelchairBoarding returns the wheelchair boarding value that applies
+// to s.
+//
+// Stops and station entrances without wheelchair boarding information inherit
+// the value of their parent station.
+func (s *Stop) EffectiveWheelchairBoarding() WheelchairBoarding {
+	if s.WheelchairBoarding != WheelchairBoardingUnknown || s.ParentStation == nil {
+		return s.WheelchairBoarding
+	}
+
+	if s.LocationType != LocationTypeStop && s.LocationType != LocationTypeStationEntrance {
+		return s.WheelchairBoarding
+	}
+
+	return s.ParentStation.WheelchairBoarding
+}
+
+func parseWheelchairBoarding(val string) (WheelchairBoarding, error) {
+	switch val {
+	case "0", "":
+		return WheelchairBoardingUnknown, nil
+	case "1":
+		return WheelchairBoardingYes, nil
+	case "2":
+		return WheelchairBoardingNo, nil
+	default:
+		return WheelchairBoardingUnknown, fmt.Errorf("invalid wheelchair boarding value: %s", val)
+	}
+}
+
 func parseLocationType(val string) (LocationType, error) {
 	switch val {
 	case "0", "":
@@ -34,7 +34,7 @@ func TestGTFS_processStops(t *testing.T) {
 		parentStationID:    "",
 		ParentStation:      nil,
 		Timezone:           "America/Chicago",
-		WheelchairBoarding: "0",
+		WheelchairBoarding: WheelchairBoardingUnknown,
 		PlatformCode:       "",
 		VehicleType:        RouteTypeCableCar,
 	}
@@ -51,7 +51,7 @@ func TestGTFS_processStops(t *testing.T) {
 		parentStationID:    "2",
 		ParentStation:      testStation1,
 		Timezone:           "America/Chicago",
-		WheelchairBoarding: "0",
+		WheelchairBoarding: WheelchairBoardingUnknown,
 		PlatformCode:       "",
 		VehicleType:        RouteTypeCableCar,
 	}
@@ -310,3 +310,113 @@ func Test_parseLocationType(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseWheelchairBoarding(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    WheelchairBoarding
+		wantErr bool
+	}{
+		{
+			name:    "Unknown (empty)",
+			val:     "",
+			want:    WheelchairBoardingUnknown,
+			wantErr: false,
+		},
+		{
+			name:    "Unknown (0)",
+			val:     "0",
+			want:    WheelchairBoardingUnknown,
+			wantErr: false,
+		},
+		{
+			name:    "Yes",
+			val:     "1",
+			want:    WheelchairBoardingYes,
+			wantErr: false,
+		},
+		{
+			name:    "No",
+			val:     "2",
+			want:    WheelchairBoardingNo,
+			wantErr: false,
+		},
+		{
+			name:    "Invalid",
+			val:     "3",
+			want:    WheelchairBoardingUnknown,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWheelchairBoarding(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseWheelchairBoarding() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseWheelchairBoarding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStop_EffectiveWheelchairBoarding(t *testing.T) {
+	station := &Stop{
+		ID:                 "station",
+		LocationType:       LocationTypeStation,
+		WheelchairBoarding: WheelchairBoardingYes,
+	}
+	tests := []struct {
+		name string
+		stop *Stop
+		want WheelchairBoarding
+	}{
+		{
+			name: "Inherited (stop)",
+			stop: &Stop{
+				LocationType:  LocationTypeStop,
+				ParentStation: station,
+			},
+			want: WheelchairBoardingYes,
+		},
+		{
+			name: "Inherited (entrance)",
+			stop: &Stop{
+				LocationType:  LocationTypeStationEntrance,
+				ParentStation: station,
+			},
+			want: WheelchairBoardingYes,
+		},
+		{
+			name: "Overridden",
+			stop: &Stop{
+				LocationType:       LocationTypeStop,
+				ParentStation:      station,
+				WheelchairBoarding: WheelchairBoardingNo,
+			},
+			want: WheelchairBoardingNo,
+		},
+		{
+			name: "No Parent",
+			stop: &Stop{
+				LocationType: LocationTypeStop,
+			},
+			want: WheelchairBoardingUnknown,
+		},
+		{
+			name: "Station",
+			stop: station,
+			want: WheelchairBoardingYes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stop.EffectiveWheelchairBoarding(); got != tt.want {
+				t.Errorf("Stop.EffectiveWheelchairBoarding() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package gtfs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateFormat is the layout of dates in GTFS files, for use with time.Parse.
+const dateFormat = "20060102"
+
+// ParseTime parses a time in the HH:MM:SS format used by GTFS files, returning
+// the amount of time elapsed since the start of the service day.
+//
+// As in GTFS files, hours may be greater than 23 for times after midnight at
+// the end of a service day, and may be given as a single digit.
+func ParseTime(val string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(val), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time: %s", val)
+	}
+
+	var res time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		n, err := strconv.ParseUint(parts[i], 10, 32)
+		if err != nil || (i > 0 && (len(parts[i]) != 2 || n > 59)) {
+			return 0, fmt.Errorf("invalid time: %s", val)
+		}
+
+		res += time.Duration(n) * unit
+	}
+
+	return res, nil
+}
+
+// FormatTime formats an amount of time elapsed since the start of the service
+// day in the HH:MM:SS format used by GTFS files.
+func FormatTime(d time.Duration) string {
+	secs := int64(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", secs/3600, (secs/60)%60, secs%60)
+}
+
+// ArrivalOffset returns the arrival time at s as the amount of time elapsed
+// since the start of the service day.
+//
+// An error is returned if s has no arrival time.
+func (s *StopTime) ArrivalOffset() (time.Duration, error) {
+	return ParseTime(s.ArrivalTime)
+}
+
+// DepartureOffset returns the departure time from s as the amount of time
+// elapsed since the start of the service day.
+//
+// An error is returned if s has no departure time.
+func (s *StopTime) DepartureOffset() (time.Duration, error) {
+	return ParseTime(s.DepartureTime)
+}
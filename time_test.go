@@ -0,0 +1,107 @@
+package gtfs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "Midnight",
+			val:     "00:00:00",
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name:    "Morning",
+			val:     "08:15:30",
+			want:    8*time.Hour + 15*time.Minute + 30*time.Second,
+			wantErr: false,
+		},
+		{
+			name:    "Single-Digit Hour",
+			val:     "8:15:30",
+			want:    8*time.Hour + 15*time.Minute + 30*time.Second,
+			wantErr: false,
+		},
+		{
+			name:    "After Midnight",
+			val:     "25:01:00",
+			want:    25*time.Hour + time.Minute,
+			wantErr: false,
+		},
+		{
+			name:    "Empty",
+			val:     "",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Missing Seconds",
+			val:     "08:15",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Minutes",
+			val:     "08:60:00",
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name:    "Negative",
+			val:     "-1:00:00",
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTime(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{
+			name: "Midnight",
+			d:    0,
+			want: "00:00:00",
+		},
+		{
+			name: "Morning",
+			d:    8*time.Hour + 15*time.Minute + 30*time.Second,
+			want: "08:15:30",
+		},
+		{
+			name: "After Midnight",
+			d:    25*time.Hour + time.Minute,
+			want: "25:01:00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatTime(tt.d); got != tt.want {
+				t.Errorf("FormatTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
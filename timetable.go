@@ -0,0 +1,589 @@
+package gtfs
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// A Timetable is a printable schedule for a single direction of a route, with a
+// row for each stop and a column for each trip.
+type Timetable struct {
+	Route       *Route
+	DirectionID string
+
+	// Services contains the services operating the timetable. Services with
+	// identical schedules share a single timetable.
+	Services []*Service
+
+	// Stops contains the stop served in each row, in the order they are
+	// served by the route's main pattern. Stops served by other patterns are
+	// inserted where they are served. A stop may appear in multiple rows if a
+	// trip serves it more than once.
+	Stops []*Stop
+
+	// Trips contains the trip in each column, in order of departure. Trips
+	// are ordered by their times at a stop they both serve, so that each row
+	// is in order wherever possible.
+	Trips []*TimetableTrip
+}
+
+// A TimetableTrip is a single column of a timetable.
+type TimetableTrip struct {
+	Trip *Trip
+
+	// Offset is the amount of time added to the times of each stop time to
+	// obtain those of this run of the trip, as returned by
+	// Trip.InstanceOffsets. It is zero for trips that aren't frequency-based.
+	Offset time.Duration
+
+	// StopTimes contains the trip's stop time in each row of the timetable,
+	// or nil for rows with stops that the trip doesn't serve.
+	StopTimes []*StopTime
+}
+
+// Time returns the time at which t departs from the stop in the specified row,
+// or arrives at it if it is the last stop of the trip, along with whether t
+// serves the stop at a scheduled time. Stops that t serves without a time have
+// a non-nil stop time in StopTimes.
+func (t *TimetableTrip) Time(row int) (time.Duration, bool) {
+	st := t.StopTimes[row]
+	if st == nil {
+		return 0, false
+	}
+
+	val := st.DepartureTime
+	if val == "" || (len(t.Trip.Stops) > 0 && st == t.Trip.Stops[len(t.Trip.Stops)-1] && st.ArrivalTime != "") {
+		val = st.ArrivalTime
+	}
+
+	d, err := ParseTime(val)
+	if err != nil {
+		return 0, false
+	}
+
+	return d + t.Offset, true
+}
+
+// Timetables builds timetables for trips on route in the specified direction,
+// with a separate timetable for each service. Services with identical
+// schedules are merged into a single timetable.
+func (g *GTFS) Timetables(route *Route, directionID string) ([]*Timetable, error) {
+	var services []*Service
+	tripsByService := map[*Service][]*Trip{}
+	for _, t := range g.Trips {
+		if t.Route != route || t.DirectionID != directionID || t.Service == nil {
+			continue
+		}
+
+		if _, ok := tripsByService[t.Service]; !ok {
+			services = append(services, t.Service)
+		}
+
+		tripsByService[t.Service] = append(tripsByService[t.Service], t)
+	}
+
+	var res []*Timetable
+	timetablesByKey := map[string]*Timetable{}
+	for _, s := range services {
+		tt, err := newTimetable(route, directionID, []*Service{s}, tripsByService[s])
+		if err != nil {
+			return nil, err
+		}
+
+		key := tt.key()
+		if existing, ok := timetablesByKey[key]; ok {
+			existing.Services = append(existing.Services, s)
+			continue
+		}
+
+		timetablesByKey[key] = tt
+		res = append(res, tt)
+	}
+
+	return res, nil
+}
+
+// TimetableForService builds a timetable for trips on route in the specified
+// direction operated by service.
+func (g *GTFS) TimetableForService(route *Route, directionID string, service *Service) (*Timetable, error) {
+	var trips []*Trip
+	for _, t := range g.Trips {
+		if t.Route == route && t.DirectionID == directionID && t.Service == service {
+			trips = append(trips, t)
+		}
+	}
+
+	return newTimetable(route, directionID, []*Service{service}, trips)
+}
+
+// TimetableForDate builds a timetable for trips on route in the specified
+// direction that operate on date.
+func (g *GTFS) TimetableForDate(route *Route, directionID string, date time.Time) (*Timetable, error) {
+	var trips []*Trip
+	var services []*Service
+	seen := map[*Service]bool{}
+	for _, t := range g.Trips {
+		if t.Route != route || t.DirectionID != directionID || t.Service == nil || !t.Service.IsActiveOn(date) {
+			continue
+		}
+
+		if !seen[t.Service] {
+			seen[t.Service] = true
+			services = append(services, t.Service)
+		}
+
+		trips = append(trips, t)
+	}
+
+	return newTimetable(route, directionID, services, trips)
+}
+
+// newTimetable builds a timetable from trips.
+func newTimetable(route *Route, directionID string, services []*Service, trips []*Trip) (*Timetable, error) {
+	res := &Timetable{
+		Route:       route,
+		DirectionID: directionID,
+		Services:    services,
+	}
+
+	// Add the stops of the most common patterns first, so that the main
+	// pattern determines the order of stops
+	patterns := tripPatterns(trips)
+	sort.SliceStable(patterns, func(i, j int) bool {
+		return len(patterns[i].Trips) > len(patterns[j].Trips)
+	})
+
+	var rows []*timetableRow
+	rowsByTrip := map[*Trip][]*timetableRow{}
+	for _, p := range patterns {
+		var patternRows []*timetableRow
+		rows, patternRows = addTimetablePattern(rows, p.Stops)
+		for _, t := range p.Trips {
+			rowsByTrip[t] = patternRows
+		}
+	}
+
+	indices := map[*timetableRow]int{}
+	for i, r := range rows {
+		indices[r] = i
+		res.Stops = append(res.Stops, r.stop)
+	}
+
+	for _, t := range trips {
+		offsets, err := t.InstanceOffsets()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, offset := range offsets {
+			col := &TimetableTrip{
+				Trip:   t,
+				Offset: offset,
+			}
+
+			res.Trips = append(res.Trips, col)
+		}
+	}
+
+	for _, col := range res.Trips {
+		col.StopTimes = make([]*StopTime, len(res.Stops))
+		for i, row := range rowsByTrip[col.Trip] {
+			col.StopTimes[indices[row]] = col.Trip.Stops[i]
+		}
+	}
+
+	orderTimetableTrips(res.Trips)
+
+	return res, nil
+}
+
+// A timetableRow is a row of a timetable under construction.
+type timetableRow struct {
+	stop *Stop
+}
+
+// addTimetablePattern adds rows to rows for any of stops that don't already
+// have them, returning the updated rows and the row of each stop. Stops are
+// matched to existing rows in order, and new rows are inserted after the row
+// of the previous stop.
+func addTimetablePattern(rows []*timetableRow, stops []*Stop) ([]*timetableRow, []*timetableRow) {
+	res := make([]*timetableRow, len(stops))
+	last := -1
+	for i, s := range stops {
+		found := -1
+		for r := last + 1; r < len(rows); r++ {
+			if rows[r].stop == s {
+				found = r
+				break
+			}
+		}
+
+		if found < 0 {
+			found = last + 1
+			rows = append(rows, nil)
+			copy(rows[found+1:], rows[found:])
+			rows[found] = &timetableRow{stop: s}
+		}
+
+		res[i] = rows[found]
+		last = found
+	}
+
+	return rows, res
+}
+
+// orderTimetableTrips sorts the columns of a timetable in order of departure.
+//
+// Columns are first sorted by their first scheduled times, and each is then
+// moved before any earlier columns that it runs ahead of, as reported by
+// runsBefore. Since trips serving different stops can't always be ordered
+// consistently, this is done by insertion rather than with sort.
+func orderTimetableTrips(cols []*TimetableTrip) {
+	first := make(map[*TimetableTrip]time.Duration, len(cols))
+	for _, col := range cols {
+		if row := col.firstTimedRow(); row >= 0 {
+			first[col], _ = col.Time(row)
+		}
+	}
+
+	sort.SliceStable(cols, func(i, j int) bool {
+		return first[cols[i]] < first[cols[j]]
+	})
+
+	for i := 1; i < len(cols); i++ {
+		col := cols[i]
+		j := i
+		for j > 0 && col.runsBefore(cols[j-1]) {
+			j--
+		}
+
+		copy(cols[j+1:i+1], cols[j:i])
+		cols[j] = col
+	}
+}
+
+// runsBefore reports whether t runs ahead of other. Trips are compared at the
+// first row in which both have different scheduled times. If they have no
+// scheduled times in common, they are compared at the later of their first
+// scheduled rows, using times interpolated between the rows that each trip
+// serves at a scheduled time.
+func (t *TimetableTrip) runsBefore(other *TimetableTrip) bool {
+	shared := false
+	for row := range t.StopTimes {
+		d, ok := t.Time(row)
+		otherD, otherOK := other.Time(row)
+		if !ok || !otherOK {
+			continue
+		}
+
+		if d != otherD {
+			return d < otherD
+		}
+
+		shared = true
+	}
+
+	if shared {
+		return false
+	}
+
+	row, otherRow := t.firstTimedRow(), other.firstTimedRow()
+	if row < 0 || otherRow < 0 {
+		return false
+	}
+
+	if otherRow > row {
+		row = otherRow
+	}
+
+	return t.interpolatedTime(row) < other.interpolatedTime(row)
+}
+
+// firstTimedRow returns the first row that t serves at a scheduled time, or -1
+// if there is none.
+func (t *TimetableTrip) firstTimedRow() int {
+	for row := range t.StopTimes {
+		if _, ok := t.Time(row); ok {
+			return row
+		}
+	}
+
+	return -1
+}
+
+// interpolatedTime estimates the time of t at the specified row from the
+// closest rows before and after it that t serves at a scheduled time,
+// interpolating linearly between them. Rows before the first scheduled time or
+// after the last take the time of the closest row. t must have at least one
+// scheduled time.
+func (t *TimetableTrip) interpolatedTime(row int) time.Duration {
+	if d, ok := t.Time(row); ok {
+		return d
+	}
+
+	prev, next := -1, -1
+	var prevD, nextD time.Duration
+	for r := row - 1; r >= 0 && prev < 0; r-- {
+		if d, ok := t.Time(r); ok {
+			prev, prevD = r, d
+		}
+	}
+
+	for r := row + 1; r < len(t.StopTimes) && next < 0; r++ {
+		if d, ok := t.Time(r); ok {
+			next, nextD = r, d
+		}
+	}
+
+	switch {
+	case prev < 0:
+		return nextD
+	case next < 0:
+		return prevD
+	default:
+		return prevD + (nextD-prevD)*time.Duration(row-prev)/time.Duration(next-prev)
+	}
+}
+
+// key returns a string identifying the stops and times in tt.
+func (tt *Timetable) key() string {
+	var b strings.Builder
+	for _, s := range tt.Stops {
+		b.WriteString(stopID(s))
+		b.WriteByte('|')
+	}
+
+	for _, col := range tt.Trips {
+		b.WriteByte(';')
+		for row := range tt.Stops {
+			if d, ok := col.Time(row); ok {
+				b.WriteString(FormatTime(d))
+			} else if col.StopTimes[row] != nil {
+				b.WriteString(timetableUntimed)
+			}
+			b.WriteByte(',')
+		}
+	}
+
+	return b.String()
+}
+
+var timetableDays = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// Days returns a description of the days of the week on which tt operates
+// (e.g. "Mon-Fri" or "Sat, Sun"), based on the weekly schedules of its
+// services. If its services don't operate on a weekly schedule, their IDs are
+// returned instead.
+func (tt *Timetable) Days() string {
+	var days [7]bool
+	weekly := false
+	for _, s := range tt.Services {
+		for i, active := range []bool{s.Monday, s.Tuesday, s.Wednesday, s.Thursday, s.Friday, s.Saturday, s.Sunday} {
+			if active {
+				days[i] = true
+				weekly = true
+			}
+		}
+	}
+
+	if !weekly {
+		ids := make([]string, 0, len(tt.Services))
+		for _, s := range tt.Services {
+			ids = append(ids, s.ID)
+		}
+
+		return strings.Join(ids, ", ")
+	}
+
+	var parts []string
+	for i := 0; i < len(days); i++ {
+		if !days[i] {
+			continue
+		}
+
+		j := i
+		for j+1 < len(days) && days[j+1] {
+			j++
+		}
+
+		switch {
+		case i == 0 && j == 6:
+			return "Daily"
+		case j-i >= 2:
+			parts = append(parts, timetableDays[i]+"-"+timetableDays[j])
+		case j-i == 1:
+			parts = append(parts, timetableDays[i], timetableDays[j])
+		default:
+			parts = append(parts, timetableDays[i])
+		}
+
+		i = j
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Title returns a title for tt made up of the route's name and the days on
+// which tt operates.
+func (tt *Timetable) Title() string {
+	name := ""
+	if tt.Route != nil {
+		name = routeName(tt.Route)
+	}
+
+	if days := tt.Days(); days != "" {
+		return name + " (" + days + ")"
+	}
+
+	return name
+}
+
+// header returns the heading of each trip column.
+func (tt *Timetable) header() []string {
+	res := make([]string, 0, len(tt.Trips))
+	for _, col := range tt.Trips {
+		name := col.Trip.ShortName
+		if name == "" {
+			name = col.Trip.ID
+		}
+
+		res = append(res, name)
+	}
+
+	return res
+}
+
+// timetableUntimed is shown for stops that a trip serves without a scheduled
+// time.
+const timetableUntimed = "•"
+
+// cell returns the text of the cell in the specified row and column, or empty
+// if the trip doesn't serve the stop.
+func (tt *Timetable) cell(row, col int) string {
+	t := tt.Trips[col]
+	if d, ok := t.Time(row); ok {
+		return formatTimetableTime(d)
+	}
+
+	if t.StopTimes[row] != nil {
+		return timetableUntimed
+	}
+
+	return ""
+}
+
+// formatTimetableTime formats d as a time of day in the HH:MM format, wrapping
+// times after midnight.
+func formatTimetableTime(d time.Duration) string {
+	mins := int64(d / time.Minute)
+	return fmt.Sprintf("%02d:%02d", (mins/60)%24, mins%60)
+}
+
+// WriteCSV writes tt to w as CSV, with a header row containing the trip in each
+// column and a row for each stop. Cells for stops that a trip doesn't serve are
+// empty, and stops that it serves without a scheduled time are shown as "•".
+func (tt *Timetable) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"stop_id", "stop_name"}, tt.header()...)); err != nil {
+		return err
+	}
+
+	for row, s := range tt.Stops {
+		record := []string{stopID(s), stopName(s)}
+		for col := range tt.Trips {
+			record = append(record, tt.cell(row, col))
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// WriteText writes tt to w as plain text, with aligned columns and a title.
+// Stops that a trip doesn't serve are shown as "-", and stops that it serves
+// without a scheduled time as "•".
+func (tt *Timetable) WriteText(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%s\n\n", tt.Title()); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintf(tw, "\t%s\t\n", strings.Join(tt.header(), "\t")); err != nil {
+		return err
+	}
+
+	for row, s := range tt.Stops {
+		cells := make([]string, 0, len(tt.Trips))
+		for col := range tt.Trips {
+			c := tt.cell(row, col)
+			if c == "" {
+				c = "-"
+			}
+
+			cells = append(cells, c)
+		}
+
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t\n", stopName(s), strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return tw.Flush()
+}
+
+// WriteHTML writes tt to w as an HTML table, with the title as its caption.
+// Stops that a trip doesn't serve are shown as a dash, and stops that it serves
+// without a scheduled time as a bullet.
+func (tt *Timetable) WriteHTML(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("<table class=\"timetable\">\n")
+	fmt.Fprintf(&b, "<caption>%s</caption>\n", html.EscapeString(tt.Title()))
+
+	b.WriteString("<thead>\n<tr><th>Stop</th>")
+	for _, h := range tt.header() {
+		fmt.Fprintf(&b, "<th>%s</th>", html.EscapeString(h))
+	}
+	b.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+	for row, s := range tt.Stops {
+		fmt.Fprintf(&b, "<tr><th>%s</th>", html.EscapeString(stopName(s)))
+		for col := range tt.Trips {
+			c := tt.cell(row, col)
+			if c == "" {
+				c = "&mdash;"
+			}
+
+			fmt.Fprintf(&b, "<td>%s</td>", c)
+		}
+		b.WriteString("</tr>\n")
+	}
+
+	b.WriteString("</tbody>\n</table>\n")
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func stopName(s *Stop) string {
+	if s == nil {
+		return ""
+	}
+
+	if s.Name == "" {
+		return s.ID
+	}
+
+	return s.Name
+}
@@ -0,0 +1,303 @@
+package gtfs
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTimetableTestFeed() (*GTFS, *Route) {
+	a := &Stop{ID: "a", Name: "Alpha"}
+	b := &Stop{ID: "b", Name: "Beta"}
+	c := &Stop{ID: "c", Name: "Gamma & Delta"}
+	d := &Stop{ID: "d", Name: "Epsilon"}
+	x := &Stop{ID: "x", Name: "Detour"}
+
+	monThu := &Service{ID: "mon_thu", Monday: true, Tuesday: true, Wednesday: true, Thursday: true, StartDate: "20190101", EndDate: "20191231"}
+	fri := &Service{ID: "fri", Friday: true, StartDate: "20190101", EndDate: "20191231"}
+	sat := &Service{ID: "sat", Saturday: true, StartDate: "20190101", EndDate: "20191231"}
+
+	route := &Route{ID: "r", ShortName: "1", LongName: "Main Street"}
+
+	var trips []*Trip
+	add := func(id string, s *Service, direction string, stops []*Stop, times []string) {
+		t := newBlockTestTrip(id, "", s, stops, times)
+		t.Route = route
+		t.DirectionID = direction
+		trips = append(trips, t)
+	}
+
+	for _, s := range []*Service{monThu, fri} {
+		// Trips are deliberately out of order
+		add(s.ID+"_2", s, "0", []*Stop{a, b, c, d}, []string{"09:00:00", "09:05:00", "09:10:00", "09:20:00"})
+		add(s.ID+"_1", s, "0", []*Stop{a, b, c, d}, []string{"08:00:00", "08:05:00", "08:10:00", "08:20:00"})
+		add(s.ID+"_3", s, "0", []*Stop{a, c, d}, []string{"10:00:00", "10:08:00", "10:18:00"})
+		add(s.ID+"_4", s, "0", []*Stop{b, x, c}, []string{"08:30:00", "08:33:00", "08:38:00"})
+		add(s.ID+"_5", s, "1", []*Stop{d, c, b, a}, []string{"12:00:00", "12:10:00", "12:15:00", "12:20:00"})
+	}
+
+	add("sat_1", sat, "0", []*Stop{a, b, c, d}, []string{"10:00:00", "10:05:00", "10:10:00", "24:20:00"})
+
+	freq := newBlockTestTrip("sat_freq", "", sat, []*Stop{a, c}, []string{"00:00:00", "00:07:00"})
+	freq.Route = route
+	freq.DirectionID = "0"
+	freq.AbsoluteTimes = false
+	freq.Frequencies = []Frequency{{StartTime: "11:00:00", EndTime: "12:00:00", HeadwaySeconds: 1800}}
+	trips = append(trips, freq)
+
+	return &GTFS{
+		Stops:    []*Stop{a, b, c, d, x},
+		Routes:   []*Route{route},
+		Services: []*Service{monThu, fri, sat},
+		Trips:    trips,
+	}, route
+}
+
+// timetableCells returns the cells of tt, in rows.
+func timetableCells(tt *Timetable) [][]string {
+	var res [][]string
+	for row, s := range tt.Stops {
+		cells := []string{s.ID}
+		for col := range tt.Trips {
+			cells = append(cells, tt.cell(row, col))
+		}
+
+		res = append(res, cells)
+	}
+
+	return res
+}
+
+func TestGTFS_Timetables(t *testing.T) {
+	g, route := newTimetableTestFeed()
+
+	tts, err := g.Timetables(route, "0")
+	if err != nil {
+		t.Fatalf("GTFS.Timetables() error = %v", err)
+	}
+
+	if len(tts) != 2 {
+		t.Fatalf("GTFS.Timetables() returned %d timetables, want 2", len(tts))
+	}
+
+	weekday, saturday := tts[0], tts[1]
+	if len(weekday.Services) != 2 || weekday.Services[0].ID != "mon_thu" || weekday.Services[1].ID != "fri" {
+		t.Errorf("GTFS.Timetables() weekday services = %v", weekday.Services)
+	}
+
+	if got := weekday.Days(); got != "Mon-Fri" {
+		t.Errorf("Timetable.Days() = %v, want Mon-Fri", got)
+	}
+
+	wantWeekday := [][]string{
+		{"a", "08:00", "", "09:00", "10:00"},
+		{"b", "08:05", "08:30", "09:05", ""},
+		{"x", "", "08:33", "", ""},
+		{"c", "08:10", "08:38", "09:10", "10:08"},
+		{"d", "08:20", "", "09:20", "10:18"},
+	}
+	if got := timetableCells(weekday); !reflect.DeepEqual(got, wantWeekday) {
+		t.Errorf("GTFS.Timetables() weekday = %v, want %v", got, wantWeekday)
+	}
+
+	wantSaturday := [][]string{
+		{"a", "10:00", "11:00", "11:30"},
+		{"b", "10:05", "", ""},
+		{"c", "10:10", "11:07", "11:37"},
+		{"d", "00:20", "", ""},
+	}
+	if got := timetableCells(saturday); !reflect.DeepEqual(got, wantSaturday) {
+		t.Errorf("GTFS.Timetables() saturday = %v, want %v", got, wantSaturday)
+	}
+
+	if saturday.Trips[1].Trip != saturday.Trips[2].Trip || saturday.Trips[2].Offset != 11*time.Hour+30*time.Minute {
+		t.Errorf("GTFS.Timetables() didn't expand frequency-based trip")
+	}
+}
+
+func TestGTFS_TimetableForDate(t *testing.T) {
+	g, route := newTimetableTestFeed()
+
+	// A Friday
+	tt, err := g.TimetableForDate(route, "1", time.Date(2019, 3, 8, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("GTFS.TimetableForDate() error = %v", err)
+	}
+
+	want := [][]string{
+		{"d", "12:00"},
+		{"c", "12:10"},
+		{"b", "12:15"},
+		{"a", "12:20"},
+	}
+	if got := timetableCells(tt); !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.TimetableForDate() = %v, want %v", got, want)
+	}
+
+	if len(tt.Services) != 1 || tt.Services[0].ID != "fri" {
+		t.Errorf("GTFS.TimetableForDate() services = %v", tt.Services)
+	}
+
+	tt, err = g.TimetableForService(route, "1", g.Services[2])
+	if err != nil || len(tt.Stops) != 0 || len(tt.Trips) != 0 {
+		t.Errorf("GTFS.TimetableForService() = %v, %v, want empty timetable", tt, err)
+	}
+}
+
+func TestGTFS_TimetableForService(t *testing.T) {
+	a := &Stop{ID: "a"}
+	b := &Stop{ID: "b"}
+	c := &Stop{ID: "c"}
+	d := &Stop{ID: "d"}
+	s := &Service{ID: "daily"}
+	route := &Route{ID: "r"}
+
+	trips := []*Trip{
+		newBlockTestTrip("late", "", s, []*Stop{a, b, c}, []string{"09:00:00", "09:10:00", "09:20:00"}),
+		newBlockTestTrip("short", "", s, []*Stop{b, d}, []string{"08:40:00", "09:00:00"}),
+		newBlockTestTrip("untimed", "", s, []*Stop{a, b, c}, []string{"08:30:00", "", "08:50:00"}),
+		newBlockTestTrip("early", "", s, []*Stop{a, b, c}, []string{"08:00:00", "08:10:00", "08:20:00"}),
+	}
+	for _, trip := range trips {
+		trip.Route = route
+	}
+
+	g := &GTFS{Trips: trips}
+
+	tt, err := g.TimetableForService(route, "", s)
+	if err != nil {
+		t.Fatalf("GTFS.TimetableForService() error = %v", err)
+	}
+
+	want := [][]string{
+		{"a", "08:00", "08:30", "", "09:00"},
+		{"b", "08:10", "•", "08:40", "09:10"},
+		{"d", "", "", "09:00", ""},
+		{"c", "08:20", "08:50", "", "09:20"},
+	}
+	if got := timetableCells(tt); !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.TimetableForService() = %v, want %v", got, want)
+	}
+}
+
+func TestGTFS_TimetableForService_shortTurn(t *testing.T) {
+	stops := make([]*Stop, 6)
+	for i := range stops {
+		stops[i] = &Stop{ID: fmt.Sprintf("s%d", i+1)}
+	}
+	s := &Service{ID: "daily"}
+	route := &Route{ID: "r"}
+
+	// The short-turn trip starts after the full trip, but reaches the stop
+	// they share first
+	trips := []*Trip{
+		newBlockTestTrip("full", "", s, stops, []string{"08:05:00", "08:09:00", "08:13:00", "08:17:00", "08:20:00", "08:25:00"}),
+		newBlockTestTrip("short", "", s, stops[4:], []string{"08:10:00", "08:15:00"}),
+	}
+	for _, trip := range trips {
+		trip.Route = route
+	}
+
+	g := &GTFS{Trips: trips}
+
+	tt, err := g.TimetableForService(route, "", s)
+	if err != nil {
+		t.Fatalf("GTFS.TimetableForService() error = %v", err)
+	}
+
+	want := [][]string{
+		{"s1", "", "08:05"},
+		{"s2", "", "08:09"},
+		{"s3", "", "08:13"},
+		{"s4", "", "08:17"},
+		{"s5", "08:10", "08:20"},
+		{"s6", "08:15", "08:25"},
+	}
+	if got := timetableCells(tt); !reflect.DeepEqual(got, want) {
+		t.Errorf("GTFS.TimetableForService() = %v, want %v", got, want)
+	}
+}
+
+func TestTimetable_Days(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []*Service
+		want     string
+	}{
+		{"daily", []*Service{{Monday: true, Tuesday: true, Wednesday: true, Thursday: true, Friday: true, Saturday: true, Sunday: true}}, "Daily"},
+		{"weekend", []*Service{{Saturday: true}, {Sunday: true}}, "Sat, Sun"},
+		{"split", []*Service{{Monday: true, Tuesday: true, Wednesday: true, Friday: true}}, "Mon-Wed, Fri"},
+		{"calendar dates", []*Service{{ID: "holiday"}, {ID: "event"}}, "holiday, event"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (&Timetable{Services: tt.services}).Days(); got != tt.want {
+				t.Errorf("Timetable.Days() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimetable_Write(t *testing.T) {
+	g, route := newTimetableTestFeed()
+	tt, err := g.TimetableForService(route, "0", g.Services[0])
+	if err != nil {
+		t.Fatalf("GTFS.TimetableForService() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		write func(*Timetable, *bytes.Buffer) error
+		want  string
+	}{
+		{
+			"csv",
+			func(tt *Timetable, buf *bytes.Buffer) error { return tt.WriteCSV(buf) },
+			"stop_id,stop_name,mon_thu_1,mon_thu_4,mon_thu_2,mon_thu_3\n" +
+				"a,Alpha,08:00,,09:00,10:00\n" +
+				"b,Beta,08:05,08:30,09:05,\n" +
+				"x,Detour,,08:33,,\n" +
+				"c,Gamma & Delta,08:10,08:38,09:10,10:08\n" +
+				"d,Epsilon,08:20,,09:20,10:18\n",
+		},
+		{
+			"text",
+			func(tt *Timetable, buf *bytes.Buffer) error { return tt.WriteText(buf) },
+			"1 Main Street (Mon-Thu)\n\n" +
+				"               mon_thu_1  mon_thu_4  mon_thu_2  mon_thu_3  \n" +
+				"Alpha          08:00      -          09:00      10:00      \n" +
+				"Beta           08:05      08:30      09:05      -          \n" +
+				"Detour         -          08:33      -          -          \n" +
+				"Gamma & Delta  08:10      08:38      09:10      10:08      \n" +
+				"Epsilon        08:20      -          09:20      10:18      \n",
+		},
+		{
+			"html",
+			func(tt *Timetable, buf *bytes.Buffer) error { return tt.WriteHTML(buf) },
+			"<table class=\"timetable\">\n" +
+				"<caption>1 Main Street (Mon-Thu)</caption>\n" +
+				"<thead>\n<tr><th>Stop</th><th>mon_thu_1</th><th>mon_thu_4</th><th>mon_thu_2</th><th>mon_thu_3</th></tr>\n</thead>\n" +
+				"<tbody>\n" +
+				"<tr><th>Alpha</th><td>08:00</td><td>&mdash;</td><td>09:00</td><td>10:00</td></tr>\n" +
+				"<tr><th>Beta</th><td>08:05</td><td>08:30</td><td>09:05</td><td>&mdash;</td></tr>\n" +
+				"<tr><th>Detour</th><td>&mdash;</td><td>08:33</td><td>&mdash;</td><td>&mdash;</td></tr>\n" +
+				"<tr><th>Gamma &amp; Delta</th><td>08:10</td><td>08:38</td><td>09:10</td><td>10:08</td></tr>\n" +
+				"<tr><th>Epsilon</th><td>08:20</td><td>&mdash;</td><td>09:20</td><td>10:18</td></tr>\n" +
+				"</tbody>\n</table>\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := test.write(tt, &buf); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+
+			if got := buf.String(); got != test.want {
+				t.Errorf("write = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
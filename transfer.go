@@ -6,12 +6,19 @@ import (
 	"strconv"
 )
 
-// A Transfer is specific transfer between two stops.
+// A Transfer is specific transfer between two stops, optionally restricted to
+// specific routes or trips.
 //
-// Fields correspond to columns in transfers.txt.
+// Fields correspond to columns in transfers.txt. From and To may be nil for
+// in-seat transfers, in which case the transfer takes place at the last stop of
+// FromTrip and the first stop of ToTrip.
 type Transfer struct {
 	From                *Stop
 	To                  *Stop
+	FromRoute           *Route
+	ToRoute             *Route
+	FromTrip            *Trip
+	ToTrip              *Trip
 	Type                TransferType
 	MinimumTransferTime uint64
 }
@@ -37,11 +44,27 @@ const (
 	// TransferTypeNone indicates that a transfer between those two stops is not
 	// possible.
 	TransferTypeNone
+
+	// TransferTypeInSeat indicates that passengers can remain on board the
+	// same vehicle between two trips.
+	//
+	// Transfers with this type will have FromTrip and ToTrip set.
+	TransferTypeInSeat
+
+	// TransferTypeReboard indicates that passengers must alight and re-board
+	// the vehicle between two trips, even though it operates both of them.
+	//
+	// Transfers with this type will have FromTrip and ToTrip set.
+	TransferTypeReboard
 )
 
 var transferFields = map[string]bool{
-	"from_stop_id":      true,
-	"to_stop_id":        true,
+	"from_stop_id":      false,
+	"to_stop_id":        false,
+	"from_route_id":     false,
+	"to_route_id":       false,
+	"from_trip_id":      false,
+	"to_trip_id":        false,
 	"transfer_type":     true,
 	"min_transfer_time": false,
 }
@@ -74,6 +97,58 @@ func (g *GTFS) processTransfers(r io.Reader) error {
 			MinimumTransferTime: minTime,
 		}
 
+		// Transfers referring to unknown routes or trips are ignored outside
+		// strict mode, since they can never apply
+		if id := row["from_route_id"]; id != "" {
+			t.FromRoute = g.routeByID(id)
+			if t.FromRoute == nil {
+				if g.strictMode {
+					return fmt.Errorf("invalid from_route_id: %s", id)
+				}
+
+				continue
+			}
+		}
+
+		if id := row["to_route_id"]; id != "" {
+			t.ToRoute = g.routeByID(id)
+			if t.ToRoute == nil {
+				if g.strictMode {
+					return fmt.Errorf("invalid to_route_id: %s", id)
+				}
+
+				continue
+			}
+		}
+
+		if id := row["from_trip_id"]; id != "" {
+			t.FromTrip = g.tripByID(id)
+			if t.FromTrip == nil {
+				if g.strictMode {
+					return fmt.Errorf("invalid from_trip_id: %s", id)
+				}
+
+				continue
+			}
+		}
+
+		if id := row["to_trip_id"]; id != "" {
+			t.ToTrip = g.tripByID(id)
+			if t.ToTrip == nil {
+				if g.strictMode {
+					return fmt.Errorf("invalid to_trip_id: %s", id)
+				}
+
+				continue
+			}
+		}
+
+		if g.strictMode {
+			if err := checkTransferReferences(t, row); err != nil {
+				return err
+			}
+		}
+
 		g.Transfers = append(g.Transfers, t)
 	}
 
@@ -90,7 +165,138 @@ func parseTransferType(val string) (TransferType, error) {
 		return TransferTypeMinimumTime, nil
 	case "3":
 		return TransferTypeNone, nil
+	case "4":
+		return TransferTypeInSeat, nil
+	case "5":
+		return TransferTypeReboard, nil
 	default:
 		return TransferTypeRecommended, fmt.Errorf("invalid transfer type: %s", val)
 	}
 }
+
+// checkTransferReferences checks that t references the stops and trips required
+// by its type.
+func checkTransferReferences(t *Transfer, row map[string]string) error {
+	switch t.Type {
+	case TransferTypeTimed, TransferTypeMinimumTime, TransferTypeNone:
+		if row["from_stop_id"] == "" || row["to_stop_id"] == "" {
+			return fmt.Errorf("transfer of type %d requires from_stop_id and to_stop_id", t.Type)
+		}
+	case TransferTypeInSeat, TransferTypeReboard:
+		if t.FromTrip == nil || t.ToTrip == nil {
+			return fmt.Errorf("transfer of type %d requires from_trip_id and to_trip_id", t.Type)
+		}
+	}
+
+	return nil
+}
+
+// AppliesToAllTrips reports whether t applies to all trips between its stops,
+// rather than only to specific routes or trips.
+func (t *Transfer) AppliesToAllTrips() bool {
+	return t.FromRoute == nil && t.ToRoute == nil && t.FromTrip == nil && t.ToTrip == nil
+}
+
+// specificity ranks how specific t is, from 1 (both trips specified) to 6
+// (only stops specified), following the order of precedence in the GTFS
+// specification.
+func (t *Transfer) specificity() int {
+	trips := 0
+	if t.FromTrip != nil {
+		trips++
+	}
+	if t.ToTrip != nil {
+		trips++
+	}
+
+	routes := 0
+	if t.FromRoute != nil {
+		routes++
+	}
+	if t.ToRoute != nil {
+		routes++
+	}
+
+	switch {
+	case trips == 2:
+		return 1
+	case trips == 1 && routes > 0:
+		return 2
+	case trips == 1:
+		return 3
+	case routes == 2:
+		return 4
+	case routes == 1:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// matches reports whether t applies to a transfer from fromTrip at fromStop to
+// toTrip at toStop.
+//
+// In-seat transfers and their prohibitions only apply between the last stop of
+// fromTrip and the first stop of toTrip, whether or not t references stops.
+func (t *Transfer) matches(fromTrip, toTrip *Trip, fromStop, toStop *Stop) bool {
+	if !transferStopMatches(t.From, fromStop) || !transferStopMatches(t.To, toStop) {
+		return false
+	}
+
+	if t.Type == TransferTypeInSeat || t.Type == TransferTypeReboard {
+		if fromTrip == nil || len(fromTrip.Stops) == 0 || fromTrip.Stops[len(fromTrip.Stops)-1].Stop != fromStop {
+			return false
+		}
+
+		if toTrip == nil || len(toTrip.Stops) == 0 || toTrip.Stops[0].Stop != toStop {
+			return false
+		}
+	}
+
+	if (t.FromTrip != nil && t.FromTrip != fromTrip) || (t.ToTrip != nil && t.ToTrip != toTrip) {
+		return false
+	}
+
+	if t.FromRoute != nil && (fromTrip == nil || fromTrip.Route != t.FromRoute) {
+		return false
+	}
+
+	if t.ToRoute != nil && (toTrip == nil || toTrip.Route != t.ToRoute) {
+		return false
+	}
+
+	return true
+}
+
+// transferStopMatches reports whether a transfer referencing ref applies at s.
+// Transfers without a stop apply at any stop, and transfers referencing a
+// station apply at all stops within it.
+func transferStopMatches(ref, s *Stop) bool {
+	if ref == nil || ref == s {
+		return true
+	}
+
+	return s != nil && ref.LocationType == LocationTypeStation && s.ParentStation == ref
+}
+
+// TransferBetween returns the most specific transfer rule applying to a
+// transfer from fromTrip at fromStop to toTrip at toStop, or nil if there is
+// none.
+//
+// Rules referencing both trips take precedence over rules referencing one trip
+// and a route, then rules referencing one trip, rules referencing both routes,
+// rules referencing one route, and finally rules referencing only stops.
+func (g *GTFS) TransferBetween(fromTrip, toTrip *Trip, fromStop, toStop *Stop) *Transfer {
+	var res *Transfer
+	for _, t := range g.Transfers {
+		if !t.matches(fromTrip, toTrip, fromStop, toStop) {
+			continue
+		}
+
+		if res == nil || t.specificity() < res.specificity() {
+			res = t
+		}
+	}
+
+	return res
+}
@@ -1,6 +1,25 @@
 package gtfs
 
-import "testing"
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testTransfersCSVValid = `from_stop_id,to_stop_id,from_route_id,to_route_id,from_trip_id,to_trip_id,transfer_type,min_transfer_time
+stop-1,stop-2,,,,,2,180
+stop-1,stop-2,route-1,route-2,,,3,
+,,,,trip-1,trip-2,4,`
+
+const testTransfersCSVInvalidTrip = `from_trip_id,to_trip_id,transfer_type
+trip-1,trip-3,4`
+
+const testTransfersCSVMissingStops = `from_stop_id,transfer_type
+stop-1,2`
+
+const testTransfersCSVMissingTrips = `from_stop_id,to_stop_id,transfer_type
+stop-1,stop-2,5`
 
 func Test_parseTransferType(t *testing.T) {
 	tests := []struct {
@@ -40,8 +59,20 @@ func Test_parseTransferType(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:    "Invalid",
+			name:    "In-Seat",
 			val:     "4",
+			want:    TransferTypeInSeat,
+			wantErr: false,
+		},
+		{
+			name:    "Re-Board",
+			val:     "5",
+			want:    TransferTypeReboard,
+			wantErr: false,
+		},
+		{
+			name:    "Invalid",
+			val:     "6",
 			want:    TransferTypeRecommended,
 			wantErr: true,
 		},
@@ -59,3 +90,236 @@ func Test_parseTransferType(t *testing.T) {
 		})
 	}
 }
+
+func TestGTFS_processTransfers(t *testing.T) {
+	stop1 := &Stop{ID: "stop-1"}
+	stop2 := &Stop{ID: "stop-2"}
+	route1 := &Route{ID: "route-1"}
+	route2 := &Route{ID: "route-2"}
+	trip1 := &Trip{ID: "trip-1", Route: route1}
+	trip2 := &Trip{ID: "trip-2", Route: route2}
+
+	type fields struct {
+		strictMode bool
+	}
+	type args struct {
+		r io.Reader
+	}
+	tests := []struct {
+		name          string
+		fields        fields
+		args          args
+		wantErr       bool
+		wantTransfers []*Transfer
+	}{
+		{
+			name: "Valid",
+			args: args{
+				r: strings.NewReader(testTransfersCSVValid),
+			},
+			wantErr: false,
+			wantTransfers: []*Transfer{
+				{
+					From:                stop1,
+					To:                  stop2,
+					Type:                TransferTypeMinimumTime,
+					MinimumTransferTime: 180,
+				},
+				{
+					From:      stop1,
+					To:        stop2,
+					FromRoute: route1,
+					ToRoute:   route2,
+					Type:      TransferTypeNone,
+				},
+				{
+					FromTrip: trip1,
+					ToTrip:   trip2,
+					Type:     TransferTypeInSeat,
+				},
+			},
+		},
+		{
+			name: "Invalid Trip (non-strict)",
+			args: args{
+				r: strings.NewReader(testTransfersCSVInvalidTrip),
+			},
+			wantErr:       false,
+			wantTransfers: nil,
+		},
+		{
+			name: "Invalid Trip (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				r: strings.NewReader(testTransfersCSVInvalidTrip),
+			},
+			wantErr:       true,
+			wantTransfers: nil,
+		},
+		{
+			name: "Missing Stops (non-strict)",
+			args: args{
+				r: strings.NewReader(testTransfersCSVMissingStops),
+			},
+			wantErr: false,
+			wantTransfers: []*Transfer{
+				{
+					From: stop1,
+					Type: TransferTypeMinimumTime,
+				},
+			},
+		},
+		{
+			name: "Missing Stops (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				r: strings.NewReader(testTransfersCSVMissingStops),
+			},
+			wantErr:       true,
+			wantTransfers: nil,
+		},
+		{
+			name: "Missing Trips (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				r: strings.NewReader(testTransfersCSVMissingTrips),
+			},
+			wantErr:       true,
+			wantTransfers: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{
+				stopsByID:  map[string]*Stop{"stop-1": stop1, "stop-2": stop2},
+				routesByID: map[string]*Route{"route-1": route1, "route-2": route2},
+				tripsByID:  map[string]*Trip{"trip-1": trip1, "trip-2": trip2},
+				strictMode: tt.fields.strictMode,
+			}
+			if err := g.processTransfers(tt.args.r); (err != nil) != tt.wantErr {
+				t.Errorf("GTFS.processTransfers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(g.Transfers, tt.wantTransfers) {
+				t.Errorf("GTFS.processTransfers() Transfers = %v, wantTransfers %v", g.Transfers, tt.wantTransfers)
+			}
+		})
+	}
+}
+
+func TestGTFS_TransferBetween(t *testing.T) {
+	station := &Stop{ID: "station", LocationType: LocationTypeStation}
+	stop1 := &Stop{ID: "stop-1", ParentStation: station}
+	stop2 := &Stop{ID: "stop-2", ParentStation: station}
+	stop3 := &Stop{ID: "stop-3"}
+	route1 := &Route{ID: "route-1"}
+	route2 := &Route{ID: "route-2"}
+	trip1 := &Trip{ID: "trip-1", Route: route1, Stops: []*StopTime{{Stop: stop3}, {Stop: stop1}}}
+	trip2 := &Trip{ID: "trip-2", Route: route2}
+	trip3 := &Trip{ID: "trip-3", Route: route2, Stops: []*StopTime{{Stop: stop2}, {Stop: stop3}}}
+
+	stops := &Transfer{From: station, To: station, Type: TransferTypeMinimumTime, MinimumTransferTime: 300}
+	oneRoute := &Transfer{From: stop1, To: stop2, ToRoute: route2, Type: TransferTypeMinimumTime, MinimumTransferTime: 240}
+	routes := &Transfer{From: stop1, To: stop2, FromRoute: route1, ToRoute: route2, Type: TransferTypeMinimumTime, MinimumTransferTime: 180}
+	oneTrip := &Transfer{From: stop1, To: stop2, ToTrip: trip3, Type: TransferTypeNone}
+	tripAndRoute := &Transfer{FromRoute: route1, ToTrip: trip3, Type: TransferTypeTimed}
+	trips := &Transfer{FromTrip: trip1, ToTrip: trip3, Type: TransferTypeInSeat}
+
+	tests := []struct {
+		name      string
+		transfers []*Transfer
+		fromTrip  *Trip
+		toTrip    *Trip
+		fromStop  *Stop
+		toStop    *Stop
+		want      *Transfer
+	}{
+		{
+			name:      "Station",
+			transfers: []*Transfer{stops},
+			fromTrip:  trip1,
+			toTrip:    trip2,
+			fromStop:  stop1,
+			toStop:    stop2,
+			want:      stops,
+		},
+		{
+			name:      "Wrong Stop",
+			transfers: []*Transfer{stops},
+			fromTrip:  trip1,
+			toTrip:    trip2,
+			fromStop:  stop1,
+			toStop:    stop3,
+			want:      nil,
+		},
+		{
+			name:      "Routes",
+			transfers: []*Transfer{stops, oneRoute, routes, oneTrip},
+			fromTrip:  trip1,
+			toTrip:    trip2,
+			fromStop:  stop1,
+			toStop:    stop2,
+			want:      routes,
+		},
+		{
+			name:      "One Route",
+			transfers: []*Transfer{stops, oneRoute, routes},
+			fromTrip:  trip3,
+			toTrip:    trip2,
+			fromStop:  stop1,
+			toStop:    stop2,
+			want:      oneRoute,
+		},
+		{
+			name:      "Most Specific",
+			transfers: []*Transfer{stops, oneRoute, routes, oneTrip, tripAndRoute, trips},
+			fromTrip:  trip1,
+			toTrip:    trip3,
+			fromStop:  stop1,
+			toStop:    stop2,
+			want:      trips,
+		},
+		{
+			name:      "In-Seat Mid-Trip",
+			transfers: []*Transfer{tripAndRoute, trips},
+			fromTrip:  trip1,
+			toTrip:    trip3,
+			fromStop:  stop3,
+			toStop:    stop3,
+			want:      tripAndRoute,
+		},
+		{
+			name:      "Trip and Route",
+			transfers: []*Transfer{stops, oneRoute, routes, oneTrip, tripAndRoute},
+			fromTrip:  trip1,
+			toTrip:    trip3,
+			fromStop:  stop1,
+			toStop:    stop2,
+			want:      tripAndRoute,
+		},
+		{
+			name:      "One Trip",
+			transfers: []*Transfer{stops, oneRoute, routes, oneTrip},
+			fromTrip:  trip1,
+			toTrip:    trip3,
+			fromStop:  stop1,
+			toStop:    stop2,
+			want:      oneTrip,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{
+				Transfers: tt.transfers,
+			}
+			if got := g.TransferBetween(tt.fromTrip, tt.toTrip, tt.fromStop, tt.toStop); got != tt.want {
+				t.Errorf("GTFS.TransferBetween() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
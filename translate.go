@@ -1,29 +1,66 @@
 package gtfs
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
 
 // A Translation is a single translation between an original string and another
 // language.
 //
-// For example, the following Translation:
+// Both the original Google extension format of translations.txt and the format
+// from the GTFS specification are supported. In the original format, ID is the
+// string being translated. For example, the following Translation:
 //
-// 	Translation{
-// 		ID:          "station-001",
-// 		Language:    "en",
-// 		Translation: "City Center",
-// 	}
+//	Translation{
+//		ID:          "station-001",
+//		Language:    "en",
+//		Translation: "City Center",
+//	}
 //
 // would translate "station-001" to "City Center" in English.
 //
+// In the specification format, TableName and FieldName identify the field being
+// translated, and either RecordID (and optionally RecordSubID) or FieldValue
+// identifies the value being translated. For example, the following
+// Translation:
+//
+//	Translation{
+//		TableName:   "stops",
+//		FieldName:   "stop_name",
+//		RecordID:    "station-001",
+//		Language:    "fr",
+//		Translation: "Centre-ville",
+//	}
+//
+// would translate the name of the stop with ID "station-001" to "Centre-ville"
+// in French.
+//
 // Fields correspond directly to columns in translations.txt.
 type Translation struct {
 	ID          string
 	Language    string
 	Translation string
+
+	TableName   string
+	FieldName   string
+	RecordID    string
+	RecordSubID string
+	FieldValue  string
+}
+
+type translationRecordKey struct {
+	table, field, lang, recordID, recordSubID string
+}
+
+type translationValueKey struct {
+	table, field, lang, value string
 }
 
 // Translate translates a source string into the specified language.
 //
+// Only translations in the original Google extension format are considered.
 // If no translation is available, the original source string is returned.
 func (g *GTFS) Translate(sourceStr, lang string) string {
 	trs, ok := g.translationsByID[sourceStr]
@@ -39,10 +76,161 @@ func (g *GTFS) Translate(sourceStr, lang string) string {
 	return tr.Translation
 }
 
+// TranslateField translates the field with the specified column name (e.g.
+// "stop_name") of entity into the specified language.
+//
+// entity must be an *Agency, *Stop, *Route, *Trip, *FeedInfo, or *Attribution.
+// Translations matching the entity's ID take precedence over translations
+// matching the field's value, which in turn take precedence over translations
+// in the original Google extension format.
+//
+// If no translation is available, the original value is returned. If field is
+// not a translatable field of entity, an empty string is returned.
+func (g *GTFS) TranslateField(entity interface{}, field, lang string) string {
+	table, recordID, value, ok := translatableField(entity, field)
+	if !ok {
+		return value
+	}
+
+	return g.translate(table, field, recordID, "", value, lang)
+}
+
+// TranslateStopTimeField translates the field with the specified column name
+// (e.g. "stop_headsign") of s, which is part of trip t, into the specified
+// language.
+//
+// If no translation is available, the original value is returned. If field is
+// not a translatable field of s, an empty string is returned.
+func (g *GTFS) TranslateStopTimeField(t *Trip, s *StopTime, field, lang string) string {
+	if field != "stop_headsign" {
+		return ""
+	}
+
+	return g.translate("stop_times", field, t.ID, strconv.FormatUint(s.Sequence, 10), s.Headsign, lang)
+}
+
+// TranslateAgencyName translates the name of a into the specified language.
+func (g *GTFS) TranslateAgencyName(a *Agency, lang string) string {
+	return g.TranslateField(a, "agency_name", lang)
+}
+
+// TranslateStopName translates the name of s into the specified language.
+func (g *GTFS) TranslateStopName(s *Stop, lang string) string {
+	return g.TranslateField(s, "stop_name", lang)
+}
+
+// TranslateRouteShortName translates the short name of r into the specified
+// language.
+func (g *GTFS) TranslateRouteShortName(r *Route, lang string) string {
+	return g.TranslateField(r, "route_short_name", lang)
+}
+
+// TranslateRouteLongName translates the long name of r into the specified
+// language.
+func (g *GTFS) TranslateRouteLongName(r *Route, lang string) string {
+	return g.TranslateField(r, "route_long_name", lang)
+}
+
+// TranslateTripHeadsign translates the headsign of t into the specified
+// language.
+func (g *GTFS) TranslateTripHeadsign(t *Trip, lang string) string {
+	return g.TranslateField(t, "trip_headsign", lang)
+}
+
+func (g *GTFS) translate(table, field, recordID, recordSubID, value, lang string) string {
+	tr, ok := g.translationsByRecord[translationRecordKey{table, field, lang, recordID, recordSubID}]
+	if ok {
+		return tr.Translation
+	}
+
+	if value == "" {
+		return value
+	}
+
+	tr, ok = g.translationsByValue[translationValueKey{table, field, lang, value}]
+	if ok {
+		return tr.Translation
+	}
+
+	return g.Translate(value, lang)
+}
+
+// translatableField returns the table name, record ID, and value of the field
+// with the specified column name of entity, along with whether that field may
+// be translated.
+func translatableField(entity interface{}, field string) (string, string, string, bool) {
+	var table, recordID string
+	var values map[string]string
+
+	switch e := entity.(type) {
+	case *Agency:
+		table, recordID = "agency", e.ID
+		values = map[string]string{
+			"agency_name":     e.Name,
+			"agency_url":      e.URL,
+			"agency_phone":    e.Phone,
+			"agency_fare_url": e.FareURL,
+			"agency_email":    e.Email,
+		}
+	case *Stop:
+		table, recordID = "stops", e.ID
+		values = map[string]string{
+			"stop_code":     e.Code,
+			"stop_name":     e.Name,
+			"stop_desc":     e.Description,
+			"stop_url":      e.URL,
+			"platform_code": e.PlatformCode,
+		}
+	case *Route:
+		table, recordID = "routes", e.ID
+		values = map[string]string{
+			"route_short_name": e.ShortName,
+			"route_long_name":  e.LongName,
+			"route_desc":       e.Description,
+			"route_url":        e.URL,
+		}
+	case *Trip:
+		table, recordID = "trips", e.ID
+		values = map[string]string{
+			"trip_headsign":   e.Headsign,
+			"trip_short_name": e.ShortName,
+		}
+	case *Attribution:
+		table, recordID = "attributions", e.ID
+		values = map[string]string{
+			"organization_name": e.OrganizationName,
+			"attribution_url":   e.URL,
+			"attribution_email": e.Email,
+			"attribution_phone": e.Phone,
+		}
+	case *FeedInfo:
+		table = "feed_info"
+		values = map[string]string{
+			"feed_publisher_name": e.PublisherName,
+			"feed_publisher_url":  e.PublisherURL,
+			"feed_version":        e.Version,
+			"feed_contact_email":  e.ContactEmail,
+			"feed_contact_url":    e.ContactURL,
+		}
+	default:
+		return "", "", "", false
+	}
+
+	value, ok := values[field]
+
+	return table, recordID, value, ok
+}
+
 var translationFields = map[string]bool{
-	"trans_id":    true,
-	"lang":        true,
-	"translation": true,
+	"trans_id":      false,
+	"lang":          false,
+	"translation":   true,
+	"table_name":    false,
+	"field_name":    false,
+	"language":      false,
+	"record_id":     false,
+	"record_sub_id": false,
+	"field_value":   false,
 }
 
 func (g *GTFS) processTranslations(r io.Reader) error {
@@ -52,22 +240,76 @@ func (g *GTFS) processTranslations(r io.Reader) error {
 	}
 
 	g.translationsByID = map[string]map[string]*Translation{}
+	g.translationsByRecord = map[translationRecordKey]*Translation{}
+	g.translationsByValue = map[translationValueKey]*Translation{}
 
 	for _, row := range res {
+		if err := g.checkTranslationLanguage(row); err != nil {
+			return err
+		}
+
+		lang := row["language"]
+		if lang == "" {
+			lang = row["lang"]
+		}
+
 		t := &Translation{
 			ID:          row["trans_id"],
-			Language:    row["lang"],
+			Language:    lang,
 			Translation: row["translation"],
+			TableName:   row["table_name"],
+			FieldName:   row["field_name"],
+			RecordID:    row["record_id"],
+			RecordSubID: row["record_sub_id"],
+			FieldValue:  row["field_value"],
+		}
+
+		if err := g.indexTranslation(t); err != nil {
+			return err
 		}
 
 		g.Translations = append(g.Translations, t)
+	}
+
+	return nil
+}
+
+// checkTranslationLanguage ensures that, in strict mode, a row from
+// translations.txt has a language in the column used by its format: language
+// for rows referencing a table, and lang for rows in the original Google
+// extension format.
+func (g *GTFS) checkTranslationLanguage(row map[string]string) error {
+	if !g.strictMode {
+		return nil
+	}
+
+	if row["table_name"] != "" && row["language"] == "" {
+		return fmt.Errorf("translation of %s.%s has no language", row["table_name"], row["field_name"])
+	}
+
+	if row["table_name"] == "" && row["lang"] == "" {
+		return fmt.Errorf("translation %s has no lang", row["trans_id"])
+	}
+
+	return nil
+}
 
+// indexTranslation adds t to the lookup maps used when translating.
+func (g *GTFS) indexTranslation(t *Translation) error {
+	switch {
+	case t.TableName != "" && t.FieldValue != "":
+		g.translationsByValue[translationValueKey{t.TableName, t.FieldName, t.Language, t.FieldValue}] = t
+	case t.TableName != "":
+		g.translationsByRecord[translationRecordKey{t.TableName, t.FieldName, t.Language, t.RecordID, t.RecordSubID}] = t
+	case t.ID != "":
 		_, ok := g.translationsByID[t.ID]
 		if !ok {
 			g.translationsByID[t.ID] = map[string]*Translation{}
 		}
 
 		g.translationsByID[t.ID][t.Language] = t
+	default:
+		return fmt.Errorf("translation has neither table_name nor trans_id: %s", t.Translation)
 	}
 
 	return nil
@@ -240,3 +240,218 @@ func TestGTFS_processTranslations(t *testing.T) {
 		})
 	}
 }
+
+const testTranslationsCSVSpecFormat = `table_name,field_name,language,translation,record_id,record_sub_id,field_value
+stops,stop_name,fr,Gare Centrale,s1,,
+stops,stop_name,fr,Centre-ville,,,City Center
+stop_times,stop_headsign,fr,Aéroport,t1,2,
+feed_info,feed_publisher_name,fr,Éditeur,,,`
+
+const testTranslationsCSVInvalidMissingKey = `language,translation
+fr,Gare Centrale`
+
+func TestGTFS_processTranslations_specFormat(t *testing.T) {
+	g := &GTFS{}
+	if err := g.processTranslations(strings.NewReader(testTranslationsCSVSpecFormat)); err != nil {
+		t.Fatalf("GTFS.processTranslations() error = %v", err)
+	}
+
+	if len(g.Translations) != 4 {
+		t.Errorf("GTFS.processTranslations() len(Translations) = %d, want 4", len(g.Translations))
+	}
+	if len(g.translationsByRecord) != 3 {
+		t.Errorf("GTFS.processTranslations() len(translationsByRecord) = %d, want 3", len(g.translationsByRecord))
+	}
+	if len(g.translationsByValue) != 1 {
+		t.Errorf("GTFS.processTranslations() len(translationsByValue) = %d, want 1", len(g.translationsByValue))
+	}
+	if len(g.translationsByID) != 0 {
+		t.Errorf("GTFS.processTranslations() len(translationsByID) = %d, want 0", len(g.translationsByID))
+	}
+
+	g = &GTFS{}
+	if err := g.processTranslations(strings.NewReader(testTranslationsCSVInvalidMissingKey)); err == nil {
+		t.Errorf("GTFS.processTranslations() expected error for translation without table_name or trans_id")
+	}
+}
+
+func TestGTFS_checkTranslationLanguage(t *testing.T) {
+	tests := []struct {
+		name       string
+		strictMode bool
+		row        map[string]string
+		wantErr    bool
+	}{
+		{
+			name:       "Spec Format",
+			strictMode: true,
+			row:        map[string]string{"table_name": "stops", "field_name": "stop_name", "language": "fr"},
+			wantErr:    false,
+		},
+		{
+			name:       "Spec Format Without Language",
+			strictMode: true,
+			row:        map[string]string{"table_name": "stops", "field_name": "stop_name", "lang": "fr"},
+			wantErr:    true,
+		},
+		{
+			name:       "Google Format",
+			strictMode: true,
+			row:        map[string]string{"trans_id": "foo", "lang": "fr"},
+			wantErr:    false,
+		},
+		{
+			name:       "Google Format Without Lang",
+			strictMode: true,
+			row:        map[string]string{"trans_id": "foo", "language": "fr"},
+			wantErr:    true,
+		},
+		{
+			name:       "No Language (non-strict)",
+			strictMode: false,
+			row:        map[string]string{"trans_id": "foo"},
+			wantErr:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{
+				strictMode: tt.strictMode,
+			}
+			if err := g.checkTranslationLanguage(tt.row); (err != nil) != tt.wantErr {
+				t.Errorf("GTFS.checkTranslationLanguage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGTFS_TranslateField(t *testing.T) {
+	g := &GTFS{}
+	err := g.processTranslations(strings.NewReader(testTranslationsCSVSpecFormat + "\nroutes,route_long_name,fr,Ligne Rouge,,,Red Line"))
+	if err != nil {
+		t.Fatalf("GTFS.processTranslations() error = %v", err)
+	}
+	g.translationsByID["Blue Line"] = map[string]*Translation{
+		"fr": {ID: "Blue Line", Language: "fr", Translation: "Ligne Bleue"},
+	}
+
+	tests := []struct {
+		name   string
+		entity interface{}
+		field  string
+		lang   string
+		want   string
+	}{
+		{
+			name:   "Record Match",
+			entity: &Stop{ID: "s1", Name: "City Center"},
+			field:  "stop_name",
+			lang:   "fr",
+			want:   "Gare Centrale",
+		},
+		{
+			name:   "Value Match",
+			entity: &Stop{ID: "s2", Name: "City Center"},
+			field:  "stop_name",
+			lang:   "fr",
+			want:   "Centre-ville",
+		},
+		{
+			name:   "Legacy Match",
+			entity: &Route{ID: "r2", LongName: "Blue Line"},
+			field:  "route_long_name",
+			lang:   "fr",
+			want:   "Ligne Bleue",
+		},
+		{
+			name:   "No Match",
+			entity: &Route{ID: "r3", LongName: "Green Line"},
+			field:  "route_long_name",
+			lang:   "fr",
+			want:   "Green Line",
+		},
+		{
+			name:   "Other Language",
+			entity: &Stop{ID: "s1", Name: "City Center"},
+			field:  "stop_name",
+			lang:   "de",
+			want:   "City Center",
+		},
+		{
+			name:   "Feed Info",
+			entity: &FeedInfo{PublisherName: "Publisher"},
+			field:  "feed_publisher_name",
+			lang:   "fr",
+			want:   "Éditeur",
+		},
+		{
+			name:   "Untranslatable Field",
+			entity: &Stop{ID: "s1", Name: "City Center"},
+			field:  "stop_lat",
+			lang:   "fr",
+			want:   "",
+		},
+		{
+			name:   "Unsupported Entity",
+			entity: &Shape{ID: "sh1"},
+			field:  "shape_id",
+			lang:   "fr",
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.TranslateField(tt.entity, tt.field, tt.lang); got != tt.want {
+				t.Errorf("GTFS.TranslateField() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := g.TranslateStopName(&Stop{ID: "s1", Name: "City Center"}, "fr"); got != "Gare Centrale" {
+		t.Errorf("GTFS.TranslateStopName() = %v, want %v", got, "Gare Centrale")
+	}
+	if got := g.TranslateRouteLongName(&Route{ID: "r1", LongName: "Red Line"}, "fr"); got != "Ligne Rouge" {
+		t.Errorf("GTFS.TranslateRouteLongName() = %v, want %v", got, "Ligne Rouge")
+	}
+}
+
+func TestGTFS_TranslateStopTimeField(t *testing.T) {
+	g := &GTFS{}
+	if err := g.processTranslations(strings.NewReader(testTranslationsCSVSpecFormat)); err != nil {
+		t.Fatalf("GTFS.processTranslations() error = %v", err)
+	}
+
+	trip := &Trip{ID: "t1"}
+	tests := []struct {
+		name     string
+		stopTime *StopTime
+		field    string
+		want     string
+	}{
+		{
+			name:     "Match",
+			stopTime: &StopTime{Sequence: 2, Headsign: "Airport"},
+			field:    "stop_headsign",
+			want:     "Aéroport",
+		},
+		{
+			name:     "Different Sequence",
+			stopTime: &StopTime{Sequence: 3, Headsign: "Airport"},
+			field:    "stop_headsign",
+			want:     "Airport",
+		},
+		{
+			name:     "Untranslatable Field",
+			stopTime: &StopTime{Sequence: 2, Headsign: "Airport"},
+			field:    "arrival_time",
+			want:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.TranslateStopTimeField(trip, tt.stopTime, tt.field, "fr"); got != tt.want {
+				t.Errorf("GTFS.TranslateStopTimeField() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
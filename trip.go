@@ -5,6 +5,7 @@ import (
 	"io"
 	"sort"
 	"strconv"
+	"time"
 )
 
 // A Trip is a trip along a route with schedule information.
@@ -23,17 +24,30 @@ type Trip struct { // nolint: maligned
 	WheelchairAccessible WheelchairAccessible
 	BikesAllowed         BikesAllowed
 
-	AbsoluteTimes  bool
+	AbsoluteTimes bool
+	Frequencies   []Frequency
+	Stops         []*StopTime
+
+	Exceptional bool
+}
+
+// A Frequency is a period during which a frequency-based trip runs at a
+// regular headway.
+//
+// Fields correspond directly to columns in frequencies.txt.
+type Frequency struct {
 	StartTime      string
 	EndTime        string
 	HeadwaySeconds uint64
 	ExactTimes     bool
-	Stops          []*StopTime
-
-	Exceptional bool
 }
 
 // StopTime provides details on a specific stop in a trip.
+//
+// For demand-responsive (GTFS-Flex) service, a StopTime may reference a
+// Location or LocationGroup instead of a Stop, in which case riders are served
+// anywhere within it between StartPickupDropOffWindow and
+// EndPickupDropOffWindow.
 type StopTime struct {
 	Stop                  *Stop
 	ArrivalTime           string
@@ -44,6 +58,16 @@ type StopTime struct {
 	DropoffType           DropoffType
 	ShapeDistanceTraveled float64
 	Timepoint             TimepointType
+	ContinuousPickup      ContinuousPickup
+	ContinuousDropOff     ContinuousDropOff
+
+	// GTFS-Flex:
+	Location                 *Location
+	LocationGroup            *LocationGroup
+	StartPickupDropOffWindow string
+	EndPickupDropOffWindow   string
+	PickupBookingRule        *BookingRule
+	DropOffBookingRule       *BookingRule
 }
 
 // WheelchairAccessible indicates whether a trip is accessible to passengers in
@@ -130,6 +154,63 @@ const (
 	TimepointTypeApproximate
 )
 
+// ContinuousPickup indicates whether riders may board anywhere along a
+// vehicle's path, rather than only at stops.
+type ContinuousPickup int
+
+const (
+	// ContinuousPickupUnspecified indicates that no continuous pickup behavior
+	// was specified.
+	//
+	// On a StopTime, this means that the behavior of the trip's route applies.
+	ContinuousPickupUnspecified ContinuousPickup = iota
+
+	// ContinuousPickupContinuous indicates that continuous stopping pickup is
+	// available.
+	ContinuousPickupContinuous
+
+	// ContinuousPickupNone indicates that no continuous stopping pickup is
+	// available.
+	ContinuousPickupNone
+
+	// ContinuousPickupPhoneAgency indicates that riders must phone the transit
+	// agency to arrange continuous stopping pickup.
+	ContinuousPickupPhoneAgency
+
+	// ContinuousPickupCoordinateWithDriver indicates that riders must
+	// coordinate with the vehicle driver to arrange continuous stopping pickup.
+	ContinuousPickupCoordinateWithDriver
+)
+
+// ContinuousDropOff indicates whether riders may alight anywhere along a
+// vehicle's path, rather than only at stops.
+type ContinuousDropOff int
+
+const (
+	// ContinuousDropOffUnspecified indicates that no continuous drop off
+	// behavior was specified.
+	//
+	// On a StopTime, this means that the behavior of the trip's route applies.
+	ContinuousDropOffUnspecified ContinuousDropOff = iota
+
+	// ContinuousDropOffContinuous indicates that continuous stopping drop off
+	// is available.
+	ContinuousDropOffContinuous
+
+	// ContinuousDropOffNone indicates that no continuous stopping drop off is
+	// available.
+	ContinuousDropOffNone
+
+	// ContinuousDropOffPhoneAgency indicates that riders must phone the transit
+	// agency to arrange continuous stopping drop off.
+	ContinuousDropOffPhoneAgency
+
+	// ContinuousDropOffCoordinateWithDriver indicates that riders must
+	// coordinate with the vehicle driver to arrange continuous stopping drop
+	// off.
+	ContinuousDropOffCoordinateWithDriver
+)
+
 var tripFields = map[string]bool{
 	"route_id":              true,
 	"service_id":            true,
@@ -148,15 +229,25 @@ var tripFields = map[string]bool{
 
 var stopTimeFields = map[string]bool{
 	"trip_id":             true,
-	"arrival_time":        true,
-	"departure_time":      true,
-	"stop_id":             true,
+	"arrival_time":        false,
+	"departure_time":      false,
+	"stop_id":             false,
 	"stop_sequence":       true,
 	"stop_headsign":       false,
 	"pickup_type":         false,
 	"drop_off_type":       false,
 	"shape_dist_traveled": false,
 	"timepoint":           false,
+	"continuous_pickup":   false,
+	"continuous_drop_off": false,
+
+	// GTFS-Flex:
+	"location_group_id":            false,
+	"location_id":                  false,
+	"start_pickup_drop_off_window": false,
+	"end_pickup_drop_off_window":   false,
+	"pickup_booking_rule_id":       false,
+	"drop_off_booking_rule_id":     false,
 }
 
 var frequencyFields = map[string]bool{
@@ -251,6 +342,20 @@ func (g *GTFS) processStopTimes(r io.Reader) error {
 			return err
 		}
 
+		continuousPickup, err := parseContinuousPickup(row["continuous_pickup"], ContinuousPickupUnspecified)
+		if err != nil {
+			return err
+		}
+
+		continuousDropOff, err := parseContinuousDropOff(row["continuous_drop_off"], ContinuousDropOffUnspecified)
+		if err != nil {
+			return err
+		}
+
+		if err := g.checkStopTimeLocation(row); err != nil {
+			return err
+		}
+
 		s := &StopTime{
 			Stop:                  g.stopByID(row["stop_id"]),
 			ArrivalTime:           row["arrival_time"],
@@ -261,6 +366,15 @@ func (g *GTFS) processStopTimes(r io.Reader) error {
 			DropoffType:           dropoffType,
 			ShapeDistanceTraveled: dist,
 			Timepoint:             timepointType,
+			ContinuousPickup:      continuousPickup,
+			ContinuousDropOff:     continuousDropOff,
+
+			Location:                 g.locationByID(row["location_id"]),
+			LocationGroup:            g.locationGroupByID(row["location_group_id"]),
+			StartPickupDropOffWindow: row["start_pickup_drop_off_window"],
+			EndPickupDropOffWindow:   row["end_pickup_drop_off_window"],
+			PickupBookingRule:        g.bookingRuleByID(row["pickup_booking_rule_id"]),
+			DropOffBookingRule:       g.bookingRuleByID(row["drop_off_booking_rule_id"]),
 		}
 
 		stopsByTrip[row["trip_id"]] = append(stopsByTrip[row["trip_id"]], s)
@@ -282,6 +396,24 @@ func (g *GTFS) processStopTimes(r io.Reader) error {
 	return nil
 }
 
+// checkStopTimeLocation ensures that a row from stop_times.txt references at
+// least one of a stop, location group, or location, and in strict mode that it
+// references exactly one.
+func (g *GTFS) checkStopTimeLocation(row map[string]string) error {
+	refs := 0
+	for _, field := range []string{"stop_id", "location_group_id", "location_id"} {
+		if row[field] != "" {
+			refs++
+		}
+	}
+
+	if refs == 0 || (g.strictMode && refs != 1) {
+		return fmt.Errorf("expected exactly one of stop_id, location_group_id, or location_id for trip %s, but got %d", row["trip_id"], refs)
+	}
+
+	return nil
+}
+
 func (g *GTFS) processFrequencies(r io.Reader) error {
 	res, err := readCSVWithHeadings(r, frequencyFields, g.strictMode)
 	if err != nil {
@@ -310,15 +442,137 @@ func (g *GTFS) processFrequencies(r io.Reader) error {
 		}
 
 		t.AbsoluteTimes = false
-		t.StartTime = row["start_time"]
-		t.EndTime = row["end_time"]
-		t.HeadwaySeconds = headwaySecs
-		t.ExactTimes = exactTimes
+		t.Frequencies = append(t.Frequencies, Frequency{
+			StartTime:      row["start_time"],
+			EndTime:        row["end_time"],
+			HeadwaySeconds: headwaySecs,
+			ExactTimes:     exactTimes,
+		})
 	}
 
 	return nil
 }
 
+// InstanceOffsets returns, for each time that t operates during a service day,
+// the amount of time that must be added to the times in t.Stops to obtain the
+// times of that instance, in ascending order.
+//
+// For trips with absolute times, the only offset is zero. For trips defined in
+// frequencies.txt, there is one offset for each departure between the
+// StartTime (inclusive) and EndTime (exclusive) of each of t.Frequencies.
+func (t *Trip) InstanceOffsets() ([]time.Duration, error) {
+	if t.AbsoluteTimes {
+		return []time.Duration{0}, nil
+	}
+
+	if len(t.Stops) == 0 || len(t.Frequencies) == 0 {
+		return nil, fmt.Errorf("invalid frequency-based trip: %s", t.ID)
+	}
+
+	first, err := t.Stops[0].DepartureOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []time.Duration
+	for _, f := range t.Frequencies {
+		offsets, err := f.offsets(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid frequency-based trip %s: %v", t.ID, err)
+		}
+
+		res = append(res, offsets...)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+
+	return res, nil
+}
+
+// offsets returns the instance offsets of the runs of a trip during f, given
+// the departure time of the trip's first stop.
+func (f *Frequency) offsets(first time.Duration) ([]time.Duration, error) {
+	if f.HeadwaySeconds == 0 {
+		return nil, fmt.Errorf("zero headway from %s", f.StartTime)
+	}
+
+	start, err := ParseTime(f.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := ParseTime(f.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	headway := time.Duration(f.HeadwaySeconds) * time.Second
+
+	var res []time.Duration
+	for d := start; d < end; d += headway {
+		res = append(res, d-first)
+	}
+
+	return res, nil
+}
+
+// WheelchairAccessibleAt returns whether a passenger in a wheelchair may board
+// or exit t at s, which must be one of t's stops.
+//
+// Both t's accessibility and the effective wheelchair boarding value of s's
+// stop are considered. If either indicates that boarding is impossible,
+// WheelchairAccessibleNo is returned; if both indicate that it is possible,
+// WheelchairAccessibleYes is returned.
+func (t *Trip) WheelchairAccessibleAt(s *StopTime) WheelchairAccessible {
+	boarding := WheelchairBoardingUnknown
+	if s.Stop != nil {
+		boarding = s.Stop.EffectiveWheelchairBoarding()
+	}
+
+	switch {
+	case t.WheelchairAccessible == WheelchairAccessibleNo || boarding == WheelchairBoardingNo:
+		return WheelchairAccessibleNo
+	case t.WheelchairAccessible == WheelchairAccessibleYes && boarding == WheelchairBoardingYes:
+		return WheelchairAccessibleYes
+	default:
+		return WheelchairAccessibleUnknown
+	}
+}
+
+// EffectiveContinuousPickup returns the continuous pickup behavior that applies
+// from s to the next stop on a trip along route r.
+//
+// Values specified on s override the default behavior of r.
+func (s *StopTime) EffectiveContinuousPickup(r *Route) ContinuousPickup {
+	if s.ContinuousPickup != ContinuousPickupUnspecified {
+		return s.ContinuousPickup
+	}
+
+	if r == nil || r.ContinuousPickup == ContinuousPickupUnspecified {
+		return ContinuousPickupNone
+	}
+
+	return r.ContinuousPickup
+}
+
+// EffectiveContinuousDropOff returns the continuous drop off behavior that
+// applies from s to the next stop on a trip along route r.
+//
+// Values specified on s override the default behavior of r.
+func (s *StopTime) EffectiveContinuousDropOff(r *Route) ContinuousDropOff {
+	if s.ContinuousDropOff != ContinuousDropOffUnspecified {
+		return s.ContinuousDropOff
+	}
+
+	if r == nil || r.ContinuousDropOff == ContinuousDropOffUnspecified {
+		return ContinuousDropOffNone
+	}
+
+	return r.ContinuousDropOff
+}
+
 func (g *GTFS) tripByID(id string) *Trip {
 	return g.tripsByID[id]
 }
@@ -390,6 +644,44 @@ func parseTimepointType(val string) (TimepointType, error) {
 	}
 }
 
+// parseContinuousPickup parses a continuous_pickup value, returning unset if
+// no value is present.
+func parseContinuousPickup(val string, unset ContinuousPickup) (ContinuousPickup, error) {
+	switch val {
+	case "":
+		return unset, nil
+	case "0":
+		return ContinuousPickupContinuous, nil
+	case "1":
+		return ContinuousPickupNone, nil
+	case "2":
+		return ContinuousPickupPhoneAgency, nil
+	case "3":
+		return ContinuousPickupCoordinateWithDriver, nil
+	default:
+		return unset, fmt.Errorf("invalid continuous pickup: %s", val)
+	}
+}
+
+// parseContinuousDropOff parses a continuous_drop_off value, returning unset if
+// no value is present.
+func parseContinuousDropOff(val string, unset ContinuousDropOff) (ContinuousDropOff, error) {
+	switch val {
+	case "":
+		return unset, nil
+	case "0":
+		return ContinuousDropOffContinuous, nil
+	case "1":
+		return ContinuousDropOffNone, nil
+	case "2":
+		return ContinuousDropOffPhoneAgency, nil
+	case "3":
+		return ContinuousDropOffCoordinateWithDriver, nil
+	default:
+		return unset, fmt.Errorf("invalid continuous drop off: %s", val)
+	}
+}
+
 func parseExceptional(val string) (bool, error) {
 	switch val {
 	case "0", "":
@@ -2,7 +2,9 @@ package gtfs
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func Test_parseBikesAllowed(t *testing.T) {
@@ -428,3 +430,497 @@ func TestGTFS_tripByID(t *testing.T) {
 		})
 	}
 }
+
+func TestGTFS_checkStopTimeLocation(t *testing.T) {
+	type fields struct {
+		strictMode bool
+	}
+	type args struct {
+		row map[string]string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "Stop (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				row: map[string]string{"stop_id": "1"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Location (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				row: map[string]string{"stop_id": "", "location_id": "zone-1"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "None (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				row: map[string]string{},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Multiple (strict)",
+			fields: fields{
+				strictMode: true,
+			},
+			args: args{
+				row: map[string]string{"stop_id": "1", "location_group_id": "lg-1"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "None (non-strict)",
+			fields: fields{
+				strictMode: false,
+			},
+			args: args{
+				row: map[string]string{"stop_id": "", "arrival_time": "", "departure_time": ""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Multiple (non-strict)",
+			fields: fields{
+				strictMode: false,
+			},
+			args: args{
+				row: map[string]string{"stop_id": "1", "location_group_id": "lg-1"},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &GTFS{
+				strictMode: tt.fields.strictMode,
+			}
+			if err := g.checkStopTimeLocation(tt.args.row); (err != nil) != tt.wantErr {
+				t.Errorf("GTFS.checkStopTimeLocation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_parseContinuousPickup(t *testing.T) {
+	type args struct {
+		val   string
+		unset ContinuousPickup
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    ContinuousPickup
+		wantErr bool
+	}{
+		{
+			name: "Empty (stop time)",
+			args: args{
+				val:   "",
+				unset: ContinuousPickupUnspecified,
+			},
+			want:    ContinuousPickupUnspecified,
+			wantErr: false,
+		},
+		{
+			name: "Empty (route)",
+			args: args{
+				val:   "",
+				unset: ContinuousPickupNone,
+			},
+			want:    ContinuousPickupNone,
+			wantErr: false,
+		},
+		{
+			name: "Continuous",
+			args: args{
+				val: "0",
+			},
+			want:    ContinuousPickupContinuous,
+			wantErr: false,
+		},
+		{
+			name: "None",
+			args: args{
+				val: "1",
+			},
+			want:    ContinuousPickupNone,
+			wantErr: false,
+		},
+		{
+			name: "Phone Agency",
+			args: args{
+				val: "2",
+			},
+			want:    ContinuousPickupPhoneAgency,
+			wantErr: false,
+		},
+		{
+			name: "Coordinate With Driver",
+			args: args{
+				val: "3",
+			},
+			want:    ContinuousPickupCoordinateWithDriver,
+			wantErr: false,
+		},
+		{
+			name: "Invalid",
+			args: args{
+				val:   "4",
+				unset: ContinuousPickupNone,
+			},
+			want:    ContinuousPickupNone,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseContinuousPickup(tt.args.val, tt.args.unset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseContinuousPickup() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseContinuousPickup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseContinuousDropOff(t *testing.T) {
+	type args struct {
+		val   string
+		unset ContinuousDropOff
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    ContinuousDropOff
+		wantErr bool
+	}{
+		{
+			name: "Empty (stop time)",
+			args: args{
+				val:   "",
+				unset: ContinuousDropOffUnspecified,
+			},
+			want:    ContinuousDropOffUnspecified,
+			wantErr: false,
+		},
+		{
+			name: "Empty (route)",
+			args: args{
+				val:   "",
+				unset: ContinuousDropOffNone,
+			},
+			want:    ContinuousDropOffNone,
+			wantErr: false,
+		},
+		{
+			name: "Continuous",
+			args: args{
+				val: "0",
+			},
+			want:    ContinuousDropOffContinuous,
+			wantErr: false,
+		},
+		{
+			name: "None",
+			args: args{
+				val: "1",
+			},
+			want:    ContinuousDropOffNone,
+			wantErr: false,
+		},
+		{
+			name: "Phone Agency",
+			args: args{
+				val: "2",
+			},
+			want:    ContinuousDropOffPhoneAgency,
+			wantErr: false,
+		},
+		{
+			name: "Coordinate With Driver",
+			args: args{
+				val: "3",
+			},
+			want:    ContinuousDropOffCoordinateWithDriver,
+			wantErr: false,
+		},
+		{
+			name: "Invalid",
+			args: args{
+				val:   "foo",
+				unset: ContinuousDropOffNone,
+			},
+			want:    ContinuousDropOffNone,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseContinuousDropOff(tt.args.val, tt.args.unset)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseContinuousDropOff() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseContinuousDropOff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopTime_EffectiveContinuousPickup(t *testing.T) {
+	testRoute := &Route{
+		ContinuousPickup: ContinuousPickupContinuous,
+	}
+	tests := []struct {
+		name     string
+		stopTime *StopTime
+		route    *Route
+		want     ContinuousPickup
+	}{
+		{
+			name:     "Inherited From Route",
+			stopTime: &StopTime{},
+			route:    testRoute,
+			want:     ContinuousPickupContinuous,
+		},
+		{
+			name: "Overridden By Stop Time",
+			stopTime: &StopTime{
+				ContinuousPickup: ContinuousPickupNone,
+			},
+			route: testRoute,
+			want:  ContinuousPickupNone,
+		},
+		{
+			name:     "No Route",
+			stopTime: &StopTime{},
+			route:    nil,
+			want:     ContinuousPickupNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stopTime.EffectiveContinuousPickup(tt.route); got != tt.want {
+				t.Errorf("StopTime.EffectiveContinuousPickup() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStopTime_EffectiveContinuousDropOff(t *testing.T) {
+	testRoute := &Route{
+		ContinuousDropOff: ContinuousDropOffPhoneAgency,
+	}
+	tests := []struct {
+		name     string
+		stopTime *StopTime
+		route    *Route
+		want     ContinuousDropOff
+	}{
+		{
+			name:     "Inherited From Route",
+			stopTime: &StopTime{},
+			route:    testRoute,
+			want:     ContinuousDropOffPhoneAgency,
+		},
+		{
+			name: "Overridden By Stop Time",
+			stopTime: &StopTime{
+				ContinuousDropOff: ContinuousDropOffCoordinateWithDriver,
+			},
+			route: testRoute,
+			want:  ContinuousDropOffCoordinateWithDriver,
+		},
+		{
+			name:     "Unspecified Route",
+			stopTime: &StopTime{},
+			route:    &Route{},
+			want:     ContinuousDropOffNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stopTime.EffectiveContinuousDropOff(tt.route); got != tt.want {
+				t.Errorf("StopTime.EffectiveContinuousDropOff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrip_WheelchairAccessibleAt(t *testing.T) {
+	station := &Stop{
+		LocationType:       LocationTypeStation,
+		WheelchairBoarding: WheelchairBoardingYes,
+	}
+	accessibleStop := &StopTime{
+		Stop: &Stop{
+			ParentStation: station,
+		},
+	}
+	inaccessibleStop := &StopTime{
+		Stop: &Stop{
+			WheelchairBoarding: WheelchairBoardingNo,
+		},
+	}
+	unknownStop := &StopTime{
+		Stop: &Stop{},
+	}
+	tests := []struct {
+		name     string
+		trip     *Trip
+		stopTime *StopTime
+		want     WheelchairAccessible
+	}{
+		{
+			name:     "Both Accessible",
+			trip:     &Trip{WheelchairAccessible: WheelchairAccessibleYes},
+			stopTime: accessibleStop,
+			want:     WheelchairAccessibleYes,
+		},
+		{
+			name:     "Inaccessible Stop",
+			trip:     &Trip{WheelchairAccessible: WheelchairAccessibleYes},
+			stopTime: inaccessibleStop,
+			want:     WheelchairAccessibleNo,
+		},
+		{
+			name:     "Inaccessible Trip",
+			trip:     &Trip{WheelchairAccessible: WheelchairAccessibleNo},
+			stopTime: accessibleStop,
+			want:     WheelchairAccessibleNo,
+		},
+		{
+			name:     "Unknown Stop",
+			trip:     &Trip{WheelchairAccessible: WheelchairAccessibleYes},
+			stopTime: unknownStop,
+			want:     WheelchairAccessibleUnknown,
+		},
+		{
+			name:     "Unknown Trip",
+			trip:     &Trip{},
+			stopTime: accessibleStop,
+			want:     WheelchairAccessibleUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.trip.WheelchairAccessibleAt(tt.stopTime); got != tt.want {
+				t.Errorf("Trip.WheelchairAccessibleAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGTFS_processFrequencies(t *testing.T) {
+	trip := &Trip{ID: "trip", AbsoluteTimes: true}
+	g := &GTFS{tripsByID: map[string]*Trip{"trip": trip}}
+
+	r := strings.NewReader("trip_id,start_time,end_time,headway_secs,exact_times\n" +
+		"trip,06:00:00,09:00:00,300,1\n" +
+		"trip,09:00:00,22:00:00,900,\n")
+	if err := g.processFrequencies(r); err != nil {
+		t.Fatalf("GTFS.processFrequencies() error = %v", err)
+	}
+
+	want := []Frequency{
+		{StartTime: "06:00:00", EndTime: "09:00:00", HeadwaySeconds: 300, ExactTimes: true},
+		{StartTime: "09:00:00", EndTime: "22:00:00", HeadwaySeconds: 900},
+	}
+	if trip.AbsoluteTimes || !reflect.DeepEqual(trip.Frequencies, want) {
+		t.Errorf("GTFS.processFrequencies() trip = %+v, want frequencies %+v", trip, want)
+	}
+}
+
+func TestTrip_InstanceOffsets(t *testing.T) {
+	stops := []*StopTime{
+		{ArrivalTime: "06:00:00", DepartureTime: "06:00:00"},
+		{ArrivalTime: "06:10:00", DepartureTime: "06:10:00"},
+	}
+	tests := []struct {
+		name    string
+		trip    *Trip
+		want    []time.Duration
+		wantErr bool
+	}{
+		{
+			name: "Absolute Times",
+			trip: &Trip{
+				AbsoluteTimes: true,
+				Stops:         stops,
+			},
+			want:    []time.Duration{0},
+			wantErr: false,
+		},
+		{
+			name: "Frequency-Based",
+			trip: &Trip{
+				Frequencies: []Frequency{{StartTime: "08:00:00", EndTime: "08:30:00", HeadwaySeconds: 600}},
+				Stops:       stops,
+			},
+			want:    []time.Duration{2 * time.Hour, 2*time.Hour + 10*time.Minute, 2*time.Hour + 20*time.Minute},
+			wantErr: false,
+		},
+		{
+			name: "Multiple Frequencies",
+			trip: &Trip{
+				Frequencies: []Frequency{
+					{StartTime: "09:00:00", EndTime: "10:00:00", HeadwaySeconds: 1800},
+					{StartTime: "08:00:00", EndTime: "08:30:00", HeadwaySeconds: 600},
+				},
+				Stops: stops,
+			},
+			want:    []time.Duration{2 * time.Hour, 2*time.Hour + 10*time.Minute, 2*time.Hour + 20*time.Minute, 3 * time.Hour, 3*time.Hour + 30*time.Minute},
+			wantErr: false,
+		},
+		{
+			name: "Invalid Start Time",
+			trip: &Trip{
+				Frequencies: []Frequency{{StartTime: "foo", EndTime: "08:30:00", HeadwaySeconds: 600}},
+				Stops:       stops,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "No Headway",
+			trip: &Trip{
+				Frequencies: []Frequency{{StartTime: "08:00:00", EndTime: "08:30:00"}},
+				Stops:       stops,
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.trip.InstanceOffsets()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Trip.InstanceOffsets() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Trip.InstanceOffsets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
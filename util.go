@@ -3,6 +3,7 @@ package gtfs
 import (
 	"fmt"
 	"io"
+	"strconv"
 )
 
 type rcOpener interface {
@@ -29,3 +30,19 @@ func parseBool(val string) (bool, error) {
 		return false, fmt.Errorf("invalid value: %s", val)
 	}
 }
+
+func parseOptionalUint(val string) (uint64, error) {
+	if val == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(val, 10, 64)
+}
+
+func parseOptionalBool(val string) (bool, error) {
+	if val == "" {
+		return false, nil
+	}
+
+	return parseBool(val)
+}
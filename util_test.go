@@ -106,3 +106,83 @@ func Test_parseBool(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseOptionalBool(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "Empty",
+			val:     "",
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:    "True",
+			val:     "1",
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:    "Invalid",
+			val:     "2",
+			want:    false,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOptionalBool(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseOptionalBool() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseOptionalBool() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseOptionalUint(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    uint64
+		wantErr bool
+	}{
+		{
+			name:    "Empty",
+			val:     "",
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name:    "Valid",
+			val:     "42",
+			want:    42,
+			wantErr: false,
+		},
+		{
+			name:    "Invalid",
+			val:     "-1",
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOptionalUint(tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseOptionalUint() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseOptionalUint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}